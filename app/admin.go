@@ -0,0 +1,412 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"filmography/config"
+	"filmography/internal/entities"
+	"filmography/internal/repository/memory"
+	"filmography/service"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// migrator is a storage backend that keeps its schema in versioned
+// migrations.
+type migrator interface {
+	Migrate() (*migrate.Migrate, error)
+}
+
+var migrateCommand = &cli.Command{
+	Name:  "migrate",
+	Usage: "manage the schema of the configured storage backend",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "up",
+			Usage: "apply pending migrations",
+			Flags: []cli.Flag{
+				&cli.IntFlag{Name: "steps", Usage: "apply at most `N` migrations, all when 0"},
+			},
+			Action: func(c *cli.Context) error {
+				return withMigrate(func(m *migrate.Migrate) error {
+					if steps := c.Int("steps"); steps > 0 {
+						return m.Steps(steps)
+					}
+					return m.Up()
+				})
+			},
+		},
+		{
+			Name:  "down",
+			Usage: "roll back applied migrations",
+			Flags: []cli.Flag{
+				&cli.IntFlag{Name: "steps", Value: 1, Usage: "roll back `N` migrations"},
+				&cli.BoolFlag{Name: "all", Usage: "roll back every migration"},
+			},
+			Action: func(c *cli.Context) error {
+				return withMigrate(func(m *migrate.Migrate) error {
+					if c.Bool("all") {
+						return m.Down()
+					}
+					return m.Steps(-c.Int("steps"))
+				})
+			},
+		},
+		{
+			Name:  "status",
+			Usage: "print the current schema version",
+			Action: func(c *cli.Context) error {
+				return withMigrate(func(m *migrate.Migrate) error {
+					version, dirty, err := m.Version()
+					if errors.Is(err, migrate.ErrNilVersion) {
+						fmt.Fprintln(c.App.Writer, "version: none")
+						return nil
+					}
+					if err != nil {
+						return err
+					}
+					fmt.Fprintf(c.App.Writer, "version: %d\ndirty: %t\n", version, dirty)
+					return nil
+				})
+			},
+		},
+		{
+			Name:      "force",
+			Usage:     "set the schema version without running migrations, clears the dirty flag",
+			ArgsUsage: "VERSION",
+			Action: func(c *cli.Context) error {
+				version, err := strconv.Atoi(c.Args().First())
+				if err != nil {
+					return fmt.Errorf("version must be a number: %w", err)
+				}
+				return withMigrate(func(m *migrate.Migrate) error {
+					return m.Force(version)
+				})
+			},
+		},
+	},
+}
+
+var seedCommand = &cli.Command{
+	Name:  "seed",
+	Usage: "load actors, films and users from a fixture file",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "file", Required: true, Usage: "fixture `FILE` in JSON"},
+	},
+	Action: func(c *cli.Context) error {
+		fixture, err := service.LoadFixture(c.String("file"))
+		if err != nil {
+			return fmt.Errorf("load fixture failed: %w", err)
+		}
+		return withService(false, func(svc service.Service, _ config.Config) error {
+			return svc.Seed(c.Context, fixture)
+		})
+	},
+}
+
+var importCommand = &cli.Command{
+	Name:  "import",
+	Usage: "load films with their credits, genres and tags and actors from JSON, CSV or JSON Lines",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "file", Required: true, Usage: "`FILE` to import"},
+		&cli.StringFlag{Name: "format", Usage: "json, csv or ndjson, guessed from the file extension when not given"},
+		&cli.BoolFlag{Name: "dry-run", Usage: "check the file without storing anything"},
+		&cli.IntFlag{Name: "batch-size", Usage: "films per transaction, IMPORT_BATCH_SIZE when not given"},
+	},
+	Action: func(c *cli.Context) error {
+		file, err := os.Open(c.String("file"))
+		if err != nil {
+			return fmt.Errorf("open failed: %w", err)
+		}
+		defer file.Close()
+
+		format := service.FileFormat(c.String("format"))
+		if format == "" {
+			format = fileFormats[strings.ToLower(filepath.Ext(file.Name()))]
+		}
+
+		return withService(false, func(svc service.Service, cfg config.Config) error {
+			options := service.ImportOptions{
+				Format:    format,
+				DryRun:    c.Bool("dry-run"),
+				BatchSize: cfg.ImportBatchSize,
+			}
+			if c.IsSet("batch-size") {
+				options.BatchSize = c.Int("batch-size")
+			}
+
+			report, err := svc.Import(c.Context, file, options)
+			if err != nil {
+				return err
+			}
+
+			for _, e := range report.Errors {
+				fmt.Fprintf(c.App.ErrWriter, "line %d: %s", e.Line, e.Message)
+				for _, field := range e.Fields {
+					fmt.Fprintf(c.App.ErrWriter, ", %s %s", field.Field, field.Message)
+				}
+				fmt.Fprintln(c.App.ErrWriter)
+			}
+			fmt.Fprintf(c.App.Writer, "rows: %d, imported: %d, failed: %d, dry run: %t\n", report.Rows, report.Imported, report.Failed, report.DryRun)
+			if report.Failed > 0 {
+				return fmt.Errorf("%d of %d rows failed", report.Failed, report.Rows)
+			}
+			return nil
+		})
+	},
+}
+
+var exportCommand = &cli.Command{
+	Name:  "export",
+	Usage: "write the catalogue in a form the import command accepts",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "file", Usage: "`FILE` to write, stdout when not given"},
+		&cli.StringFlag{Name: "format", Usage: "json, csv or ndjson, guessed from the file extension when not given"},
+		&cli.StringFlag{Name: "entity", Value: string(service.ExportFilms), Usage: "films, actors or all"},
+	},
+	Action: func(c *cli.Context) error {
+		options := service.ExportOptions{
+			Format: service.FileFormat(c.String("format")),
+			Entity: service.ExportEntity(c.String("entity")),
+		}
+		if options.Format == "" {
+			options.Format = fileFormats[strings.ToLower(filepath.Ext(c.String("file")))]
+		}
+		err := service.ValidateExport(&options)
+		if err != nil {
+			return err
+		}
+
+		out := c.App.Writer
+		if c.String("file") != "" {
+			file, err := os.Create(c.String("file"))
+			if err != nil {
+				return fmt.Errorf("create failed: %w", err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		return withService(false, func(svc service.Service, _ config.Config) error {
+			return svc.Export(c.Context, out, options)
+		})
+	},
+}
+
+// fileFormats maps file extensions to their format.
+var fileFormats = map[string]service.FileFormat{
+	".json":   service.FormatJSON,
+	".csv":    service.FormatCSV,
+	".ndjson": service.FormatJSONLines,
+	".jsonl":  service.FormatJSONLines,
+}
+
+var userCommand = &cli.Command{
+	Name:  "user",
+	Usage: "manage user accounts",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "create",
+			Usage: "create a user, the password is read from stdin when not given",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "username", Required: true},
+				&cli.StringFlag{Name: "password"},
+				&cli.StringFlag{Name: "role", Value: string(entities.User), Usage: "user or admin"},
+			},
+			Action: func(c *cli.Context) error {
+				password, err := passwordFlag(c)
+				if err != nil {
+					return err
+				}
+				return withService(false, func(svc service.Service, _ config.Config) error {
+					user := entities.UserEntity{
+						Username: c.String("username"),
+						Role:     entities.Role(c.String("role")),
+					}
+					return svc.CreateUser(c.Context, user, password)
+				})
+			},
+		},
+		{
+			Name:  "list",
+			Usage: "print all users",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "role", Usage: "only users with this role"},
+			},
+			Action: func(c *cli.Context) error {
+				return withService(false, func(svc service.Service, _ config.Config) error {
+					w := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+					fmt.Fprintln(w, "ID\tUSERNAME\tROLE")
+
+					query := entities.UserQuery{Role: entities.Role(c.String("role"))}
+					query.Limit = 100
+					for {
+						users, total, err := svc.GetUsers(c.Context, query)
+						if err != nil {
+							return err
+						}
+						for _, user := range users {
+							fmt.Fprintf(w, "%s\t%s\t%s\n", user.ID, user.Username, user.Role)
+						}
+						query.Offset += len(users)
+						if len(users) == 0 || query.Offset >= total {
+							break
+						}
+					}
+					return w.Flush()
+				})
+			},
+		},
+		{
+			Name:  "set-role",
+			Usage: "change the role of a user",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "username", Required: true},
+				&cli.StringFlag{Name: "role", Required: true, Usage: "user or admin"},
+			},
+			Action: func(c *cli.Context) error {
+				return withService(false, func(svc service.Service, _ config.Config) error {
+					return svc.SetRole(c.Context, c.String("username"), entities.Role(c.String("role")))
+				})
+			},
+		},
+		{
+			Name:  "reset-password",
+			Usage: "set a new password, it is read from stdin when not given",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "username", Required: true},
+				&cli.StringFlag{Name: "password"},
+			},
+			Action: func(c *cli.Context) error {
+				password, err := passwordFlag(c)
+				if err != nil {
+					return err
+				}
+				return withService(false, func(svc service.Service, _ config.Config) error {
+					return svc.ResetPassword(c.Context, c.String("username"), password)
+				})
+			},
+		},
+	},
+}
+
+var tokenCommand = &cli.Command{
+	Name:  "token",
+	Usage: "manage access tokens",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "issue",
+			Usage: "print a fresh access token for a user",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "username", Required: true},
+			},
+			Action: func(c *cli.Context) error {
+				return withService(true, func(svc service.Service, _ config.Config) error {
+					token, err := svc.IssueToken(c.Context, c.String("username"))
+					if err != nil {
+						return err
+					}
+					fmt.Fprintln(c.App.Writer, token.Access)
+					return nil
+				})
+			},
+		},
+	},
+}
+
+// withService opens the configured storage, runs fn and closes it again.
+// The token store is opened only when tokens is set, other commands get an
+// in-process one so that they work without Redis.
+func withService(tokens bool, fn func(svc service.Service, cfg config.Config) error) error {
+	cfg, err := config.New()
+	if err != nil {
+		return fmt.Errorf("config new failed: %w", err)
+	}
+
+	repo, err := NewRepo(cfg)
+	if err != nil {
+		return fmt.Errorf("repository new failed: %w", err)
+	}
+	defer func() {
+		err := repo.Close()
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("repo close failed")
+		}
+	}()
+
+	var cache Cache = memory.NewTokenStore()
+	if tokens {
+		cache, err = NewCache(cfg)
+	}
+	if err != nil {
+		return fmt.Errorf("cache new failed: %w", err)
+	}
+	defer func() {
+		err := cache.Close()
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("cache close failed")
+		}
+	}()
+
+	return fn(service.New(repo, cache, cfg), cfg)
+}
+
+// withMigrate opens the configured storage without applying migrations and
+// runs fn on its migrator. Closing the migrator closes the storage too.
+func withMigrate(fn func(m *migrate.Migrate) error) error {
+	cfg, err := config.New()
+	if err != nil {
+		return fmt.Errorf("config new failed: %w", err)
+	}
+	cfg.AutoMigrate = false
+
+	repo, err := NewRepo(cfg)
+	if err != nil {
+		return fmt.Errorf("repository new failed: %w", err)
+	}
+	source, ok := repo.(migrator)
+	if !ok {
+		repo.Close()
+		return fmt.Errorf("storage driver %s has no migrations", cfg.StorageDriver)
+	}
+
+	m, err := source.Migrate()
+	if err != nil {
+		repo.Close()
+		return fmt.Errorf("migrate new failed: %w", err)
+	}
+	defer m.Close()
+
+	err = fn(m)
+	if errors.Is(err, migrate.ErrNoChange) {
+		logrus.Info("no change")
+		return nil
+	}
+	return err
+}
+
+// passwordFlag returns the --password flag or the first line of stdin so
+// that passwords stay out of the shell history.
+func passwordFlag(c *cli.Context) (string, error) {
+	if password := c.String("password"); password != "" {
+		return password, nil
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("read password failed: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
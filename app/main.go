@@ -2,13 +2,11 @@ package main
 
 import (
 	"errors"
-	"filmography/config"
-	"filmography/internal/handlers"
-	"filmography/internal/repository"
-	"filmography/internal/repository/redis"
 	"filmography/service"
+	"os"
+
 	"github.com/sirupsen/logrus"
-	"net/http"
+	"github.com/urfave/cli/v2"
 )
 
 // @title Filmography web-application
@@ -18,60 +16,34 @@ import (
 // @host localhost:8080
 // @BasePath /
 
-// @securityDefinition.apikey ApiKeyAuth
+// @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
 
 func main() {
-	cfg, err := config.New()
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"error": err,
-		}).Fatal("config new failed")
+	app := &cli.App{
+		Name:   "filmography",
+		Usage:  "filmography web-application and its admin tools",
+		Action: serve,
+		Commands: []*cli.Command{
+			serveCommand,
+			migrateCommand,
+			seedCommand,
+			importCommand,
+			exportCommand,
+			userCommand,
+			tokenCommand,
+		},
 	}
 
-	repo, err := repository.New(cfg)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
+	if err := app.Run(os.Args); err != nil {
+		fields := logrus.Fields{
 			"error": err,
-		}).Fatal("repository new failed")
-	}
-	defer func() {
-		err := repo.Close()
-		if err != nil {
-			logrus.WithFields(logrus.Fields{
-				"error": err,
-			}).Fatal("repo close failed")
 		}
-	}()
-
-	cache, err := redis.New(cfg)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"error": err,
-		}).Fatal("redis new failed")
-	}
-
-	svc := service.New(repo, cache, cfg)
-	handlersEngine, err := handlers.SetRequestHandlers(svc, cfg)
-	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"error": err,
-		}).Fatal("set request handlers failed")
-	}
-
-	srv := &Server{}
-	go func() {
-		if err := srv.Run(handlersEngine); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			logrus.WithFields(logrus.Fields{
-				"error": err,
-			}).Error("server run failed")
-			return
+		var svcErr *service.Error
+		if errors.As(err, &svcErr) && len(svcErr.Fields) > 0 {
+			fields["fields"] = svcErr.Fields
 		}
-	}()
-	if err := srv.WaitForShutDown(); err != nil {
-		logrus.WithFields(logrus.Fields{
-			"error": err,
-		}).Error("server shut down failed")
+		logrus.WithFields(fields).Fatal("command failed")
 	}
 }
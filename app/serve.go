@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"filmography/config"
+	"filmography/internal/handlers"
+	"filmography/service"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+var serveCommand = &cli.Command{
+	Name:   "serve",
+	Usage:  "run the HTTP server, the default when no command is given",
+	Action: serve,
+}
+
+func serve(c *cli.Context) error {
+	return withService(true, func(svc service.Service, cfg config.Config) error {
+		if cfg.SeedFile != "" {
+			fixture, err := service.LoadFixture(cfg.SeedFile)
+			if err != nil {
+				return fmt.Errorf("load fixture failed: %w", err)
+			}
+			err = svc.Seed(c.Context, fixture)
+			if err != nil {
+				return fmt.Errorf("seed failed: %w", err)
+			}
+		}
+
+		err := svc.BootstrapAdmin(c.Context)
+		if err != nil {
+			return fmt.Errorf("bootstrap admin failed: %w", err)
+		}
+
+		handlersEngine, err := handlers.SetRequestHandlers(svc, cfg)
+		if err != nil {
+			return fmt.Errorf("set request handlers failed: %w", err)
+		}
+
+		srv := &Server{}
+		go func() {
+			if err := srv.Run(handlersEngine); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logrus.WithFields(logrus.Fields{
+					"error": err,
+				}).Error("server run failed")
+				return
+			}
+		}()
+		if err := srv.WaitForShutDown(); err != nil {
+			return fmt.Errorf("server shut down failed: %w", err)
+		}
+		return nil
+	})
+}
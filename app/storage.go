@@ -0,0 +1,47 @@
+package main
+
+import (
+	"filmography/config"
+	"filmography/internal/repository"
+	"filmography/internal/repository/memory"
+	"filmography/internal/repository/redis"
+	"filmography/internal/repository/sqlite"
+	"filmography/service"
+	"fmt"
+)
+
+// Repo is a storage backend of the service that holds resources until it
+// is closed.
+type Repo interface {
+	service.Repo
+	Close() error
+}
+
+// Cache is a token store of the service that holds resources until it is
+// closed.
+type Cache interface {
+	service.Cache
+	Close() error
+}
+
+// NewRepo opens the storage backend selected by cfg.StorageDriver.
+func NewRepo(cfg config.Config) (Repo, error) {
+	switch cfg.StorageDriver {
+	case "postgres":
+		return repository.New(cfg)
+	case "sqlite":
+		return sqlite.New(cfg)
+	case "memory":
+		return memory.New(), nil
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+}
+
+// NewCache opens the token store, the memory driver keeps tokens in
+// process instead of Redis.
+func NewCache(cfg config.Config) (Cache, error) {
+	if cfg.StorageDriver == "memory" {
+		return memory.NewTokenStore(), nil
+	}
+	return redis.New(cfg)
+}
@@ -9,13 +9,31 @@ import (
 type Config struct {
 	Env string `env:"ENV"`
 
+	// StorageDriver selects the repository: postgres, sqlite or memory.
+	// The memory driver also keeps tokens in process instead of Redis.
+	StorageDriver     string `env:"STORAGE_DRIVER" env-default:"postgres"`
+	SQLitePath        string `env:"SQLITE_PATH" env-default:"filmography.db"`
+	SQLiteMigratePath string `env:"SQLITE_MIGRATE_PATH" env-default:"file://internal/repository/sqlite/migrations"`
+	// SeedFile is a JSON fixture loaded into the store on start.
+	SeedFile string `env:"SEED_FILE"`
+	// ImportBatchSize is the number of films stored per transaction by
+	// the bulk import.
+	ImportBatchSize int `env:"IMPORT_BATCH_SIZE" env-default:"100"`
+	// RatingPriorWeight is the number of average votes every film starts
+	// with in its Bayesian weighted user rating, 0 leaves the score out.
+	RatingPriorWeight float64 `env:"RATING_PRIOR_WEIGHT" env-default:"0"`
+
 	PostgresDBUsername string `env:"POSTGRES_DB_USERNAME"`
 	PostgresDBPassword string `env:"POSTGRES_DB_PASSWORD"`
 	PostgresDBHost     string `env:"POSTGRES_DB_HOST"`
 	PostgresDBName     string `env:"POSTGRES_DB_NAME"`
 	MigratePath        string `env:"MIGRATE_PATH"`
+	// AutoMigrate applies pending migrations whenever the repository is
+	// opened, turn it off to manage them with the migrate command only.
+	AutoMigrate bool `env:"AUTO_MIGRATE" env-default:"true"`
 
-	ServerHost string `env:"SERVER_HOST"`
+	ServerHost     string `env:"SERVER_HOST"`
+	RequestTimeout int    `env:"REQUEST_TIMEOUT" env-default:"30"`
 
 	AdminLogin string `env:"ADMIN_LOGIN"`
 	AdminPass  string `env:"ADMIN_PASS"`
@@ -17,7 +17,12 @@ const docTemplate = `{
     "paths": {
         "/actor": {
             "get": {
-                "description": "Возвращает список всех актеров.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу актеров с учетом фильтров и сортировки.",
                 "produces": [
                     "application/json"
                 ],
@@ -25,25 +30,85 @@ const docTemplate = `{
                     "Actor"
                 ],
                 "summary": "Возвращает список актеров",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Размер страницы (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "-birthday",
+                        "description": "Поля сортировки: name, gender, birthday; минус для убывания",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Пол",
+                        "name": "gender",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Родившиеся до даты (YYYY-MM-DD)",
+                        "name": "born_before",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "Список актеров",
+                        "description": "Страница актеров",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/entities.ActorEntity"
-                            }
+                            "$ref": "#/definitions/handlers.ListResponse-entities_ActorEntity"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "500": {
                         "description": "Ошибка при получении актеров",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
             },
             "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
                 "description": "Создает нового актера на основе переданных данных.",
                 "consumes": [
                     "application/json"
@@ -79,13 +144,31 @@ const docTemplate = `{
                     "400": {
                         "description": "Ошибка при декодировании JSON",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "500": {
                         "description": "Ошибка при создании актера",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
@@ -93,6 +176,11 @@ const docTemplate = `{
         },
         "/actor/{id}": {
             "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
                 "description": "Возвращает информацию об актере по указанному ID.",
                 "produces": [
                     "application/json"
@@ -106,8 +194,14 @@ const docTemplate = `{
                         "type": "string",
                         "description": "ID актера",
                         "name": "id",
-                        "in": "query",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -115,17 +209,49 @@ const docTemplate = `{
                         "description": "Информация об актере",
                         "schema": {
                             "$ref": "#/definitions/entities.ActorEntity"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Хеш ответа, меняется с любым его полем"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Актер не изменился"
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Актер не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "500": {
                         "description": "Ошибка при получении актера",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
             },
             "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
                 "description": "Обновляет информацию об актере с указанным ID на основе переданных данных.",
                 "consumes": [
                     "application/json"
@@ -142,9 +268,15 @@ const docTemplate = `{
                         "type": "string",
                         "description": "ID актера",
                         "name": "id",
-                        "in": "query",
+                        "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее; без заголовка запрос безусловный",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
                     {
                         "description": "Данные актера",
                         "name": "actor",
@@ -168,18 +300,53 @@ const docTemplate = `{
                     "400": {
                         "description": "Ошибка при декодировании JSON",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Актер не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Актер изменился после получения ETag",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "500": {
                         "description": "Ошибка при обновлении актера",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
             },
             "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
                 "description": "Удаляет актера с указанным ID.",
                 "tags": [
                     "Actor"
@@ -190,8 +357,14 @@ const docTemplate = `{
                         "type": "string",
                         "description": "ID актера",
                         "name": "id",
-                        "in": "query",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее; без заголовка запрос безусловный",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -204,161 +377,238 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Актер не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Актер изменился после получения ETag",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
                     "500": {
                         "description": "Ошибка при удалении актера",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
-            }
-        },
-        "/admin/auth/logout": {
-            "post": {
+            },
+            "patch": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Allows a user to log out and invalidate their access token.",
+                "description": "Применяет JSON Merge Patch (RFC 7396) или JSON Patch (RFC 6902) к актеру в том виде, в котором его возвращает GET /actor/{id}. Результат проверяется так же, как при создании, и сохраняется целиком или не сохраняется вовсе.",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Actor"
                 ],
-                "summary": "User logout",
-                "responses": {
-                    "200": {
-                        "description": "Logout successful",
-                        "schema": {
-                            "type": "string"
-                        }
+                "summary": "Частично обновляет актера",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID актера",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее; без заголовка запрос безусловный",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Патч актера",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
                         "schema": {
-                            "type": "string"
+                            "type": "object"
                         }
                     }
-                }
-            }
-        },
-        "/admin/auth/refresh": {
-            "get": {
-                "description": "Allows refreshing an access token using a valid refresh token.",
-                "produces": [
-                    "application/json"
                 ],
-                "tags": [
-                    "Auth"
-                ],
-                "summary": "Refresh access token",
                 "responses": {
                     "200": {
-                        "description": "New access token response",
+                        "description": "Актер после изменения",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "$ref": "#/definitions/entities.ActorEntity"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Хеш ответа, меняется с любым его полем"
                             }
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Некорректный патч",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "Требуется токен доступа",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "403": {
-                        "description": "Forbidden",
+                        "description": "Недостаточно прав",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Актер не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Патч не применим: нет пути или не прошла операция test",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Актер изменился после получения ETag",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "415": {
+                        "description": "Неизвестный формат патча",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Ошибка при обновлении актера",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
             }
         },
-        "/admin/auth/signin": {
-            "post": {
-                "description": "Allows a user to sign in with their credentials.",
-                "consumes": [
-                    "application/json"
+        "/actor/{id}/credits": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Возвращает все фильмы человека с указанным ID вместе с ролями: режиссер, сценарист, продюсер, композитор или актер. Отсортированы по дате выхода.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Actor"
                 ],
-                "summary": "User sign-in",
+                "summary": "Возвращает титры человека",
                 "parameters": [
                     {
-                        "description": "User credentials",
-                        "name": "credentials",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/entities.Auth"
-                        }
+                        "type": "string",
+                        "description": "ID человека",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Access token response",
+                        "description": "Титры человека",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entities.PersonCredit"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad request",
+                    "401": {
+                        "description": "Требуется токен доступа",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "403": {
-                        "description": "Forbidden",
+                        "description": "Недостаточно прав",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Человек не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Ошибка при получении титров",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
             }
         },
-        "/film": {
+        "/actor/{id}/films": {
             "get": {
-                "description": "Возвращает список всех фильмов.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает фильмы, в которых снимался актер с указанным ID, отсортированные по дате выхода.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Film"
+                    "Actor"
+                ],
+                "summary": "Возвращает фильмы актера",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID актера",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Возвращает список фильмов",
                 "responses": {
                     "200": {
-                        "description": "Список фильмов",
+                        "description": "Список фильмов актера",
                         "schema": {
                             "type": "array",
                             "items": {
@@ -366,98 +616,120 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Ошибка при получении фильмов",
+                    "401": {
+                        "description": "Требуется токен доступа",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
-                    }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Актер не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении фильмов актера",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
                 }
-            },
+            }
+        },
+        "/auth/logout": {
             "post": {
-                "description": "Создает новый фильм на основе переданных данных.",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Allows a user to log out and invalidate their access token.\nThe old path POST /auth/logout/ still works but is deprecated, its responses carry the Deprecation header.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Film"
+                    "Auth"
                 ],
-                "summary": "Создает фильм.",
-                "parameters": [
-                    {
-                        "description": "Данные фильма",
-                        "name": "film",
-                        "in": "body",
-                        "required": true,
+                "summary": "User logout",
+                "responses": {
+                    "200": {
+                        "description": "Logout successful",
                         "schema": {
-                            "$ref": "#/definitions/entities.FilmEntity"
+                            "type": "string"
                         }
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Created",
+                    },
+                    "401": {
+                        "description": "Access token required",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
-                    "400": {
-                        "description": "Ошибка при декодировании JSON",
+                    "403": {
+                        "description": "Invalid access token",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "500": {
-                        "description": "Ошибка при создании фильма",
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
             }
         },
-        "/film/{id}": {
-            "get": {
-                "description": "Возвращает информацию о фильме по указанному ID.",
+        "/auth/refresh": {
+            "post": {
+                "description": "Rotates the refresh token cookie and returns a new access token.\nEach refresh token is single-use, reusing one revokes all tokens of its family.\nThe new tokens carry the current role of the user, the tokens of a deleted user are revoked.\nGET /auth/refresh and GET /auth/refresh/ still work but are deprecated, their responses carry the Deprecation header.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Film"
-                ],
-                "summary": "Возвращает информацию о фильме",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "ID фильма",
-                        "name": "id",
-                        "in": "query",
-                        "required": true
-                    }
+                    "Auth"
                 ],
+                "summary": "Refresh access token",
                 "responses": {
                     "200": {
-                        "description": "Информация о фильме",
+                        "description": "New access token response",
                         "schema": {
-                            "$ref": "#/definitions/entities.FilmEntity"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Missing, expired, revoked or reused refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Invalid refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "500": {
-                        "description": "Ошибка при получении фильма",
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Обновляет информацию о фильме с указанным ID на основе переданных данных.",
+            }
+        },
+        "/auth/sign-in": {
+            "post": {
+                "description": "Allows a user to sign in with their credentials.\nThe old path POST /auth/sing-in/ still works but is deprecated, its responses carry the Deprecation header.",
                 "consumes": [
                     "application/json"
                 ],
@@ -465,30 +737,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Film"
+                    "Auth"
                 ],
-                "summary": "Обновляет информацию о фильме",
+                "summary": "User sign-in",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "ID фильма",
-                        "name": "id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "description": "Данные фильма",
-                        "name": "film",
+                        "description": "User credentials",
+                        "name": "credentials",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/entities.FilmEntity"
+                            "$ref": "#/definitions/entities.Auth"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "Access token response",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -497,83 +762,29 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Ошибка при декодировании JSON",
+                        "description": "Malformed request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка при обновлении фильма",
-                        "schema": {
-                            "type": "string"
-                        }
-                    }
-                }
-            },
-            "delete": {
-                "description": "Удаляет фильм с указанным ID.",
-                "tags": [
-                    "Film"
-                ],
-                "summary": "Удаляет фильм",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "ID фильма",
-                        "name": "id",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    "401": {
+                        "description": "Wrong login or password",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "500": {
-                        "description": "Ошибка при удалении фильма",
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
             }
         },
-        "/user": {
-            "get": {
-                "description": "Возвращает список всех юзеров.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "User"
-                ],
-                "summary": "Возвращает список юзеров",
-                "responses": {
-                    "200": {
-                        "description": "Список юзеров",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/entities.UserEntity"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Ошибка при получении юзеров",
-                        "schema": {
-                            "type": "string"
-                        }
-                    }
-                }
-            },
+        "/auth/sign-up": {
             "post": {
-                "description": "Создает нового юзера на основе переданных данных.",
+                "description": "Registers a new account with the user role.\nThe old path POST /auth/sign-up/ still works but is deprecated, its responses carry the Deprecation header.",
                 "consumes": [
                     "application/json"
                 ],
@@ -581,17 +792,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Auth"
                 ],
-                "summary": "Создает юзера.",
+                "summary": "User sign-up",
                 "parameters": [
                     {
-                        "description": "Данные юзера",
-                        "name": "user",
+                        "description": "User credentials",
+                        "name": "credentials",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/entities.UserEntity"
+                            "$ref": "#/definitions/entities.Auth"
                         }
                     }
                 ],
@@ -606,150 +817,4601 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Ошибка при декодировании JSON",
+                        "description": "Malformed request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "User already exists",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid username or password",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "500": {
-                        "description": "Ошибка при создании юзера",
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
             }
         },
-        "/user/{id}": {
+        "/export": {
             "get": {
-                "description": "Возвращает информацию о юзере по указанному ID.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Выгружает фильмы с составом, съемочной группой, жанрами и тегами и/или актеров в JSON, CSV или NDJSON. Строки передаются клиенту по мере чтения из базы. Файл можно загрузить обратно через POST /import. CSV содержит одну сущность, поэтому entity=all для него недоступен.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
                 ],
                 "tags": [
-                    "User"
+                    "Import"
                 ],
-                "summary": "Возвращает информацию о юзере",
+                "summary": "Выгрузка каталога",
                 "parameters": [
                     {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson"
+                        ],
                         "type": "string",
-                        "description": "ID юзера",
-                        "name": "id",
-                        "in": "query",
-                        "required": true
+                        "default": "json",
+                        "description": "Формат файла",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "films",
+                            "actors",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "films",
+                        "description": "Что выгружать",
+                        "name": "entity",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Информация о юзере",
+                        "description": "Фильмы и актеры",
                         "schema": {
-                            "$ref": "#/definitions/entities.UserEntity"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/service.FilmRecord"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "500": {
-                        "description": "Ошибка при получении юзере",
+                        "description": "Ошибка при выгрузке",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Обновляет информацию о юзере с указанным ID на основе переданных данных.",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/film": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Возвращает страницу фильмов с учетом фильтров и сортировки.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Film"
                 ],
-                "summary": "Обновляет информацию о юзере",
+                "summary": "Возвращает список фильмов",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "ID юзера",
-                        "name": "id",
-                        "in": "query",
-                        "required": true
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Размер страницы (1-100)",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
-                        "description": "Данные юзера",
-                        "name": "user",
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "rating,-release_date",
+                        "description": "Поля сортировки: title, rating, release_date; минус для убывания",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Минимальный рейтинг",
+                        "name": "min_rating",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Вышедшие после даты (YYYY-MM-DD)",
+                        "name": "released_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID жанров через запятую",
+                        "name": "genre",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Теги через запятую",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "Фильм должен иметь любой или все из жанров и тегов",
+                        "name": "match",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница фильмов",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ListResponse-entities_FilmEntity"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении фильмов",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создает новый фильм на основе переданных данных. Актеры, участники съемочной группы и жанры задаются по ID, теги — произвольные слова, приводятся к нижнему регистру. Актеры без записи в credits попадают в титры без порядка.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Film"
+                ],
+                "summary": "Создает фильм.",
+                "parameters": [
+                    {
+                        "description": "Данные фильма",
+                        "name": "film",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/entities.UserEntity"
+                            "$ref": "#/definitions/entities.FilmEntity"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка при декодировании JSON",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации, неизвестный участник или жанр",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при создании фильма",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/film/facets": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Считает фильмы, подходящие под фильтры GET /film, по каждому жанру и по самым частым тегам.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Film"
+                ],
+                "summary": "Возвращает фасеты фильмов",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Минимальный рейтинг",
+                        "name": "min_rating",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Вышедшие после даты (YYYY-MM-DD)",
+                        "name": "released_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID жанров через запятую",
+                        "name": "genre",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Теги через запятую",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "Фильм должен иметь любой или все из жанров и тегов",
+                        "name": "match",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Число фильмов по жанрам и тегам",
+                        "schema": {
+                            "$ref": "#/definitions/service.FilmFacets"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при подсчете фильмов",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/film/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает информацию о фильме по указанному ID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Film"
+                ],
+                "summary": "Возвращает информацию о фильме",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID фильма",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Информация о фильме",
+                        "schema": {
+                            "$ref": "#/definitions/entities.FilmEntity"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Хеш ответа без пользовательского рейтинга"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Фильм не изменился"
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Фильм не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении фильма",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Обновляет информацию о фильме с указанным ID на основе переданных данных.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Film"
+                ],
+                "summary": "Обновляет информацию о фильме",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID фильма",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее; без заголовка запрос безусловный",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные фильма",
+                        "name": "film",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.FilmEntity"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
+                    },
+                    "400": {
+                        "description": "Ошибка при декодировании JSON",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Фильм не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Фильм изменился после получения ETag",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации, неизвестный участник или жанр",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при обновлении фильма",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет фильм с указанным ID.",
+                "tags": [
+                    "Film"
+                ],
+                "summary": "Удаляет фильм",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID фильма",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее; без заголовка запрос безусловный",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Фильм не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Фильм изменился после получения ETag",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении фильма",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Применяет JSON Merge Patch (RFC 7396) или JSON Patch (RFC 6902) к фильму в том виде, в котором его возвращает GET /film/{id}. Результат проверяется так же, как при создании, и сохраняется целиком или не сохраняется вовсе. Актеры, убранные из Actors или Credits, убираются из состава.",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Film"
+                ],
+                "summary": "Частично обновляет фильм",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID фильма",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее; без заголовка запрос безусловный",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Патч фильма",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Фильм после изменения",
+                        "schema": {
+                            "$ref": "#/definitions/entities.FilmEntity"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Хеш ответа без пользовательского рейтинга"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный патч",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Фильм не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Патч не применим: нет пути или не прошла операция test",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Фильм изменился после получения ETag",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "415": {
+                        "description": "Неизвестный формат патча",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации, неизвестный участник или жанр",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при обновлении фильма",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/film/{id}/actors": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает актеров, снимавшихся в фильме с указанным ID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Film"
+                ],
+                "summary": "Возвращает актеров фильма",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID фильма",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список актеров фильма",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entities.ActorEntity"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Фильм не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении актеров фильма",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/film/{id}/credits": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает всех участников фильма с указанным ID: режиссеров, сценаристов, продюсеров, композиторов и актеров. Актеры упорядочены по порядку в титрах.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Film"
+                ],
+                "summary": "Возвращает титры фильма",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID фильма",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "director",
+                            "writer",
+                            "producer",
+                            "composer",
+                            "actor"
+                        ],
+                        "type": "string",
+                        "description": "Роль участника",
+                        "name": "role",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Титры фильма",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entities.CreditEntity"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Фильм не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Неизвестная роль",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении титров фильма",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/film/{id}/rating": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает оценку, которую текущий юзер поставил фильму с указанным ID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rating"
+                ],
+                "summary": "Возвращает оценку фильма",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID фильма",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Оценка фильма",
+                        "schema": {
+                            "$ref": "#/definitions/entities.FilmRating"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Оценка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении оценки",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Ставит фильму оценку текущего юзера от 0 до 10, повторная оценка заменяет прежнюю.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rating"
+                ],
+                "summary": "Оценивает фильм",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID фильма",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Оценка",
+                        "name": "rating",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RateFilmRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Оценка и сводка оценок фильма",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RateFilmResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка при декодировании JSON",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Фильм не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при сохранении оценки",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/film/{id}/reviews": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу опубликованных отзывов на фильм, по умолчанию сначала новые.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Review"
+                ],
+                "summary": "Возвращает отзывы на фильм",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID фильма",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Размер страницы (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "-created_at",
+                        "description": "Поля сортировки: created_at, updated_at; минус для убывания",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница отзывов",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ListResponse-entities_ReviewEntity"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Фильм не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении отзывов",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Добавляет отзыв текущего юзера на фильм, отзыв публикуется после модерации. Юзер может оставить один отзыв на фильм.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Review"
+                ],
+                "summary": "Добавляет отзыв",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID фильма",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Текст отзыва",
+                        "name": "review",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Отзыв",
+                        "schema": {
+                            "$ref": "#/definitions/entities.ReviewEntity"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка при декодировании JSON",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Фильм не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Юзер уже оставил отзыв на фильм",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при добавлении отзыва",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/genre": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает все жанры по алфавиту с числом фильмов каждого жанра.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genre"
+                ],
+                "summary": "Возвращает список жанров",
+                "responses": {
+                    "200": {
+                        "description": "Жанры",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entities.GenreCount"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении жанров",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создает жанр с уникальным без учета регистра названием.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genre"
+                ],
+                "summary": "Создает жанр",
+                "parameters": [
+                    {
+                        "description": "Данные жанра",
+                        "name": "genre",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GenreRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Жанр",
+                        "schema": {
+                            "$ref": "#/definitions/entities.GenreEntity"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка при декодировании JSON",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Жанр с таким названием уже есть",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при создании жанра",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/genre/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает жанр с указанным ID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genre"
+                ],
+                "summary": "Возвращает жанр",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID жанра",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Жанр",
+                        "schema": {
+                            "$ref": "#/definitions/entities.GenreEntity"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Жанр не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении жанра",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Переименовывает жанр с указанным ID, фильмы остаются в жанре.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genre"
+                ],
+                "summary": "Переименовывает жанр",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID жанра",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные жанра",
+                        "name": "genre",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GenreRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка при декодировании JSON",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Жанр не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Жанр с таким названием уже есть",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при обновлении жанра",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет жанр с указанным ID, фильмы жанра остаются в каталоге.",
+                "tags": [
+                    "Genre"
+                ],
+                "summary": "Удаляет жанр",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID жанра",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Жанр не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении жанра",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Загружает фильмы с составом, съемочной группой, жанрами и тегами и актеров из JSON, CSV или JSON Lines в формате GET /export. Люди ищутся по имени и дате рождения, жанры по названию, и создаются, если их нет, фильм с уже существующим id заменяется. Если в записи нет credits, genres или tags, сохраненные значения остаются. Фильмы сохраняются пачками в транзакциях, ошибочные строки пропускаются и перечисляются в отчете. CSV с фильмами содержит колонки id, title, description, release_date, rating, cast, credits, genres, tags, где cast — список name|gender|birthday через точку с запятой, credits — список role|name|gender|birthday|character|billing, genres и tags — названия через точку с запятой. CSV с актерами — колонки id, name, gender, birthday. В JSON актеры отличаются полем type.",
+                "consumes": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Import"
+                ],
+                "summary": "Массовый импорт фильмов",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Формат файла, по умолчанию из Content-Type",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Проверить файл, ничего не сохраняя",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отчет об импорте",
+                        "schema": {
+                            "$ref": "#/definitions/service.ImportReport"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Неизвестный формат или неверный заголовок CSV",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при импорте",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу просмотров текущего юзера, по умолчанию сначала последние.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watch"
+                ],
+                "summary": "Возвращает историю просмотров",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID фильма",
+                        "name": "film_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Размер страницы (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "-watched_at",
+                        "description": "Поля сортировки: watched_at, rating, title; минус для убывания",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница просмотров",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ListResponse-entities_HistoryEntry"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении истории",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Отмечает фильм просмотренным текущим юзером в указанный день, по умолчанию сегодня, с необязательной личной оценкой. Просмотренный фильм убирается из списка к просмотру.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watch"
+                ],
+                "summary": "Добавляет просмотр",
+                "parameters": [
+                    {
+                        "description": "Просмотр",
+                        "name": "entry",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HistoryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Просмотр",
+                        "schema": {
+                            "$ref": "#/definitions/entities.HistoryEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка при декодировании JSON",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Фильм не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при добавлении просмотра",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/history/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Выгружает историю просмотров текущего юзера в JSON, CSV или NDJSON, сначала старые.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "Watch"
+                ],
+                "summary": "Выгрузка истории просмотров",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Формат файла",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "История просмотров",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/service.HistoryRecord"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при выгрузке",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/history/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает просмотр текущего юзера по указанному ID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watch"
+                ],
+                "summary": "Возвращает просмотр",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID просмотра",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Просмотр",
+                        "schema": {
+                            "$ref": "#/definitions/entities.HistoryEntry"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Просмотр не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении просмотра",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Изменяет дату и личную оценку просмотра текущего юзера, фильм не меняется.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watch"
+                ],
+                "summary": "Изменяет просмотр",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID просмотра",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Дата и оценка просмотра",
+                        "name": "entry",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HistoryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Просмотр",
+                        "schema": {
+                            "$ref": "#/definitions/entities.HistoryEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка при декодировании JSON",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Просмотр не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при изменении просмотра",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет просмотр текущего юзера из истории.",
+                "tags": [
+                    "Watch"
+                ],
+                "summary": "Удаляет просмотр",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID просмотра",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Просмотр не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении просмотра",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/ratings": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу всех оценок текущего юзера, начиная с последней, включая замененные.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rating"
+                ],
+                "summary": "Возвращает историю оценок",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Размер страницы (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID фильма",
+                        "name": "film_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница оценок",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ListResponse-entities_FilmRating"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении оценок",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/reviews": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу отзывов текущего юзера в любом статусе, сначала новые.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Review"
+                ],
+                "summary": "Возвращает свои отзывы",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Статус: pending, published, rejected",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Размер страницы (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "-created_at",
+                        "description": "Поля сортировки: created_at, updated_at; минус для убывания",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница отзывов",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ListResponse-entities_ReviewEntity"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении отзывов",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/watchlist": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу списка фильмов к просмотру текущего юзера, по умолчанию в порядке юзера.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watch"
+                ],
+                "summary": "Возвращает список к просмотру",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Размер страницы (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "position",
+                        "description": "Поля сортировки: position, added_at, title, release_date; минус для убывания",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница списка",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ListResponse-entities_WatchlistEntry"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении списка",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/watchlist/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Выгружает список к просмотру текущего юзера в JSON, CSV или NDJSON в порядке юзера.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "Watch"
+                ],
+                "summary": "Выгрузка списка к просмотру",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Формат файла",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список к просмотру",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/service.WatchlistRecord"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при выгрузке",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/watchlist/{film_id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Добавляет фильм в список к просмотру текущего юзера или переставляет его, если он уже в списке. Без позиции новый фильм добавляется в конец, а фильм из списка остается на месте.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watch"
+                ],
+                "summary": "Добавляет фильм в список к просмотру",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID фильма",
+                        "name": "film_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Позиция в списке, начиная с 1",
+                        "name": "entry",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.WatchlistRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Запись списка",
+                        "schema": {
+                            "$ref": "#/definitions/entities.WatchlistEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка при декодировании JSON",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Фильм не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при добавлении в список",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Убирает фильм из списка к просмотру текущего юзера.",
+                "tags": [
+                    "Watch"
+                ],
+                "summary": "Убирает фильм из списка к просмотру",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID фильма",
+                        "name": "film_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Фильма нет в списке",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении из списка",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/person": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу актеров с учетом фильтров и сортировки.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Actor"
+                ],
+                "summary": "Возвращает список актеров",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Размер страницы (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "-birthday",
+                        "description": "Поля сортировки: name, gender, birthday; минус для убывания",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Пол",
+                        "name": "gender",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Родившиеся до даты (YYYY-MM-DD)",
+                        "name": "born_before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница актеров",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ListResponse-entities_ActorEntity"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении актеров",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создает нового актера на основе переданных данных.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Actor"
+                ],
+                "summary": "Создает актера",
+                "parameters": [
+                    {
+                        "description": "Данные актера",
+                        "name": "actor",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.ActorEntity"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка при декодировании JSON",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при создании актера",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/person/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает информацию об актере по указанному ID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Actor"
+                ],
+                "summary": "Возвращает информацию об актере",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID актера",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Информация об актере",
+                        "schema": {
+                            "$ref": "#/definitions/entities.ActorEntity"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Хеш ответа, меняется с любым его полем"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Актер не изменился"
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Актер не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении актера",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Обновляет информацию об актере с указанным ID на основе переданных данных.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Actor"
+                ],
+                "summary": "Обновляет информацию об актере",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID актера",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее; без заголовка запрос безусловный",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные актера",
+                        "name": "actor",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.ActorEntity"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка при декодировании JSON",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Актер не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Актер изменился после получения ETag",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при обновлении актера",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет актера с указанным ID.",
+                "tags": [
+                    "Actor"
+                ],
+                "summary": "Удаляет актера",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID актера",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее; без заголовка запрос безусловный",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Актер не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Актер изменился после получения ETag",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении актера",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Применяет JSON Merge Patch (RFC 7396) или JSON Patch (RFC 6902) к актеру в том виде, в котором его возвращает GET /actor/{id}. Результат проверяется так же, как при создании, и сохраняется целиком или не сохраняется вовсе.",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Actor"
+                ],
+                "summary": "Частично обновляет актера",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID актера",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее; без заголовка запрос безусловный",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Патч актера",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Актер после изменения",
+                        "schema": {
+                            "$ref": "#/definitions/entities.ActorEntity"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Хеш ответа, меняется с любым его полем"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный патч",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Актер не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Патч не применим: нет пути или не прошла операция test",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Актер изменился после получения ETag",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "415": {
+                        "description": "Неизвестный формат патча",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при обновлении актера",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/person/{id}/credits": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает все фильмы человека с указанным ID вместе с ролями: режиссер, сценарист, продюсер, композитор или актер. Отсортированы по дате выхода.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Actor"
+                ],
+                "summary": "Возвращает титры человека",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID человека",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Титры человека",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entities.PersonCredit"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Человек не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении титров",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/review": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу отзывов в указанном статусе, по умолчанию ожидающих модерации, сначала старые.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Review"
+                ],
+                "summary": "Возвращает очередь модерации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "pending",
+                        "description": "Статус: pending, published, rejected",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID фильма",
+                        "name": "film_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID автора",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Размер страницы (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "created_at",
+                        "description": "Поля сортировки: created_at, updated_at; минус для убывания",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница отзывов",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ListResponse-entities_ReviewEntity"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Фильм не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении отзывов",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/review/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает опубликованный отзыв, свой отзыв в любом статусе или, для админов, любой отзыв.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Review"
+                ],
+                "summary": "Возвращает отзыв",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID отзыва",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отзыв",
+                        "schema": {
+                            "$ref": "#/definitions/entities.ReviewEntity"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Отзыв не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении отзыва",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Изменяет текст своего отзыва, измененный отзыв снова проходит модерацию.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Review"
+                ],
+                "summary": "Изменяет отзыв",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID отзыва",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Текст отзыва",
+                        "name": "review",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отзыв",
+                        "schema": {
+                            "$ref": "#/definitions/entities.ReviewEntity"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка при декодировании JSON",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Отзыв другого юзера",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Отзыв не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при изменении отзыва",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет свой отзыв, админы могут удалить любой.",
+                "tags": [
+                    "Review"
+                ],
+                "summary": "Удаляет отзыв",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID отзыва",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Отзыв другого юзера",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Отзыв не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении отзыва",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/review/{id}/status": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Публикует или отклоняет (скрывает) отзыв.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Review"
+                ],
+                "summary": "Модерирует отзыв",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID отзыва",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый статус: published или rejected",
+                        "name": "status",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ModerateReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отзыв",
+                        "schema": {
+                            "$ref": "#/definitions/entities.ReviewEntity"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка при декодировании JSON",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Отзыв не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при модерации отзыва",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/search": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Ищет фильмы по названию и описанию и актеров по имени. Каждое слово запроса ищется как префикс, результаты отсортированы по релевантности, совпадения выделены тегом mark, остальной текст фрагмента экранирован как HTML.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Полнотекстовый поиск",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Поисковый запрос",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Количество результатов (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Результаты поиска",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SearchResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при поиске",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу юзеров с учетом фильтров и сортировки.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Возвращает список юзеров",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Размер страницы (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поля сортировки: username, role; минус для убывания",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Роль",
+                        "name": "role",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница юзеров",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ListResponse-entities_UserEntity"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении юзеров",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создает нового юзера на основе переданных данных.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Создает юзера.",
+                "parameters": [
+                    {
+                        "description": "Данные юзера",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка при декодировании JSON",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Юзер уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при создании юзера",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает информацию о юзере по указанному ID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Возвращает информацию о юзере",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID юзера",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Информация о юзере",
+                        "schema": {
+                            "$ref": "#/definitions/entities.UserEntity"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Хеш ответа, меняется с любым его полем"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Юзер не изменился"
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Юзер не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при получении юзере",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Обновляет информацию о юзере с указанным ID на основе переданных данных.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Обновляет информацию о юзере",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID юзера",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее; без заголовка запрос безусловный",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные юзера",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.UserEntity"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка при декодировании JSON",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Юзер не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Юзер уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Юзер изменился после получения ETag",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при обновлении юзера",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет юзера с указанным ID.",
+                "tags": [
+                    "User"
+                ],
+                "summary": "Удаляет юзера",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID юзера",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее; без заголовка запрос безусловный",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Юзер не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Юзер изменился после получения ETag",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении юзера",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Применяет JSON Merge Patch (RFC 7396) или JSON Patch (RFC 6902) к юзеру в том виде, в котором его возвращает GET /user/{id}. Результат проверяется так же, как при создании, и сохраняется целиком или не сохраняется вовсе. Пароль патчем не меняется.",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Частично обновляет юзера",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID юзера",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее; без заголовка запрос безусловный",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Патч юзера",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Юзер после изменения",
+                        "schema": {
+                            "$ref": "#/definitions/entities.UserEntity"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Хеш ответа, меняется с любым его полем"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный патч",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется токен доступа",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Юзер не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Юзер уже существует или патч не применим",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Юзер изменился после получения ETag",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "415": {
+                        "description": "Неизвестный формат патча",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при обновлении юзера",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "entities.ActorEntity": {
+            "type": "object",
+            "properties": {
+                "birthday": {
+                    "type": "string"
+                },
+                "gender": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version counts the edits of the actor, see AnyVersion.",
+                    "type": "integer"
+                }
+            }
+        },
+        "entities.Auth": {
+            "type": "object",
+            "properties": {
+                "login": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.CreditEntity": {
+            "type": "object",
+            "properties": {
+                "billing": {
+                    "type": "integer"
+                },
+                "character": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "personID": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/entities.CreditRole"
+                }
+            }
+        },
+        "entities.CreditRole": {
+            "type": "string",
+            "enum": [
+                "director",
+                "writer",
+                "producer",
+                "composer",
+                "actor"
+            ],
+            "x-enum-varnames": [
+                "CreditDirector",
+                "CreditWriter",
+                "CreditProducer",
+                "CreditComposer",
+                "CreditActor"
+            ]
+        },
+        "entities.FilmEntity": {
+            "type": "object",
+            "properties": {
+                "actors": {
+                    "description": "Actors is the cast ordered by billing, Credits has the whole crew\nwith the role and character of everyone.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.ActorEntity"
+                    }
+                },
+                "credits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.CreditEntity"
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
+                "genres": {
+                    "description": "Genres are linked by ID, Tags are free-form lowercase words.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.GenreEntity"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "rating": {
+                    "description": "Rating is the editorial rating, UserRating sums up the ratings of\nthe users.",
+                    "type": "number"
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "userRating": {
+                    "$ref": "#/definitions/entities.RatingSummary"
+                },
+                "version": {
+                    "description": "Version counts the edits of the film, see AnyVersion.",
+                    "type": "integer"
+                }
+            }
+        },
+        "entities.FilmRating": {
+            "type": "object",
+            "properties": {
+                "filmID": {
+                    "type": "string"
+                },
+                "ratedAt": {
+                    "type": "string"
+                },
+                "rating": {
+                    "type": "number"
+                },
+                "userID": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.GenreCount": {
+            "type": "object",
+            "properties": {
+                "films": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.GenreEntity": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.HistoryEntry": {
+            "type": "object",
+            "properties": {
+                "filmID": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "rating": {
+                    "type": "number"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "string"
+                },
+                "watchedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.PersonCredit": {
+            "type": "object",
+            "properties": {
+                "billing": {
+                    "type": "integer"
+                },
+                "character": {
+                    "type": "string"
+                },
+                "filmID": {
+                    "type": "string"
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/entities.CreditRole"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.RatingSummary": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "mean": {
+                    "type": "number"
+                },
+                "weighted": {
+                    "type": "number"
+                }
+            }
+        },
+        "entities.ReviewEntity": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "description": "Author is the username of the user, it is filled in on reads.",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "filmID": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "moderatedAt": {
+                    "type": "string"
+                },
+                "moderatorID": {
+                    "description": "ModeratorID is the admin who last published or rejected the review,\nempty while it waits for moderation.",
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/entities.ReviewStatus"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.ReviewStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "published",
+                "rejected"
+            ],
+            "x-enum-varnames": [
+                "ReviewPending",
+                "ReviewPublished",
+                "ReviewRejected"
+            ]
+        },
+        "entities.SearchResult": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "number"
+                },
+                "snippet": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.TagCount": {
+            "type": "object",
+            "properties": {
+                "films": {
+                    "type": "integer"
+                },
+                "tag": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.UserEntity": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version counts the edits of the user, see AnyVersion. A new password\nis not an edit.",
+                    "type": "integer"
+                }
+            }
+        },
+        "entities.WatchlistEntry": {
+            "type": "object",
+            "properties": {
+                "addedAt": {
+                    "type": "string"
+                },
+                "filmID": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CreateUserRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.GenreRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.HistoryRequest": {
+            "type": "object",
+            "properties": {
+                "film_id": {
+                    "type": "string"
+                },
+                "rating": {
+                    "type": "number"
+                },
+                "watched_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ListResponse-entities_ActorEntity": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.ActorEntity"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ListResponse-entities_FilmEntity": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.FilmEntity"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ListResponse-entities_FilmRating": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.FilmRating"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ListResponse-entities_HistoryEntry": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.HistoryEntry"
                     }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "Ошибка при декодировании JSON",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "500": {
-                        "description": "Ошибка при обновлении юзера",
-                        "schema": {
-                            "type": "string"
-                        }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ListResponse-entities_ReviewEntity": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.ReviewEntity"
                     }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
                 }
-            },
-            "delete": {
-                "description": "Удаляет юзера с указанным ID.",
-                "tags": [
-                    "User"
-                ],
-                "summary": "Удаляет юзера",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "ID юзера",
-                        "name": "id",
-                        "in": "query",
-                        "required": true
+            }
+        },
+        "handlers.ListResponse-entities_UserEntity": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.UserEntity"
                     }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Ошибка при удалении юзера",
-                        "schema": {
-                            "type": "string"
-                        }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ListResponse-entities_WatchlistEntry": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.WatchlistEntry"
                     }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
-        }
-    },
-    "definitions": {
-        "entities.ActorEntity": {
+        },
+        "handlers.ModerateReviewRequest": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "$ref": "#/definitions/entities.ReviewStatus"
+                }
+            }
+        },
+        "handlers.RateFilmRequest": {
+            "type": "object",
+            "properties": {
+                "rating": {
+                    "type": "number"
+                }
+            }
+        },
+        "handlers.RateFilmResponse": {
+            "type": "object",
+            "properties": {
+                "rating": {
+                    "type": "number"
+                },
+                "summary": {
+                    "$ref": "#/definitions/entities.RatingSummary"
+                }
+            }
+        },
+        "handlers.ReviewRequest": {
             "type": "object",
             "properties": {
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.SearchResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.SearchResult"
+                    }
+                },
+                "query": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.WatchlistRequest": {
+            "type": "object",
+            "properties": {
+                "position": {
+                    "type": "integer"
+                }
+            }
+        },
+        "problem.Details": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/service.ErrorCode"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.CreditRecord": {
+            "type": "object",
+            "properties": {
+                "billing": {
+                    "type": "integer"
+                },
                 "birthday": {
                     "type": "string"
                 },
+                "character": {
+                    "type": "string"
+                },
                 "gender": {
                     "type": "string"
                 },
@@ -758,21 +5420,110 @@ const docTemplate = `{
                 },
                 "name": {
                     "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/entities.CreditRole"
                 }
             }
         },
-        "entities.Auth": {
+        "service.ErrorCode": {
+            "type": "string",
+            "enum": [
+                "malformed_request",
+                "validation_failed",
+                "film_not_found",
+                "actor_not_found",
+                "user_not_found",
+                "rating_not_found",
+                "review_not_found",
+                "not_on_watchlist",
+                "history_entry_not_found",
+                "genre_not_found",
+                "unknown_actor",
+                "unknown_genre",
+                "conflict",
+                "invalid_reference",
+                "user_exists",
+                "review_exists",
+                "genre_exists",
+                "version_mismatch",
+                "patch_conflict",
+                "unsupported_media_type",
+                "invalid_credentials",
+                "token_missing",
+                "token_expired",
+                "token_invalid",
+                "token_revoked",
+                "token_reused",
+                "forbidden",
+                "not_found",
+                "method_not_allowed",
+                "timeout",
+                "internal_error"
+            ],
+            "x-enum-varnames": [
+                "CodeMalformedRequest",
+                "CodeValidationFailed",
+                "CodeFilmNotFound",
+                "CodeActorNotFound",
+                "CodeUserNotFound",
+                "CodeRatingNotFound",
+                "CodeReviewNotFound",
+                "CodeNotOnWatchlist",
+                "CodeHistoryNotFound",
+                "CodeGenreNotFound",
+                "CodeUnknownActor",
+                "CodeUnknownGenre",
+                "CodeConflict",
+                "CodeInvalidReference",
+                "CodeUserExists",
+                "CodeReviewExists",
+                "CodeGenreExists",
+                "CodeVersionMismatch",
+                "CodePatchConflict",
+                "CodeUnsupportedMedia",
+                "CodeInvalidCredentials",
+                "CodeTokenMissing",
+                "CodeTokenExpired",
+                "CodeTokenInvalid",
+                "CodeTokenRevoked",
+                "CodeTokenReused",
+                "CodeForbidden",
+                "CodeNotFound",
+                "CodeMethodNotAllowed",
+                "CodeTimeout",
+                "CodeInternal"
+            ]
+        },
+        "service.FieldError": {
             "type": "object",
             "properties": {
-                "login": {
+                "field": {
                     "type": "string"
                 },
-                "password": {
+                "message": {
                     "type": "string"
                 }
             }
         },
-        "entities.FilmEntity": {
+        "service.FilmFacets": {
+            "type": "object",
+            "properties": {
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.GenreCount"
+                    }
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.TagCount"
+                    }
+                }
+            }
+        },
+        "service.FilmRecord": {
             "type": "object",
             "properties": {
                 "actors": {
@@ -781,37 +5532,134 @@ const docTemplate = `{
                         "$ref": "#/definitions/entities.ActorEntity"
                     }
                 },
+                "credits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.CreditRecord"
+                    }
+                },
                 "description": {
                     "type": "string"
                 },
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "id": {
                     "type": "string"
                 },
+                "name": {
+                    "type": "string"
+                },
                 "rating": {
                     "type": "number"
                 },
-                "releaseDate": {
+                "release_date": {
                     "type": "string"
                 },
-                "title": {
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
                     "type": "string"
                 }
             }
         },
-        "entities.UserEntity": {
+        "service.HistoryRecord": {
             "type": "object",
             "properties": {
+                "film_id": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
-                "role": {
+                "rating": {
+                    "type": "number"
+                },
+                "title": {
                     "type": "string"
                 },
-                "username": {
+                "watched_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.ImportError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/service.ErrorCode"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.FieldError"
+                    }
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.ImportReport": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.ImportError"
+                    }
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.WatchlistRecord": {
+            "type": "object",
+            "properties": {
+                "added_at": {
+                    "type": "string"
+                },
+                "film_id": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "release_date": {
+                    "type": "string"
+                },
+                "title": {
                     "type": "string"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
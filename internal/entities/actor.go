@@ -1,12 +1,23 @@
 package entities
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
-// Actor model
+var (
+	// ErrUnknownActor means a film credits a person that does not exist,
+	// whatever the role.
+	ErrUnknownActor = fmt.Errorf("unknown actor: %w", ErrInvalidReference)
+)
+
+// Actor model, any person credited on films is stored as one.
 // @SWG.Model
 type ActorEntity struct {
 	ID       string
 	Name     string
 	Gender   string
 	Birthday time.Time
+	// Version counts the edits of the actor, see AnyVersion.
+	Version int
 }
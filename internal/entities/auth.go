@@ -1,11 +1,5 @@
 package entities
 
-import "fmt"
-
-var (
-	ErrWrongLoginOrPassword = fmt.Errorf("wrong login or password")
-)
-
 type Auth struct {
 	Login    string `json:"login"`
 	Password string `json:"password"`
@@ -14,4 +8,16 @@ type Auth struct {
 type Token struct {
 	Access string `json:"access_token"`
 	RT     string `json:"refresh_token"`
+	RTID   string `json:"-"`
+	Family string `json:"-"`
+}
+
+// TokenClaims holds the subject and role a token was issued for.
+// ID and Family are set for refresh tokens only.
+type TokenClaims struct {
+	UserID string
+	Role   Role
+	Type   string
+	ID     string
+	Family string
 }
@@ -0,0 +1,109 @@
+package entities
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CreditRole is what a person did on a film.
+type CreditRole string
+
+const (
+	CreditDirector CreditRole = "director"
+	CreditWriter   CreditRole = "writer"
+	CreditProducer CreditRole = "producer"
+	CreditComposer CreditRole = "composer"
+	CreditActor    CreditRole = "actor"
+)
+
+// creditRoles are the roles in the order credits are listed in.
+var creditRoles = []CreditRole{CreditDirector, CreditWriter, CreditProducer, CreditComposer, CreditActor}
+
+func (r CreditRole) Valid() bool {
+	return slices.Contains(creditRoles, r)
+}
+
+// CreditEntity credits a person on a film. Character is the part an actor
+// plays, Billing orders the cast from 1 and is 0 for an unbilled credit.
+type CreditEntity struct {
+	PersonID  string
+	Name      string
+	Role      CreditRole
+	Character string
+	Billing   int
+}
+
+// PersonCredit is a credit of a person together with the film.
+type PersonCredit struct {
+	FilmID      string
+	Title       string
+	ReleaseDate time.Time
+	Role        CreditRole
+	Character   string
+	Billing     int
+}
+
+// FilmCredits returns the credits of film, Actors given without a credit
+// as actor are added as unbilled actor credits. A person is credited once
+// per role, the first credit wins.
+func FilmCredits(film FilmEntity) []CreditEntity {
+	credits := make([]CreditEntity, 0, len(film.Credits)+len(film.Actors))
+	for _, credit := range film.Credits {
+		if !hasCredit(credits, credit.PersonID, credit.Role) {
+			credits = append(credits, credit)
+		}
+	}
+	for _, actor := range film.Actors {
+		if !hasCredit(credits, actor.ID, CreditActor) {
+			credits = append(credits, CreditEntity{PersonID: actor.ID, Role: CreditActor})
+		}
+	}
+	return credits
+}
+
+func hasCredit(credits []CreditEntity, personID string, role CreditRole) bool {
+	return slices.ContainsFunc(credits, func(credit CreditEntity) bool {
+		return credit.PersonID == personID && credit.Role == role
+	})
+}
+
+// CompareCredits orders credits by role, then the billed cast by billing
+// before the unbilled, then by name.
+func CompareCredits(a, b CreditEntity) int {
+	if c := compareRoles(a.Role, b.Role); c != 0 {
+		return c
+	}
+	if c := CompareBilling(a.Billing, b.Billing); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.Name, b.Name); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.PersonID, b.PersonID)
+}
+
+// CompareBilling puts billed credits in order and the unbilled ones last.
+func CompareBilling(a, b int) int {
+	if (a == 0) != (b == 0) {
+		return cmp.Compare(b, a)
+	}
+	return cmp.Compare(a, b)
+}
+
+// ComparePersonCredits orders the credits of a person by the release of
+// the film, then by role.
+func ComparePersonCredits(a, b PersonCredit) int {
+	if c := a.ReleaseDate.Compare(b.ReleaseDate); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.FilmID, b.FilmID); c != 0 {
+		return c
+	}
+	return compareRoles(a.Role, b.Role)
+}
+
+func compareRoles(a, b CreditRole) int {
+	return cmp.Compare(slices.Index(creditRoles, a), slices.Index(creditRoles, b))
+}
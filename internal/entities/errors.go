@@ -0,0 +1,22 @@
+package entities
+
+import "fmt"
+
+// Errors every repository implementation reports, whatever its storage.
+var (
+	// ErrNotFound means the requested record does not exist.
+	ErrNotFound = fmt.Errorf("not found")
+	// ErrConflict means the record clashes with an existing one, such as a
+	// duplicate unique key.
+	ErrConflict = fmt.Errorf("conflict")
+	// ErrInvalidReference means the record refers to another record that
+	// does not exist.
+	ErrInvalidReference = fmt.Errorf("invalid reference")
+	// ErrVersionMismatch means a conditional write found the record at
+	// another version than the expected one.
+	ErrVersionMismatch = fmt.Errorf("version mismatch")
+)
+
+// AnyVersion makes a write unconditional. Stored records start at version
+// 1 and every edit increments it.
+const AnyVersion = 0
@@ -9,6 +9,27 @@ type FilmEntity struct {
 	Title       string
 	Description string
 	ReleaseDate time.Time
-	Rating      float64
-	Actors      []ActorEntity
+	// Rating is the editorial rating, UserRating sums up the ratings of
+	// the users.
+	Rating     float64
+	UserRating RatingSummary
+	// Actors is the cast ordered by billing, Credits has the whole crew
+	// with the role and character of everyone.
+	Actors  []ActorEntity
+	Credits []CreditEntity
+	// Genres are linked by ID, Tags are free-form lowercase words.
+	Genres []GenreEntity
+	Tags   []string
+	// Version counts the edits of the film, see AnyVersion.
+	Version int
+}
+
+// CreditedFilm is a film as import and export files carry it, with the
+// people it credits in full so that a store can match them by name and
+// birthday. The cast is in Actors, Crew has the rest of the credited
+// people. A film read from a file leaves Credits, Genres or Tags nil when
+// the file does not have them, the stored ones are kept then.
+type CreditedFilm struct {
+	FilmEntity
+	Crew []ActorEntity
 }
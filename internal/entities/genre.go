@@ -0,0 +1,27 @@
+package entities
+
+import "fmt"
+
+var (
+	ErrUnknownGenre = fmt.Errorf("unknown genre: %w", ErrInvalidReference)
+)
+
+// Genre model
+// @SWG.Model
+type GenreEntity struct {
+	ID   string
+	Name string
+}
+
+// GenreCount is a genre with the number of films it has, a facet of the
+// film list.
+type GenreCount struct {
+	GenreEntity
+	Films int
+}
+
+// TagCount is a tag with the number of films it is given to.
+type TagCount struct {
+	Tag   string
+	Films int
+}
@@ -0,0 +1,198 @@
+package entities
+
+import "time"
+
+var (
+	FilmSortFields   = []string{"title", "rating", "release_date"}
+	ActorSortFields  = []string{"name", "gender", "birthday"}
+	UserSortFields   = []string{"username", "role"}
+	ReviewSortFields = []string{"created_at", "updated_at"}
+	// WatchlistSortFields and HistorySortFields order the lists of a user,
+	// title and release_date are those of the film.
+	WatchlistSortFields = []string{"position", "added_at", "title", "release_date"}
+	HistorySortFields   = []string{"watched_at", "rating", "title"}
+	// RatingSortFields order the rating history, it is always listed as
+	// RatingHistorySort.
+	RatingSortFields = []string{"rated_at", "id"}
+	// RatingHistorySort lists the newest ratings first, ratings given at
+	// the same time by the order they were stored in.
+	RatingHistorySort = []SortField{{Field: "rated_at", Desc: true}, {Field: "id", Desc: true}}
+)
+
+// Unrated is the sort key of a missing rating, it comes before any
+// rating in every store.
+const Unrated = -1.0
+
+// SortField is a column a list is ordered by.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ListQuery holds the pagination and ordering of a list request.
+// Lists are always ordered by ID last so that pages are stable.
+type ListQuery struct {
+	Limit  int
+	Offset int
+	Sort   []SortField
+	// After holds the sort keys of the last item of the previous page, one
+	// per Sort field followed by the ID. The list continues behind that
+	// item rather than at Offset, which then only counts the items before.
+	After []any
+}
+
+// Sortable is an item of a list. SortKey returns the value the list is
+// ordered by for a sort field or "id", nil for any other field.
+type Sortable interface {
+	SortKey(field string) any
+}
+
+// SortKeys returns the keys of item a list continues behind, see
+// ListQuery.After.
+func SortKeys(item Sortable, sort []SortField) []any {
+	keys := make([]any, 0, len(sort)+1)
+	for _, s := range sort {
+		keys = append(keys, item.SortKey(s.Field))
+	}
+	return append(keys, item.SortKey("id"))
+}
+
+// FilmQuery selects films. A film matches Genres and Tags when it has any
+// of them, or all of them with MatchAll.
+type FilmQuery struct {
+	ListQuery
+	MinRating     *float64
+	ReleasedAfter *time.Time
+	Genres        []string
+	Tags          []string
+	MatchAll      bool
+}
+
+type ActorQuery struct {
+	ListQuery
+	Gender     string
+	BornBefore *time.Time
+}
+
+type UserQuery struct {
+	ListQuery
+	Role Role
+}
+
+// RatingQuery selects the rating history of a user, newest first.
+type RatingQuery struct {
+	ListQuery
+	UserID string
+	FilmID string
+}
+
+// ReviewQuery selects reviews, empty fields match every review.
+type ReviewQuery struct {
+	ListQuery
+	FilmID string
+	UserID string
+	Status ReviewStatus
+}
+
+// WatchQuery selects the watchlist or the watch history of a user.
+type WatchQuery struct {
+	ListQuery
+	UserID string
+	FilmID string
+}
+
+func (f FilmEntity) SortKey(field string) any {
+	switch field {
+	case "id":
+		return f.ID
+	case "title":
+		return f.Title
+	case "rating":
+		return f.Rating
+	case "release_date":
+		return f.ReleaseDate
+	}
+	return nil
+}
+
+func (a ActorEntity) SortKey(field string) any {
+	switch field {
+	case "id":
+		return a.ID
+	case "name":
+		return a.Name
+	case "gender":
+		return a.Gender
+	case "birthday":
+		return a.Birthday
+	}
+	return nil
+}
+
+func (u UserEntity) SortKey(field string) any {
+	switch field {
+	case "id":
+		return u.ID
+	case "username":
+		return u.Username
+	case "role":
+		return string(u.Role)
+	}
+	return nil
+}
+
+func (r ReviewEntity) SortKey(field string) any {
+	switch field {
+	case "id":
+		return r.ID
+	case "created_at":
+		return r.CreatedAt
+	case "updated_at":
+		return r.UpdatedAt
+	}
+	return nil
+}
+
+func (r FilmRating) SortKey(field string) any {
+	switch field {
+	case "id":
+		return r.ID
+	case "rated_at":
+		return r.RatedAt
+	}
+	return nil
+}
+
+// SortKey of a watchlist entry, its ID is the film.
+func (e WatchlistEntry) SortKey(field string) any {
+	switch field {
+	case "id":
+		return e.FilmID
+	case "position":
+		return e.Position
+	case "added_at":
+		return e.AddedAt
+	case "title":
+		return e.Title
+	case "release_date":
+		return e.ReleaseDate
+	}
+	return nil
+}
+
+func (e HistoryEntry) SortKey(field string) any {
+	switch field {
+	case "id":
+		return e.ID
+	case "watched_at":
+		return e.WatchedAt
+	case "rating":
+		if e.Rating == nil {
+			return Unrated
+		}
+		return *e.Rating
+	case "title":
+		return e.Title
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package entities
+
+import (
+	"math"
+	"time"
+)
+
+// FilmRating is the rating a user has given a film. ID orders the rating
+// history, it is not shown.
+type FilmRating struct {
+	ID      int64 `json:"-"`
+	FilmID  string
+	UserID  string
+	Rating  float64
+	RatedAt time.Time
+}
+
+// RatingSummary is the aggregate of the user ratings of a film, it is kept
+// apart from the editorial FilmEntity.Rating. Weighted is the Bayesian
+// score, nil unless it is enabled.
+type RatingSummary struct {
+	Mean     float64
+	Count    int
+	Weighted *float64
+}
+
+// NewRatingSummary returns the summary of count ratings adding up to sum.
+func NewRatingSummary(sum float64, count int) RatingSummary {
+	if count == 0 {
+		return RatingSummary{}
+	}
+	return RatingSummary{Mean: RoundRating(sum / float64(count)), Count: count}
+}
+
+// RatingTotals add up the user ratings of all films.
+type RatingTotals struct {
+	Sum   float64
+	Count int
+}
+
+// RoundRating rounds to the two decimals ratings are stored with.
+func RoundRating(rating float64) float64 {
+	return math.Round(rating*100) / 100
+}
@@ -0,0 +1,36 @@
+package entities
+
+import "time"
+
+// ReviewStatus is the stage of moderation a review is in. Only published
+// reviews are shown to everyone.
+type ReviewStatus string
+
+const (
+	ReviewPending   ReviewStatus = "pending"
+	ReviewPublished ReviewStatus = "published"
+	ReviewRejected  ReviewStatus = "rejected"
+)
+
+// Valid reports whether the status is one of the known statuses.
+func (s ReviewStatus) Valid() bool {
+	return s == ReviewPending || s == ReviewPublished || s == ReviewRejected
+}
+
+// Review model
+// @SWG.Model
+type ReviewEntity struct {
+	ID     string
+	FilmID string
+	UserID string
+	// Author is the username of the user, it is filled in on reads.
+	Author    string
+	Text      string
+	Status    ReviewStatus
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// ModeratorID is the admin who last published or rejected the review,
+	// empty while it waits for moderation.
+	ModeratorID string
+	ModeratedAt *time.Time
+}
@@ -0,0 +1,16 @@
+package entities
+
+const (
+	SearchTypeFilm  = "film"
+	SearchTypeActor = "actor"
+)
+
+// Search result model
+// @SWG.Model
+type SearchResult struct {
+	Type    string
+	ID      string
+	Title   string
+	Snippet string
+	Rank    float64
+}
@@ -1,14 +1,29 @@
 package entities
 
+import "fmt"
+
+var (
+	ErrUserNotFound = fmt.Errorf("user %w", ErrNotFound)
+)
+
 type Role string
 
 var Admin Role = "admin"
 var User Role = "user"
 
+// Valid reports whether the role is one of the known roles.
+func (r Role) Valid() bool {
+	return r == Admin || r == User
+}
+
 // Actor model
 // @SWG.Model
 type UserEntity struct {
-	Role     Role
-	ID       string
-	Username string
+	Role         Role
+	ID           string
+	Username     string
+	PasswordHash string `json:"-"`
+	// Version counts the edits of the user, see AnyVersion. A new password
+	// is not an edit.
+	Version int
 }
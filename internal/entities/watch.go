@@ -0,0 +1,26 @@
+package entities
+
+import "time"
+
+// WatchlistEntry is a film a user plans to watch. Positions start at 1
+// and have no gaps.
+type WatchlistEntry struct {
+	UserID      string
+	FilmID      string
+	Title       string
+	ReleaseDate time.Time
+	Position    int
+	AddedAt     time.Time
+}
+
+// HistoryEntry is a viewing of a film by a user. Rating is the personal
+// rating of the viewing, it does not count towards the user rating of the
+// film.
+type HistoryEntry struct {
+	ID        string
+	UserID    string
+	FilmID    string
+	Title     string
+	WatchedAt time.Time
+	Rating    *float64
+}
@@ -4,43 +4,49 @@ import (
 	"context"
 	"encoding/json"
 	"filmography/internal/entities"
-	"fmt"
-	"github.com/sirupsen/logrus"
+	"filmography/internal/patch"
+	"filmography/internal/problem"
 	"net/http"
 )
 
 type ActorService interface {
 	CreateActor(ctx context.Context, actor entities.ActorEntity) error
-	GetActors(ctx context.Context) ([]entities.ActorEntity, error)
+	GetActors(ctx context.Context, query entities.ActorQuery) ([]entities.ActorEntity, int, error)
 	GetActor(ctx context.Context, id string) (entities.ActorEntity, error)
+	GetFilmsByActor(ctx context.Context, actorID string) ([]entities.FilmEntity, error)
+	GetActorCredits(ctx context.Context, actorID string) ([]entities.PersonCredit, error)
 	UpdateActor(ctx context.Context, id string, actor entities.ActorEntity) error
-	DeleteActor(ctx context.Context, id string) error
+	PatchActor(ctx context.Context, id string, p patch.Patch, version int) (entities.ActorEntity, error)
+	DeleteActor(ctx context.Context, id string, version int) error
 }
 
 // createActor создает нового актера.
 // @Summary Создает актера
 // @Description Создает нового актера на основе переданных данных.
 // @Tags Actor
+// @Security ApiKeyAuth
 // @Accept json
 // @Produce json
 // @Param actor body entities.ActorEntity true "Данные актера"
 // @Success 201 {object} map[string]string
-// @Failure 400 {string} string "Ошибка при декодировании JSON"
-// @Failure 500 {string} string "Ошибка при создании актера"
+// @Failure 400 {object} problem.Details "Ошибка при декодировании JSON"
+// @Failure 422 {object} problem.Details "Ошибка валидации"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 500 {object} problem.Details "Ошибка при создании актера"
 // @Router /actor [post]
+// @Router /person [post]
 func (handlers Handlers) createActor(w http.ResponseWriter, r *http.Request) {
 	actor := entities.ActorEntity{}
 	err := json.NewDecoder(r.Body).Decode(&actor)
 	if err != nil {
-		http.Error(w, fmt.Errorf("failed to decode JSON: %w", err).Error(), http.StatusBadRequest)
-		logrus.WithField("error", err).Error("failed to decode JSON")
+		problem.Error(w, r, errMalformedJSON)
 		return
 	}
 
 	err = handlers.svc.CreateActor(r.Context(), actor)
 	if err != nil {
-		http.Error(w, fmt.Errorf("failed to create actor: %w", err).Error(), http.StatusInternalServerError)
-		logrus.WithField("error", err).Error("failed to create actor")
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -54,22 +60,44 @@ func (handlers Handlers) createActor(w http.ResponseWriter, r *http.Request) {
 
 // getActors возвращает список актеров.
 // @Summary Возвращает список актеров
-// @Description Возвращает список всех актеров.
+// @Description Возвращает страницу актеров с учетом фильтров и сортировки.
 // @Tags Actor
+// @Security ApiKeyAuth
+// @Param limit query int false "Размер страницы (1-100)" default(20)
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой"
+// @Param sort query string false "Поля сортировки: name, gender, birthday; минус для убывания" example(-birthday)
+// @Param gender query string false "Пол"
+// @Param born_before query string false "Родившиеся до даты (YYYY-MM-DD)"
 // @Produce json
-// @Success 200 {array} entities.ActorEntity "Список актеров"
-// @Failure 500 {string} string "Ошибка при получении актеров"
+// @Success 200 {object} ListResponse[entities.ActorEntity] "Страница актеров"
+// @Failure 422 {object} problem.Details "Неверные параметры запроса"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 500 {object} problem.Details "Ошибка при получении актеров"
 // @Router /actor [get]
+// @Router /person [get]
 func (handlers Handlers) getActors(w http.ResponseWriter, r *http.Request) {
-	actors, err := handlers.svc.GetActors(r.Context())
+	values := r.URL.Query()
+	listQuery, err := parseListQuery[entities.ActorEntity](values, entities.ActorSortFields)
 	if err != nil {
-		http.Error(w, fmt.Errorf("failed to get actors: %w", err).Error(), http.StatusInternalServerError)
-		logrus.WithField("error", err).Error("failed to get actors")
+		problem.Error(w, r, err)
+		return
+	}
+	query := entities.ActorQuery{ListQuery: listQuery, Gender: values.Get("gender")}
+	if query.BornBefore, err = parseDateParam(values, "born_before"); err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	actors, total, err := handlers.svc.GetActors(r.Context(), query)
+	if err != nil {
+		problem.Error(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(actors)
+	err = json.NewEncoder(w).Encode(newListResponse(actors, total, listQuery))
 	if err != nil {
 		return
 	}
@@ -79,17 +107,27 @@ func (handlers Handlers) getActors(w http.ResponseWriter, r *http.Request) {
 // @Summary Возвращает информацию об актере
 // @Description Возвращает информацию об актере по указанному ID.
 // @Tags Actor
-// @Param id query string true "ID актера"
+// @Security ApiKeyAuth
+// @Param id path string true "ID актера"
+// @Param If-None-Match header string false "ETag, полученный ранее"
 // @Produce json
 // @Success 200 {object} entities.ActorEntity "Информация об актере"
-// @Failure 500 {string} string "Ошибка при получении актера"
+// @Header 200 {string} ETag "Хеш ответа, меняется с любым его полем"
+// @Success 304 "Актер не изменился"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Актер не найден"
+// @Failure 500 {object} problem.Details "Ошибка при получении актера"
 // @Router /actor/{id} [get]
+// @Router /person/{id} [get]
 func (handlers Handlers) getActor(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+	id := r.PathValue("id")
 	actor, err := handlers.svc.GetActor(r.Context(), id)
 	if err != nil {
-		http.Error(w, fmt.Errorf("failed to get actor: %w", err).Error(), http.StatusInternalServerError)
-		logrus.WithField("error", err).Error("failed to get actor")
+		problem.Error(w, r, err)
+		return
+	}
+	if notModified(w, r, actor) {
 		return
 	}
 
@@ -100,32 +138,111 @@ func (handlers Handlers) getActor(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// actorVersion returns the version of the actor a write is conditional on,
+// see ifMatch.
+func (handlers Handlers) actorVersion(r *http.Request, id string) (int, error) {
+	return ifMatch(r, func() (entities.ActorEntity, int, error) {
+		actor, err := handlers.svc.GetActor(r.Context(), id)
+		return actor, actor.Version, err
+	})
+}
+
+// getActorFilms возвращает фильмографию актера.
+// @Summary Возвращает фильмы актера
+// @Description Возвращает фильмы, в которых снимался актер с указанным ID, отсортированные по дате выхода.
+// @Tags Actor
+// @Security ApiKeyAuth
+// @Param id path string true "ID актера"
+// @Produce json
+// @Success 200 {array} entities.FilmEntity "Список фильмов актера"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Актер не найден"
+// @Failure 500 {object} problem.Details "Ошибка при получении фильмов актера"
+// @Router /actor/{id}/films [get]
+func (handlers Handlers) getActorFilms(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	films, err := handlers.svc.GetFilmsByActor(r.Context(), id)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(films)
+	if err != nil {
+		return
+	}
+}
+
+// getActorCredits возвращает все работы человека.
+// @Summary Возвращает титры человека
+// @Description Возвращает все фильмы человека с указанным ID вместе с ролями: режиссер, сценарист, продюсер, композитор или актер. Отсортированы по дате выхода.
+// @Tags Actor
+// @Security ApiKeyAuth
+// @Param id path string true "ID человека"
+// @Produce json
+// @Success 200 {array} entities.PersonCredit "Титры человека"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Человек не найден"
+// @Failure 500 {object} problem.Details "Ошибка при получении титров"
+// @Router /actor/{id}/credits [get]
+// @Router /person/{id}/credits [get]
+func (handlers Handlers) getActorCredits(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	credits, err := handlers.svc.GetActorCredits(r.Context(), id)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(credits)
+	if err != nil {
+		return
+	}
+}
+
 // updateActor обновляет информацию об актере.
 // @Summary Обновляет информацию об актере
 // @Description Обновляет информацию об актере с указанным ID на основе переданных данных.
 // @Tags Actor
-// @Param id query string true "ID актера"
+// @Security ApiKeyAuth
+// @Param id path string true "ID актера"
+// @Param If-Match header string false "ETag, полученный ранее; без заголовка запрос безусловный"
 // @Accept json
 // @Produce json
 // @Param actor body entities.ActorEntity true "Данные актера"
 // @Success 201 {object} map[string]string
-// @Failure 400 {string} string "Ошибка при декодировании JSON"
-// @Failure 500 {string} string "Ошибка при обновлении актера"
+// @Failure 400 {object} problem.Details "Ошибка при декодировании JSON"
+// @Failure 422 {object} problem.Details "Ошибка валидации"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Актер не найден"
+// @Failure 412 {object} problem.Details "Актер изменился после получения ETag"
+// @Failure 500 {object} problem.Details "Ошибка при обновлении актера"
 // @Router /actor/{id} [put]
+// @Router /person/{id} [put]
 func (handlers Handlers) updateActor(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+	id := r.PathValue("id")
+	version, err := handlers.actorVersion(r, id)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
 	actor := entities.ActorEntity{}
-	err := json.NewDecoder(r.Body).Decode(&actor)
+	err = json.NewDecoder(r.Body).Decode(&actor)
 	if err != nil {
-		http.Error(w, fmt.Errorf("failed to decode JSON: %w", err).Error(), http.StatusBadRequest)
-		logrus.WithField("error", err).Error("failed to decode JSON")
+		problem.Error(w, r, errMalformedJSON)
 		return
 	}
+	actor.Version = version
 
 	err = handlers.svc.UpdateActor(r.Context(), id, actor)
 	if err != nil {
-		http.Error(w, fmt.Errorf("failed to update actor: %w", err).Error(), http.StatusInternalServerError)
-		logrus.WithField("error", err).Error("failed to update actor")
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -139,20 +256,84 @@ func (handlers Handlers) updateActor(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// patchActor частично обновляет актера.
+// @Summary Частично обновляет актера
+// @Description Применяет JSON Merge Patch (RFC 7396) или JSON Patch (RFC 6902) к актеру в том виде, в котором его возвращает GET /actor/{id}. Результат проверяется так же, как при создании, и сохраняется целиком или не сохраняется вовсе.
+// @Tags Actor
+// @Security ApiKeyAuth
+// @Param id path string true "ID актера"
+// @Param If-Match header string false "ETag, полученный ранее; без заголовка запрос безусловный"
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param patch body object true "Патч актера"
+// @Success 200 {object} entities.ActorEntity "Актер после изменения"
+// @Header 200 {string} ETag "Хеш ответа, меняется с любым его полем"
+// @Failure 400 {object} problem.Details "Некорректный патч"
+// @Failure 422 {object} problem.Details "Ошибка валидации"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Актер не найден"
+// @Failure 409 {object} problem.Details "Патч не применим: нет пути или не прошла операция test"
+// @Failure 412 {object} problem.Details "Актер изменился после получения ETag"
+// @Failure 415 {object} problem.Details "Неизвестный формат патча"
+// @Failure 500 {object} problem.Details "Ошибка при обновлении актера"
+// @Router /actor/{id} [patch]
+// @Router /person/{id} [patch]
+func (handlers Handlers) patchActor(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	version, err := handlers.actorVersion(r, id)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	p, err := readPatch(w, r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	actor, err := handlers.svc.PatchActor(r.Context(), id, p, version)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	setETag(w, actor)
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(actor)
+	if err != nil {
+		return
+	}
+}
+
 // deleteActor удаляет актера по его ID.
 // @Summary Удаляет актера
 // @Description Удаляет актера с указанным ID.
 // @Tags Actor
-// @Param id query string true "ID актера"
+// @Security ApiKeyAuth
+// @Param id path string true "ID актера"
+// @Param If-Match header string false "ETag, полученный ранее; без заголовка запрос безусловный"
 // @Success 200 {object} map[string]string
-// @Failure 500 {string} string "Ошибка при удалении актера"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Актер не найден"
+// @Failure 412 {object} problem.Details "Актер изменился после получения ETag"
+// @Failure 500 {object} problem.Details "Ошибка при удалении актера"
 // @Router /actor/{id} [delete]
+// @Router /person/{id} [delete]
 func (handlers Handlers) deleteActor(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
-	err := handlers.svc.DeleteActor(r.Context(), id)
+	id := r.PathValue("id")
+	version, err := handlers.actorVersion(r, id)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	err = handlers.svc.DeleteActor(r.Context(), id, version)
 	if err != nil {
-		http.Error(w, fmt.Errorf("failed to delete actor: %w", err).Error(), http.StatusInternalServerError)
-		logrus.WithField("error", err).Error("failed to delete actor")
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -1,68 +1,61 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"filmography/internal/entities"
+	"filmography/internal/problem"
 	"filmography/service"
-	"fmt"
-	"github.com/golang-jwt/jwt"
 	"github.com/sirupsen/logrus"
-	"log"
 	"net/http"
 	"strings"
 	"time"
 )
 
 type AuthService interface {
-	SingIn(authInfo entities.Auth) (*entities.Token, error)
-	Verify(token string) error
-	Logout(token string, expired time.Duration) error
+	SingIn(ctx context.Context, authInfo entities.Auth) (*entities.Token, error)
+	SignUp(ctx context.Context, authInfo entities.Auth) error
+	Verify(token string) (entities.TokenClaims, error)
+	Refresh(ctx context.Context, refreshToken string) (*entities.Token, error)
+	Logout(token string, refreshToken string, expired time.Duration) error
 	CheckToken(userId string) bool
 }
 
 // @Summary User sign-in
 // @Description Allows a user to sign in with their credentials.
+// @Description The old path POST /auth/sing-in/ still works but is deprecated, its responses carry the Deprecation header.
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param credentials body entities.Auth true "User credentials"
 // @Success 200 {object} map[string]string "Access token response"
-// @Failure 400 {string} string "Bad request"
-// @Failure 403 {string} string "Forbidden"
-// @Failure 500 {string} string "Internal server error"
-// @Router /admin/auth/signin [post]
+// @Failure 400 {object} problem.Details "Malformed request"
+// @Failure 401 {object} problem.Details "Wrong login or password"
+// @Failure 500 {object} problem.Details "Internal server error"
+// @Router /auth/sign-in [post]
 func (handlers Handlers) SignIn(w http.ResponseWriter, r *http.Request) {
 	var auth entities.Auth
 
 	err := json.NewDecoder(r.Body).Decode(&auth)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Error(w, r, errMalformedJSON)
 		return
 	}
 
-	log.Println(auth)
-
-	token, err := handlers.svc.SingIn(auth)
+	token, err := handlers.svc.SingIn(r.Context(), auth)
 	if err != nil {
-		if errors.Is(err, entities.ErrWrongLoginOrPassword) {
-			http.Error(w, err.Error(), http.StatusForbidden)
-			return
-		}
-
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		logrus.WithFields(logrus.Fields{
-			"error": err,
-		}).Error("sing in failed")
+		problem.Error(w, r, err)
 		return
 	}
 
 	exp := time.Now().Add(time.Duration(handlers.cfg.RefreshTokenExp) * 24 * time.Hour)
 	cookie := http.Cookie{
-		Name:    "refresh_token",
-		Value:   token.RT,
-		Path:    "/admin/auth",
-		Expires: exp,
+		Name:     "refresh_token",
+		Value:    token.RT,
+		Path:     "/auth",
+		Expires:  exp,
+		HttpOnly: true,
 	}
 	http.SetCookie(w, &cookie)
 
@@ -75,113 +68,81 @@ func (handlers Handlers) SignIn(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (handlers Handlers) VerifyToken(w http.ResponseWriter, r *http.Request, next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token := strings.Split(r.Header.Get("Authorization"), " ")
-		if len(token) < 2 {
-			http.Error(w, fmt.Errorf("access token required").Error(), http.StatusUnauthorized)
-			return
-		}
-		accessToken := token[1]
-
-		err := handlers.svc.Verify(accessToken)
-		if err != nil {
-			if errors.Is(err, service.ErrTokenExpired) {
-				http.Error(w, err.Error(), http.StatusUnauthorized)
-				return
-			}
-			if strings.Contains(err.Error(), jwt.ErrSignatureInvalid.Error()) {
-				http.Error(w, fmt.Errorf("wrong signature").Error(), http.StatusForbidden)
-				return
-			}
-
-			http.Error(w, fmt.Errorf("wrong token").Error(), http.StatusForbidden)
-			logrus.WithFields(logrus.Fields{
-				"error": err,
-			}).Error("verify failed")
-			return
-		}
+// @Summary User sign-up
+// @Description Registers a new account with the user role.
+// @Description The old path POST /auth/sign-up/ still works but is deprecated, its responses carry the Deprecation header.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param credentials body entities.Auth true "User credentials"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} problem.Details "Malformed request"
+// @Failure 409 {object} problem.Details "User already exists"
+// @Failure 422 {object} problem.Details "Invalid username or password"
+// @Failure 500 {object} problem.Details "Internal server error"
+// @Router /auth/sign-up [post]
+func (handlers Handlers) SignUp(w http.ResponseWriter, r *http.Request) {
+	var auth entities.Auth
 
-		if !handlers.svc.CheckToken(accessToken) {
-			http.Error(w, "you already logged out", http.StatusForbidden)
-			return
-		}
+	err := json.NewDecoder(r.Body).Decode(&auth)
+	if err != nil {
+		problem.Error(w, r, errMalformedJSON)
+		return
+	}
+
+	err = handlers.svc.SignUp(r.Context(), auth)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
 
-		next.ServeHTTP(w, r)
-	})
+	w.WriteHeader(http.StatusCreated)
+	response := map[string]string{
+		"message": "user is successfully registered",
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return
+	}
 }
 
 // @Summary Refresh access token
-// @Description Allows refreshing an access token using a valid refresh token.
+// @Description Rotates the refresh token cookie and returns a new access token.
+// @Description Each refresh token is single-use, reusing one revokes all tokens of its family.
+// @Description The new tokens carry the current role of the user, the tokens of a deleted user are revoked.
+// @Description GET /auth/refresh and GET /auth/refresh/ still work but are deprecated, their responses carry the Deprecation header.
 // @Tags Auth
 // @Produce json
 // @Success 200 {object} map[string]string "New access token response"
-// @Failure 400 {string} string "Bad request"
-// @Failure 401 {string} string "Unauthorized"
-// @Failure 403 {string} string "Forbidden"
-// @Failure 500 {string} string "Internal server error"
-// @Router /admin/auth/refresh [get]
+// @Failure 401 {object} problem.Details "Missing, expired, revoked or reused refresh token"
+// @Failure 403 {object} problem.Details "Invalid refresh token"
+// @Failure 500 {object} problem.Details "Internal server error"
+// @Router /auth/refresh [post]
 func (handlers Handlers) Refresh(w http.ResponseWriter, r *http.Request) {
 	refresh, err := r.Cookie("refresh_token")
 	if err != nil {
-		if errors.Is(err, http.ErrNoCookie) {
-			http.Error(w, fmt.Errorf("bad refresh token").Error(), http.StatusForbidden)
-			return
-		}
-
-		logrus.WithFields(logrus.Fields{
-			"error": err,
-		}).Error("get cookies failed")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	err = handlers.svc.Verify(refresh.Value)
-	if err != nil {
-		if errors.Is(err, service.ErrTokenExpired) {
-			http.Error(w, err.Error(), http.StatusUnauthorized)
-			return
-		}
-		if strings.Contains(err.Error(), jwt.ErrSignatureInvalid.Error()) {
-			http.Error(w, fmt.Errorf("wrong signature").Error(), http.StatusForbidden)
-			return
-		}
-
-		logrus.WithFields(logrus.Fields{
-			"error": err,
-		}).Error("verify failed")
-		http.Error(w, fmt.Errorf("verify rt failed: %w", err).Error(), http.StatusInternalServerError)
+		problem.Error(w, r, service.NewError(service.CodeTokenMissing, "refresh token required"))
 		return
 	}
 
-	params := service.TokenParams{
-		Type:            service.Admin,
-		Hs256Secret:     handlers.cfg.Hs256Secret,
-		AccessTokenExp:  handlers.cfg.AccessTokenExp,
-		RefreshTokenExp: handlers.cfg.RefreshTokenExp,
-	}
-
-	token, err := service.NewToken(params)
+	token, err := handlers.svc.Refresh(r.Context(), refresh.Value)
 	if err != nil {
-		if errors.Is(err, service.ErrUnknownType) {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
+		if errors.Is(err, service.ErrTokenReused) {
+			logrus.WithFields(logrus.Fields{
+				"error":       err,
+				"remote_addr": r.RemoteAddr,
+			}).Warn("security: refresh token reuse detected, token family revoked")
 		}
-
-		logrus.WithFields(logrus.Fields{
-			"error": err,
-		}).Error("new token failed")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		problem.Error(w, r, err)
 		return
 	}
 
 	exp := time.Now().Add(time.Duration(handlers.cfg.RefreshTokenExp) * 24 * time.Hour)
 	cookie := http.Cookie{
-		Name:  "refresh_token",
-		Value: token.RT,
-		Path:  "/admin/auth",
-		// Set other cookie properties like HttpOnly and Secure based on your needs
-		Expires: exp,
+		Name:     "refresh_token",
+		Value:    token.RT,
+		Path:     "/auth",
+		Expires:  exp,
+		HttpOnly: true,
 	}
 	http.SetCookie(w, &cookie)
 
@@ -196,34 +157,39 @@ func (handlers Handlers) Refresh(w http.ResponseWriter, r *http.Request) {
 
 // @Summary User logout
 // @Description Allows a user to log out and invalidate their access token.
+// @Description The old path POST /auth/logout/ still works but is deprecated, its responses carry the Deprecation header.
 // @Tags Auth
 // @Security ApiKeyAuth
 // @Produce json
 // @Success 200 {string} string "Logout successful"
-// @Failure 500 {string} string "Internal server error"
-// @Router /admin/auth/logout [post]
+// @Failure 401 {object} problem.Details "Access token required"
+// @Failure 403 {object} problem.Details "Invalid access token"
+// @Failure 500 {object} problem.Details "Internal server error"
+// @Router /auth/logout [post]
 func (handlers Handlers) Logout(w http.ResponseWriter, r *http.Request) {
 	exp := time.Duration(handlers.cfg.AccessTokenExp) * time.Minute
 	token := strings.Split(r.Header.Get("Authorization"), " ")
 	if len(token) < 2 {
-		http.Error(w, fmt.Errorf("access token required").Error(), http.StatusUnauthorized)
+		problem.Error(w, r, service.ErrTokenMissing)
 		return
 	}
 	accessToken := token[1]
 
-	err := handlers.svc.Logout(accessToken, exp)
+	refreshToken := ""
+	if refresh, err := r.Cookie("refresh_token"); err == nil {
+		refreshToken = refresh.Value
+	}
+
+	err := handlers.svc.Logout(accessToken, refreshToken, exp)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{
-			"error": err,
-		}).Error("logout failed")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		problem.Error(w, r, err)
 		return
 	}
 
 	cookie := http.Cookie{
 		Name:    "refresh_token",
 		Value:   "",
-		Path:    "/admin/auth",
+		Path:    "/auth",
 		Expires: time.Now().Add(-1 * time.Second), // Expire the cookie immediately
 	}
 	http.SetCookie(w, &cookie)
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"filmography/internal/entities"
+	"filmography/service"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// etag is the strong entity tag of the JSON representation of record. It
+// hashes the body, so the names of the cast and the genres count as much
+// as the fields of the record, and records of different types never share
+// a tag. The user rating of a film is left out: it is computed on read and
+// its weighted score moves with the ratings of every other film, a tag
+// that followed it would fail the If-Match of an edit whenever anyone
+// rates anything.
+func etag(record any) (string, error) {
+	if film, ok := record.(entities.FilmEntity); ok {
+		film.UserRating = entities.RatingSummary{}
+		record = film
+	}
+	body, err := json.Marshal(record)
+	if err != nil {
+		return "", fmt.Errorf("marshal failed: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// setETag sets the ETag of record on the response and returns it, it is
+// empty if the record cannot be encoded.
+func setETag(w http.ResponseWriter, record any) string {
+	tag, err := etag(record)
+	if err != nil {
+		return ""
+	}
+	w.Header().Set("ETag", tag)
+	return tag
+}
+
+// notModified sets the ETag of record and reports whether If-None-Match
+// of the request names it already. It writes 304 then and the caller has
+// nothing left to send.
+func notModified(w http.ResponseWriter, r *http.Request, record any) bool {
+	tag := setETag(w, record)
+	if tag == "" {
+		return false
+	}
+	for _, candidate := range entityTags(r, "If-None-Match") {
+		// If-None-Match compares weakly, W/"x" matches "x".
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatch checks If-Match of the request against the current
+// representation of the record and returns the version a write has to be
+// conditional on, entities.AnyVersion without the header or for "*". load
+// returns the record and its version, it is only called for entity tags.
+// If-Match compares strongly, so a weak tag matches no representation.
+func ifMatch[T any](r *http.Request, load func() (T, int, error)) (int, error) {
+	tags := entityTags(r, "If-Match")
+	if len(tags) == 0 || slices.Contains(tags, "*") {
+		return entities.AnyVersion, nil
+	}
+
+	record, version, err := load()
+	if err != nil {
+		return 0, err
+	}
+	tag, err := etag(record)
+	if err != nil {
+		return 0, err
+	}
+	if !slices.Contains(tags, tag) {
+		return 0, service.ErrVersionMismatch
+	}
+	return version, nil
+}
+
+// entityTags splits the list of entity tags in the header of the request.
+func entityTags(r *http.Request, header string) []string {
+	tags := make([]string, 0)
+	for _, value := range r.Header.Values(header) {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"filmography/internal/entities"
+	"filmography/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestETagFollowsRepresentation(t *testing.T) {
+	film := entities.FilmEntity{ID: "f1", Title: "Heat", Version: 1}
+	actor := entities.ActorEntity{ID: "a1", Name: "Al Pacino", Version: 1}
+
+	filmTag, _ := etag(film)
+	actorTag, _ := etag(actor)
+	if filmTag == actorTag {
+		t.Errorf("film and actor at version 1 share the tag %s", filmTag)
+	}
+
+	// The cast does not bump the version but changes the representation.
+	cast := film
+	cast.Actors = []entities.ActorEntity{actor}
+	if castTag, _ := etag(cast); castTag == filmTag {
+		t.Errorf("tag %s did not change with the cast", filmTag)
+	}
+
+	// The user rating moves with the ratings of other films, it is left
+	// out so that rating does not fail the If-Match of an edit.
+	weighted := 7.9
+	rated := film
+	rated.UserRating = entities.RatingSummary{Count: 1, Mean: 8, Weighted: &weighted}
+	if ratedTag, _ := etag(rated); ratedTag != filmTag {
+		t.Errorf("tag with the user rating = %s, want %s", ratedTag, filmTag)
+	}
+	if again, _ := etag(film); again != filmTag {
+		t.Errorf("tag of the same film = %s, want %s", again, filmTag)
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	film := entities.FilmEntity{ID: "f1", Title: "Heat", Version: 1}
+	tag, _ := etag(film)
+
+	tests := []struct {
+		name, ifNoneMatch string
+		want              bool
+	}{
+		{"no header", "", false},
+		{"same tag", tag, true},
+		{"weak same tag", "W/" + tag, true},
+		{"any", "*", true},
+		{"other tag", `"other"`, false},
+		{"list with the tag", `"other", ` + tag, true},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/film/f1", nil)
+		if tt.ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", tt.ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		if got := notModified(w, r, film); got != tt.want {
+			t.Errorf("%s: notModified() = %v, want %v", tt.name, got, tt.want)
+		}
+		if w.Header().Get("ETag") != tag {
+			t.Errorf("%s: ETag = %q, want %q", tt.name, w.Header().Get("ETag"), tag)
+		}
+		if tt.want && w.Code != http.StatusNotModified {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, http.StatusNotModified)
+		}
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	film := entities.FilmEntity{ID: "f1", Title: "Heat", Version: 3}
+	tag, _ := etag(film)
+	load := func() (entities.FilmEntity, int, error) {
+		return film, film.Version, nil
+	}
+
+	tests := []struct {
+		name, ifMatch string
+		want          int
+		wantErr       error
+	}{
+		{"no header", "", entities.AnyVersion, nil},
+		{"any", "*", entities.AnyVersion, nil},
+		{"current tag", tag, 3, nil},
+		{"list with the current tag", `"stale", ` + tag, 3, nil},
+		{"stale tag", `"stale"`, 0, service.ErrVersionMismatch},
+		{"weak current tag", "W/" + tag, 0, service.ErrVersionMismatch},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPut, "/film/f1", nil)
+		if tt.ifMatch != "" {
+			r.Header.Set("If-Match", tt.ifMatch)
+		}
+		got, err := ifMatch(r, load)
+		if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+			t.Errorf("%s: ifMatch() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("%s: ifMatch() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodPut, "/film/f1", nil)
+	r.Header.Set("If-Match", tag)
+	_, err := ifMatch(r, func() (entities.FilmEntity, int, error) {
+		return entities.FilmEntity{}, 0, service.ErrFilmNotFound
+	})
+	if !errors.Is(err, service.ErrFilmNotFound) {
+		t.Errorf("ifMatch() of a missing film error = %v, want %v", err, service.ErrFilmNotFound)
+	}
+}
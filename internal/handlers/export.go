@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"filmography/internal/problem"
+	"filmography/service"
+	"io"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+type ExportService interface {
+	Export(ctx context.Context, w io.Writer, options service.ExportOptions) error
+}
+
+// exportContentTypes are the content types of the export formats.
+var exportContentTypes = map[service.FileFormat]string{
+	service.FormatJSON:      "application/json",
+	service.FormatCSV:       "text/csv",
+	service.FormatJSONLines: "application/x-ndjson",
+}
+
+// startedWriter remembers whether anything was written, after that an
+// error can no longer be reported as a problem.
+type startedWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (w *startedWriter) Write(p []byte) (int, error) {
+	w.started = true
+	return w.w.Write(p)
+}
+
+// export выгружает каталог.
+// @Summary Выгрузка каталога
+// @Description Выгружает фильмы с составом, съемочной группой, жанрами и тегами и/или актеров в JSON, CSV или NDJSON. Строки передаются клиенту по мере чтения из базы. Файл можно загрузить обратно через POST /import. CSV содержит одну сущность, поэтому entity=all для него недоступен.
+// @Tags Import
+// @Security ApiKeyAuth
+// @Produce json
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "Формат файла" Enums(json, csv, ndjson) default(json)
+// @Param entity query string false "Что выгружать" Enums(films, actors, all) default(films)
+// @Success 200 {array} service.FilmRecord "Фильмы и актеры"
+// @Failure 422 {object} problem.Details "Неверные параметры запроса"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 500 {object} problem.Details "Ошибка при выгрузке"
+// @Router /export [get]
+func (handlers Handlers) export(w http.ResponseWriter, r *http.Request) {
+	options := service.ExportOptions{
+		Format: service.FileFormat(r.URL.Query().Get("format")),
+		Entity: service.ExportEntity(r.URL.Query().Get("entity")),
+	}
+	err := service.ValidateExport(&options)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	handlers.streamExport(w, r, options.Format, string(options.Entity), func(body io.Writer) error {
+		return handlers.svc.Export(r.Context(), body, options)
+	})
+}
+
+// streamExport sends the file written by export as an attachment named
+// name. The format must have been checked, an error is reported as a
+// problem until the first byte is written and aborts the response after.
+func (handlers Handlers) streamExport(w http.ResponseWriter, r *http.Request, format service.FileFormat, name string, export func(body io.Writer) error) {
+	w.Header().Set("Content-Type", exportContentTypes[format])
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+"."+string(format)+`"`)
+
+	body := &startedWriter{w: w}
+	err := export(body)
+	if err == nil {
+		return
+	}
+	if !body.started {
+		w.Header().Del("Content-Disposition")
+		problem.Error(w, r, err)
+		return
+	}
+
+	// The client has got part of the file, aborting the response tells it
+	// the file is incomplete.
+	logrus.WithFields(logrus.Fields{
+		"error": err,
+	}).Error("export failed")
+	panic(http.ErrAbortHandler)
+}
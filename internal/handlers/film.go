@@ -4,62 +4,54 @@ import (
 	"context"
 	"encoding/json"
 	"filmography/internal/entities"
-	"fmt"
-	"github.com/sirupsen/logrus"
+	"filmography/internal/patch"
+	"filmography/internal/problem"
 	"net/http"
+	"net/url"
 	"time"
 )
 
 type FilmService interface {
 	CreateFilm(ctx context.Context, film entities.FilmEntity) error
-	GetFilms(ctx context.Context) ([]entities.FilmEntity, error)
+	GetFilms(ctx context.Context, query entities.FilmQuery) ([]entities.FilmEntity, int, error)
 	GetFilm(ctx context.Context, id string) (entities.FilmEntity, error)
+	GetActorsByFilm(ctx context.Context, filmID string) ([]entities.ActorEntity, error)
+	GetFilmCredits(ctx context.Context, filmID string, role entities.CreditRole) ([]entities.CreditEntity, error)
 	UpdateFilm(ctx context.Context, id string, film entities.FilmEntity) error
-	DeleteFilm(ctx context.Context, id string) error
+	PatchFilm(ctx context.Context, id string, p patch.Patch, version int) (entities.FilmEntity, error)
+	DeleteFilm(ctx context.Context, id string, version int) error
 }
 
 type CreateFilmRequest struct {
-	Title       string                 `json:"name"`
-	Description string                 `json:"description"`
-	ReleaseDate time.Time              `json:"release_date"`
-	Rating      float64                `json:"rating"`
-	Actors      []entities.ActorEntity `json:"actors"`
+	Title       string                  `json:"name"`
+	Description string                  `json:"description"`
+	ReleaseDate time.Time               `json:"release_date"`
+	Rating      float64                 `json:"rating"`
+	Actors      []entities.ActorEntity  `json:"actors"`
+	Credits     []entities.CreditEntity `json:"credits"`
+	Genres      []entities.GenreEntity  `json:"genres"`
+	Tags        []string                `json:"tags"`
 }
 
 // createFilm создает новый фильм.
 // @Summary Создает фильм.
-// @Description Создает новый фильм на основе переданных данных.
+// @Description Создает новый фильм на основе переданных данных. Актеры, участники съемочной группы и жанры задаются по ID, теги — произвольные слова, приводятся к нижнему регистру. Актеры без записи в credits попадают в титры без порядка.
 // @Tags Film
+// @Security ApiKeyAuth
 // @Accept json
 // @Produce json
 // @Param film body entities.FilmEntity true "Данные фильма"
 // @Success 201 {object} map[string]string
-// @Failure 400 {string} string "Ошибка при декодировании JSON"
-// @Failure 500 {string} string "Ошибка при создании фильма"
+// @Failure 400 {object} problem.Details "Ошибка при декодировании JSON"
+// @Failure 422 {object} problem.Details "Ошибка валидации, неизвестный участник или жанр"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 500 {object} problem.Details "Ошибка при создании фильма"
 // @Router /film [post]
 func (handlers Handlers) createFilm(w http.ResponseWriter, r *http.Request) {
 	request := CreateFilmRequest{}
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, fmt.Errorf("failed to decode JSON: %w", err).Error(), http.StatusBadRequest)
-		logrus.WithField("error", err).Error("failed to decode JSON")
-		return
-	}
-
-	if len(request.Title) == 0 || len(request.Title) > 150 {
-		http.Error(w, "invalid name length", http.StatusBadRequest)
-		logrus.Error("invalid name length")
-		return
-	}
-
-	if len(request.Description) > 1000 {
-		http.Error(w, "invalid description length", http.StatusBadRequest)
-		logrus.Error("invalid description length")
-		return
-	}
-
-	if request.Rating < 0 || request.Rating > 10 {
-		http.Error(w, "invalid rating value", http.StatusBadRequest)
-		logrus.Error("invalid rating value")
+		problem.Error(w, r, errMalformedJSON)
 		return
 	}
 
@@ -69,12 +61,14 @@ func (handlers Handlers) createFilm(w http.ResponseWriter, r *http.Request) {
 		ReleaseDate: request.ReleaseDate,
 		Rating:      request.Rating,
 		Actors:      request.Actors,
+		Credits:     request.Credits,
+		Genres:      request.Genres,
+		Tags:        request.Tags,
 	}
 
 	err := handlers.svc.CreateFilm(r.Context(), film)
 	if err != nil {
-		http.Error(w, fmt.Errorf("failed to create film: %w", err).Error(), http.StatusInternalServerError)
-		logrus.WithField("error", err).Error("failed to create film")
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -90,42 +84,95 @@ func (handlers Handlers) createFilm(w http.ResponseWriter, r *http.Request) {
 
 // getFilms возвращает список фильмов.
 // @Summary Возвращает список фильмов
-// @Description Возвращает список всех фильмов.
+// @Description Возвращает страницу фильмов с учетом фильтров и сортировки.
 // @Tags Film
+// @Security ApiKeyAuth
+// @Param limit query int false "Размер страницы (1-100)" default(20)
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой"
+// @Param sort query string false "Поля сортировки: title, rating, release_date; минус для убывания" example(rating,-release_date)
+// @Param min_rating query number false "Минимальный рейтинг"
+// @Param released_after query string false "Вышедшие после даты (YYYY-MM-DD)"
+// @Param genre query string false "ID жанров через запятую"
+// @Param tag query string false "Теги через запятую"
+// @Param match query string false "Фильм должен иметь любой или все из жанров и тегов" Enums(any, all) default(any)
 // @Produce json
-// @Success 200 {array} entities.FilmEntity "Список фильмов"
-// @Failure 500 {string} string "Ошибка при получении фильмов"
+// @Success 200 {object} ListResponse[entities.FilmEntity] "Страница фильмов"
+// @Failure 422 {object} problem.Details "Неверные параметры запроса"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 500 {object} problem.Details "Ошибка при получении фильмов"
 // @Router /film [get]
 func (handlers Handlers) getFilms(w http.ResponseWriter, r *http.Request) {
-	films, err := handlers.svc.GetFilms(r.Context())
+	values := r.URL.Query()
+	listQuery, err := parseListQuery[entities.FilmEntity](values, entities.FilmSortFields)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+	query, err := parseFilmQuery(values)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+	query.ListQuery = listQuery
+
+	films, total, err := handlers.svc.GetFilms(r.Context(), query)
 	if err != nil {
-		http.Error(w, fmt.Errorf("failed to get films: %w", err).Error(), http.StatusInternalServerError)
-		logrus.WithField("error", err).Error("failed to get films")
+		problem.Error(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(films)
+	err = json.NewEncoder(w).Encode(newListResponse(films, total, listQuery))
 	if err != nil {
 		return
 	}
 }
 
+// parseFilmQuery reads the filters of a film list.
+func parseFilmQuery(values url.Values) (entities.FilmQuery, error) {
+	var err error
+	query := entities.FilmQuery{
+		Genres: parseListParam(values, "genre"),
+		Tags:   parseListParam(values, "tag"),
+	}
+	if query.MinRating, err = parseFloatParam(values, "min_rating"); err != nil {
+		return entities.FilmQuery{}, err
+	}
+	if query.ReleasedAfter, err = parseDateParam(values, "released_after"); err != nil {
+		return entities.FilmQuery{}, err
+	}
+	if query.MatchAll, err = parseMatchParam(values); err != nil {
+		return entities.FilmQuery{}, err
+	}
+	return query, nil
+}
+
 // getFilm возвращает информацию о фильме по его ID.
 // @Summary Возвращает информацию о фильме
 // @Description Возвращает информацию о фильме по указанному ID.
 // @Tags Film
-// @Param id query string true "ID фильма"
+// @Security ApiKeyAuth
+// @Param id path string true "ID фильма"
+// @Param If-None-Match header string false "ETag, полученный ранее"
 // @Produce json
 // @Success 200 {object} entities.FilmEntity "Информация о фильме"
-// @Failure 500 {string} string "Ошибка при получении фильма"
+// @Header 200 {string} ETag "Хеш ответа без пользовательского рейтинга"
+// @Success 304 "Фильм не изменился"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Фильм не найден"
+// @Failure 500 {object} problem.Details "Ошибка при получении фильма"
 // @Router /film/{id} [get]
 func (handlers Handlers) getFilm(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+	id := r.PathValue("id")
 	film, err := handlers.svc.GetFilm(r.Context(), id)
 	if err != nil {
-		http.Error(w, fmt.Errorf("failed to get film: %w", err).Error(), http.StatusInternalServerError)
-		logrus.WithField("error", err).Error("failed to get film")
+		problem.Error(w, r, err)
+		return
+	}
+	if notModified(w, r, film) {
 		return
 	}
 
@@ -136,32 +183,112 @@ func (handlers Handlers) getFilm(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// filmVersion returns the version of the film a write is conditional on,
+// see ifMatch.
+func (handlers Handlers) filmVersion(r *http.Request, id string) (int, error) {
+	return ifMatch(r, func() (entities.FilmEntity, int, error) {
+		film, err := handlers.svc.GetFilm(r.Context(), id)
+		return film, film.Version, err
+	})
+}
+
+// getFilmActors возвращает актерский состав фильма.
+// @Summary Возвращает актеров фильма
+// @Description Возвращает актеров, снимавшихся в фильме с указанным ID.
+// @Tags Film
+// @Security ApiKeyAuth
+// @Param id path string true "ID фильма"
+// @Produce json
+// @Success 200 {array} entities.ActorEntity "Список актеров фильма"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Фильм не найден"
+// @Failure 500 {object} problem.Details "Ошибка при получении актеров фильма"
+// @Router /film/{id}/actors [get]
+func (handlers Handlers) getFilmActors(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	actors, err := handlers.svc.GetActorsByFilm(r.Context(), id)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(actors)
+	if err != nil {
+		return
+	}
+}
+
+// getFilmCredits возвращает титры фильма.
+// @Summary Возвращает титры фильма
+// @Description Возвращает всех участников фильма с указанным ID: режиссеров, сценаристов, продюсеров, композиторов и актеров. Актеры упорядочены по порядку в титрах.
+// @Tags Film
+// @Security ApiKeyAuth
+// @Param id path string true "ID фильма"
+// @Param role query string false "Роль участника" Enums(director, writer, producer, composer, actor)
+// @Produce json
+// @Success 200 {array} entities.CreditEntity "Титры фильма"
+// @Failure 422 {object} problem.Details "Неизвестная роль"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Фильм не найден"
+// @Failure 500 {object} problem.Details "Ошибка при получении титров фильма"
+// @Router /film/{id}/credits [get]
+func (handlers Handlers) getFilmCredits(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	role := entities.CreditRole(r.URL.Query().Get("role"))
+	credits, err := handlers.svc.GetFilmCredits(r.Context(), id, role)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(credits)
+	if err != nil {
+		return
+	}
+}
+
 // updateFilm обновляет информацию о фильме.
 // @Summary Обновляет информацию о фильме
 // @Description Обновляет информацию о фильме с указанным ID на основе переданных данных.
 // @Tags Film
-// @Param id query string true "ID фильма"
+// @Security ApiKeyAuth
+// @Param id path string true "ID фильма"
+// @Param If-Match header string false "ETag, полученный ранее; без заголовка запрос безусловный"
 // @Accept json
 // @Produce json
 // @Param film body entities.FilmEntity true "Данные фильма"
 // @Success 201 {object} map[string]string
-// @Failure 400 {string} string "Ошибка при декодировании JSON"
-// @Failure 500 {string} string "Ошибка при обновлении фильма"
+// @Failure 400 {object} problem.Details "Ошибка при декодировании JSON"
+// @Failure 422 {object} problem.Details "Ошибка валидации, неизвестный участник или жанр"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Фильм не найден"
+// @Failure 412 {object} problem.Details "Фильм изменился после получения ETag"
+// @Failure 500 {object} problem.Details "Ошибка при обновлении фильма"
 // @Router /film/{id} [put]
 func (handlers Handlers) updateFilm(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+	id := r.PathValue("id")
+	version, err := handlers.filmVersion(r, id)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
 	film := entities.FilmEntity{}
-	err := json.NewDecoder(r.Body).Decode(&film)
+	err = json.NewDecoder(r.Body).Decode(&film)
 	if err != nil {
-		http.Error(w, fmt.Errorf("failed to decode JSON: %w", err).Error(), http.StatusBadRequest)
-		logrus.WithField("error", err).Error("failed to decode JSON")
+		problem.Error(w, r, errMalformedJSON)
 		return
 	}
+	film.Version = version
 
 	err = handlers.svc.UpdateFilm(r.Context(), id, film)
 	if err != nil {
-		http.Error(w, fmt.Errorf("failed to update film: %w", err).Error(), http.StatusInternalServerError)
-		logrus.WithField("error", err).Error("failed to update film")
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -175,20 +302,82 @@ func (handlers Handlers) updateFilm(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// patchFilm частично обновляет фильм.
+// @Summary Частично обновляет фильм
+// @Description Применяет JSON Merge Patch (RFC 7396) или JSON Patch (RFC 6902) к фильму в том виде, в котором его возвращает GET /film/{id}. Результат проверяется так же, как при создании, и сохраняется целиком или не сохраняется вовсе. Актеры, убранные из Actors или Credits, убираются из состава.
+// @Tags Film
+// @Security ApiKeyAuth
+// @Param id path string true "ID фильма"
+// @Param If-Match header string false "ETag, полученный ранее; без заголовка запрос безусловный"
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param patch body object true "Патч фильма"
+// @Success 200 {object} entities.FilmEntity "Фильм после изменения"
+// @Header 200 {string} ETag "Хеш ответа без пользовательского рейтинга"
+// @Failure 400 {object} problem.Details "Некорректный патч"
+// @Failure 422 {object} problem.Details "Ошибка валидации, неизвестный участник или жанр"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Фильм не найден"
+// @Failure 409 {object} problem.Details "Патч не применим: нет пути или не прошла операция test"
+// @Failure 412 {object} problem.Details "Фильм изменился после получения ETag"
+// @Failure 415 {object} problem.Details "Неизвестный формат патча"
+// @Failure 500 {object} problem.Details "Ошибка при обновлении фильма"
+// @Router /film/{id} [patch]
+func (handlers Handlers) patchFilm(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	version, err := handlers.filmVersion(r, id)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	p, err := readPatch(w, r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	film, err := handlers.svc.PatchFilm(r.Context(), id, p, version)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	setETag(w, film)
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(film)
+	if err != nil {
+		return
+	}
+}
+
 // deleteFilm удаляет фильма по его ID.
 // @Summary Удаляет фильм
 // @Description Удаляет фильм с указанным ID.
 // @Tags Film
-// @Param id query string true "ID фильма"
+// @Security ApiKeyAuth
+// @Param id path string true "ID фильма"
+// @Param If-Match header string false "ETag, полученный ранее; без заголовка запрос безусловный"
 // @Success 200 {object} map[string]string
-// @Failure 500 {string} string "Ошибка при удалении фильма"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Фильм не найден"
+// @Failure 412 {object} problem.Details "Фильм изменился после получения ETag"
+// @Failure 500 {object} problem.Details "Ошибка при удалении фильма"
 // @Router /film/{id} [delete]
 func (handlers Handlers) deleteFilm(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
-	err := handlers.svc.DeleteFilm(r.Context(), id)
+	id := r.PathValue("id")
+	version, err := handlers.filmVersion(r, id)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	err = handlers.svc.DeleteFilm(r.Context(), id, version)
 	if err != nil {
-		http.Error(w, fmt.Errorf("failed to delete film: %w", err).Error(), http.StatusInternalServerError)
-		logrus.WithField("error", err).Error("failed to delete film")
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"filmography/internal/entities"
+	"filmography/internal/problem"
+	"filmography/service"
+	"net/http"
+)
+
+type GenreService interface {
+	CreateGenre(ctx context.Context, genre entities.GenreEntity) (entities.GenreEntity, error)
+	GetGenres(ctx context.Context) ([]entities.GenreCount, error)
+	GetGenre(ctx context.Context, id string) (entities.GenreEntity, error)
+	UpdateGenre(ctx context.Context, id string, genre entities.GenreEntity) error
+	DeleteGenre(ctx context.Context, id string) error
+	GetFilmFacets(ctx context.Context, query entities.FilmQuery) (service.FilmFacets, error)
+}
+
+type GenreRequest struct {
+	Name string `json:"name"`
+}
+
+// createGenre создает новый жанр.
+// @Summary Создает жанр
+// @Description Создает жанр с уникальным без учета регистра названием.
+// @Tags Genre
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param genre body GenreRequest true "Данные жанра"
+// @Success 201 {object} entities.GenreEntity "Жанр"
+// @Failure 400 {object} problem.Details "Ошибка при декодировании JSON"
+// @Failure 422 {object} problem.Details "Ошибка валидации"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 409 {object} problem.Details "Жанр с таким названием уже есть"
+// @Failure 500 {object} problem.Details "Ошибка при создании жанра"
+// @Router /genre [post]
+func (handlers Handlers) createGenre(w http.ResponseWriter, r *http.Request) {
+	request := GenreRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		problem.Error(w, r, errMalformedJSON)
+		return
+	}
+
+	genre, err := handlers.svc.CreateGenre(r.Context(), entities.GenreEntity{Name: request.Name})
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(genre)
+	if err != nil {
+		return
+	}
+}
+
+// getGenres возвращает список жанров.
+// @Summary Возвращает список жанров
+// @Description Возвращает все жанры по алфавиту с числом фильмов каждого жанра.
+// @Tags Genre
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} entities.GenreCount "Жанры"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 500 {object} problem.Details "Ошибка при получении жанров"
+// @Router /genre [get]
+func (handlers Handlers) getGenres(w http.ResponseWriter, r *http.Request) {
+	genres, err := handlers.svc.GetGenres(r.Context())
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(genres)
+	if err != nil {
+		return
+	}
+}
+
+// getGenre возвращает жанр по его ID.
+// @Summary Возвращает жанр
+// @Description Возвращает жанр с указанным ID.
+// @Tags Genre
+// @Security ApiKeyAuth
+// @Param id path string true "ID жанра"
+// @Produce json
+// @Success 200 {object} entities.GenreEntity "Жанр"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Жанр не найден"
+// @Failure 500 {object} problem.Details "Ошибка при получении жанра"
+// @Router /genre/{id} [get]
+func (handlers Handlers) getGenre(w http.ResponseWriter, r *http.Request) {
+	genre, err := handlers.svc.GetGenre(r.Context(), r.PathValue("id"))
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(genre)
+	if err != nil {
+		return
+	}
+}
+
+// updateGenre переименовывает жанр.
+// @Summary Переименовывает жанр
+// @Description Переименовывает жанр с указанным ID, фильмы остаются в жанре.
+// @Tags Genre
+// @Security ApiKeyAuth
+// @Param id path string true "ID жанра"
+// @Accept json
+// @Produce json
+// @Param genre body GenreRequest true "Данные жанра"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} problem.Details "Ошибка при декодировании JSON"
+// @Failure 422 {object} problem.Details "Ошибка валидации"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Жанр не найден"
+// @Failure 409 {object} problem.Details "Жанр с таким названием уже есть"
+// @Failure 500 {object} problem.Details "Ошибка при обновлении жанра"
+// @Router /genre/{id} [put]
+func (handlers Handlers) updateGenre(w http.ResponseWriter, r *http.Request) {
+	request := GenreRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		problem.Error(w, r, errMalformedJSON)
+		return
+	}
+
+	err := handlers.svc.UpdateGenre(r.Context(), r.PathValue("id"), entities.GenreEntity{Name: request.Name})
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response := map[string]string{
+		"message": "genre is successfully updated",
+	}
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		return
+	}
+}
+
+// deleteGenre удаляет жанр по его ID.
+// @Summary Удаляет жанр
+// @Description Удаляет жанр с указанным ID, фильмы жанра остаются в каталоге.
+// @Tags Genre
+// @Security ApiKeyAuth
+// @Param id path string true "ID жанра"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Жанр не найден"
+// @Failure 500 {object} problem.Details "Ошибка при удалении жанра"
+// @Router /genre/{id} [delete]
+func (handlers Handlers) deleteGenre(w http.ResponseWriter, r *http.Request) {
+	err := handlers.svc.DeleteGenre(r.Context(), r.PathValue("id"))
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	response := map[string]string{
+		"message": "genre is successfully deleted",
+	}
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		return
+	}
+}
+
+// getFilmFacets считает фильмы по жанрам и тегам.
+// @Summary Возвращает фасеты фильмов
+// @Description Считает фильмы, подходящие под фильтры GET /film, по каждому жанру и по самым частым тегам.
+// @Tags Film
+// @Security ApiKeyAuth
+// @Param min_rating query number false "Минимальный рейтинг"
+// @Param released_after query string false "Вышедшие после даты (YYYY-MM-DD)"
+// @Param genre query string false "ID жанров через запятую"
+// @Param tag query string false "Теги через запятую"
+// @Param match query string false "Фильм должен иметь любой или все из жанров и тегов" Enums(any, all) default(any)
+// @Produce json
+// @Success 200 {object} service.FilmFacets "Число фильмов по жанрам и тегам"
+// @Failure 422 {object} problem.Details "Неверные параметры запроса"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 500 {object} problem.Details "Ошибка при подсчете фильмов"
+// @Router /film/facets [get]
+func (handlers Handlers) getFilmFacets(w http.ResponseWriter, r *http.Request) {
+	query, err := parseFilmQuery(r.URL.Query())
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	facets, err := handlers.svc.GetFilmFacets(r.Context(), query)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(facets)
+	if err != nil {
+		return
+	}
+}
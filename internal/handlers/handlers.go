@@ -2,9 +2,30 @@ package handlers
 
 import (
 	"filmography/config"
+	"filmography/internal/entities"
+	"filmography/internal/middleware"
+	"filmography/internal/problem"
+	"filmography/service"
 	"fmt"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"net/http"
+	"time"
+)
+
+var (
+	// readRoles may browse the catalogue.
+	readRoles = []entities.Role{entities.User, entities.Admin}
+	// adminRoles may change the catalogue and manage users.
+	adminRoles = []entities.Role{entities.Admin}
+)
+
+var (
+	// errMalformedJSON is returned when a request body cannot be decoded.
+	errMalformedJSON = service.NewError(service.CodeMalformedRequest, "request body is not valid JSON")
+	// errNoRoute and errMethodNotAllowed replace the plain text answers of
+	// the mux, see routeProblems.
+	errNoRoute          = service.NewError(service.CodeNotFound, "no route matches the path")
+	errMethodNotAllowed = service.NewError(service.CodeMethodNotAllowed, "the route does not allow the method")
 )
 
 type Handlers struct {
@@ -21,91 +42,175 @@ type Service interface {
 	FilmService
 	AuthService
 	UserService
+	SearchService
+	ImportService
+	ExportService
+	RatingService
+	ReviewService
+	WatchService
+	GenreService
 }
 
-func SetRequestHandlers(service Service, cfg config.Config) (*http.ServeMux, error) {
+func SetRequestHandlers(service Service, cfg config.Config) (http.Handler, error) {
 	mux := http.NewServeMux()
 	handlers := NewHandlers(service, cfg)
 
-	mux.Handle("/swagger/", httpSwagger.Handler(httpSwagger.URL("/docs/")))
-
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-
-		if r.Method == http.MethodGet {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			if _, err := fmt.Fprint(w, `"hello message"`); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-		}
-	})
-
-	mux.HandleFunc("/actor", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodPost {
-			handlers.VerifyToken(w, r, http.HandlerFunc(handlers.createActor))
-		} else if r.Method == http.MethodGet {
-			handlers.VerifyToken(w, r, http.HandlerFunc(handlers.getActors))
-		}
-	})
-
-	mux.HandleFunc("/actor/", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodGet {
-			handlers.VerifyToken(w, r, http.HandlerFunc(handlers.getActor))
-		} else if r.Method == http.MethodPut {
-			handlers.VerifyToken(w, r, http.HandlerFunc(handlers.updateActor))
-		} else if r.Method == http.MethodDelete {
-			handlers.VerifyToken(w, r, http.HandlerFunc(handlers.deleteActor))
-		}
-	})
-
-	mux.HandleFunc("/film", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodPost {
-			handlers.VerifyToken(w, r, http.HandlerFunc(handlers.createFilm))
-		} else if r.Method == http.MethodGet {
-			handlers.VerifyToken(w, r, http.HandlerFunc(handlers.getFilms))
+	// The request timeout is set per route. The file transfers go without
+	// it: an export streams for as long as the client reads and an import
+	// takes as long as the file, both end with the connection.
+	timeout := middleware.Timeout(time.Duration(cfg.RequestTimeout) * time.Second)
+	readTransfer := middleware.Auth(service, readRoles...)
+	adminTransfer := middleware.Auth(service, adminRoles...)
+	read := func(next http.Handler) http.Handler {
+		return timeout(readTransfer(next))
+	}
+	admin := func(next http.Handler) http.Handler {
+		return timeout(adminTransfer(next))
+	}
+	deprecated := func(successor string, next http.HandlerFunc) http.Handler {
+		return middleware.Deprecated(successor)(timeout(next))
+	}
+
+	mux.Handle("GET /swagger/", timeout(httpSwagger.Handler(httpSwagger.URL("/docs/"))))
+
+	mux.Handle("GET /{$}", timeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := fmt.Fprint(w, `"hello message"`); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
-	})
-
-	mux.HandleFunc("/film/", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodGet {
-			handlers.VerifyToken(w, r, http.HandlerFunc(handlers.getFilm))
-		} else if r.Method == http.MethodPut {
-			handlers.VerifyToken(w, r, http.HandlerFunc(handlers.updateFilm))
-		} else if r.Method == http.MethodDelete {
-			handlers.VerifyToken(w, r, http.HandlerFunc(handlers.deleteFilm))
-		}
-	})
-
-	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodPost {
-			handlers.VerifyToken(w, r, http.HandlerFunc(handlers.createUser))
-		} else if r.Method == http.MethodGet {
-			handlers.VerifyToken(w, r, http.HandlerFunc(handlers.getUsers))
-		}
-	})
+	})))
+
+	mux.Handle("GET /actor", read(http.HandlerFunc(handlers.getActors)))
+	mux.Handle("POST /actor", admin(http.HandlerFunc(handlers.createActor)))
+	mux.Handle("GET /actor/{id}", read(http.HandlerFunc(handlers.getActor)))
+	mux.Handle("PUT /actor/{id}", admin(http.HandlerFunc(handlers.updateActor)))
+	mux.Handle("PATCH /actor/{id}", admin(http.HandlerFunc(handlers.patchActor)))
+	mux.Handle("DELETE /actor/{id}", admin(http.HandlerFunc(handlers.deleteActor)))
+	mux.Handle("GET /actor/{id}/films", read(http.HandlerFunc(handlers.getActorFilms)))
+	mux.Handle("GET /actor/{id}/credits", read(http.HandlerFunc(handlers.getActorCredits)))
+
+	// People behind the camera are stored with the actors, /person serves
+	// the same resource under its general name.
+	mux.Handle("GET /person", read(http.HandlerFunc(handlers.getActors)))
+	mux.Handle("POST /person", admin(http.HandlerFunc(handlers.createActor)))
+	mux.Handle("GET /person/{id}", read(http.HandlerFunc(handlers.getActor)))
+	mux.Handle("PUT /person/{id}", admin(http.HandlerFunc(handlers.updateActor)))
+	mux.Handle("PATCH /person/{id}", admin(http.HandlerFunc(handlers.patchActor)))
+	mux.Handle("DELETE /person/{id}", admin(http.HandlerFunc(handlers.deleteActor)))
+	mux.Handle("GET /person/{id}/credits", read(http.HandlerFunc(handlers.getActorCredits)))
+
+	mux.Handle("GET /film", read(http.HandlerFunc(handlers.getFilms)))
+	mux.Handle("POST /film", admin(http.HandlerFunc(handlers.createFilm)))
+	mux.Handle("GET /film/facets", read(http.HandlerFunc(handlers.getFilmFacets)))
+	mux.Handle("GET /film/{id}", read(http.HandlerFunc(handlers.getFilm)))
+	mux.Handle("PUT /film/{id}", admin(http.HandlerFunc(handlers.updateFilm)))
+	mux.Handle("PATCH /film/{id}", admin(http.HandlerFunc(handlers.patchFilm)))
+	mux.Handle("DELETE /film/{id}", admin(http.HandlerFunc(handlers.deleteFilm)))
+	mux.Handle("GET /film/{id}/actors", read(http.HandlerFunc(handlers.getFilmActors)))
+	mux.Handle("GET /film/{id}/credits", read(http.HandlerFunc(handlers.getFilmCredits)))
+	mux.Handle("PUT /film/{id}/rating", read(http.HandlerFunc(handlers.rateFilm)))
+	mux.Handle("GET /film/{id}/rating", read(http.HandlerFunc(handlers.getFilmRating)))
+	mux.Handle("GET /film/{id}/reviews", read(http.HandlerFunc(handlers.getFilmReviews)))
+	mux.Handle("POST /film/{id}/reviews", read(http.HandlerFunc(handlers.createReview)))
+
+	mux.Handle("GET /genre", read(http.HandlerFunc(handlers.getGenres)))
+	mux.Handle("POST /genre", admin(http.HandlerFunc(handlers.createGenre)))
+	mux.Handle("GET /genre/{id}", read(http.HandlerFunc(handlers.getGenre)))
+	mux.Handle("PUT /genre/{id}", admin(http.HandlerFunc(handlers.updateGenre)))
+	mux.Handle("DELETE /genre/{id}", admin(http.HandlerFunc(handlers.deleteGenre)))
+
+	mux.Handle("GET /review", admin(http.HandlerFunc(handlers.getReviews)))
+	mux.Handle("GET /review/{id}", read(http.HandlerFunc(handlers.getReview)))
+	mux.Handle("PUT /review/{id}", read(http.HandlerFunc(handlers.updateReview)))
+	mux.Handle("DELETE /review/{id}", read(http.HandlerFunc(handlers.deleteReview)))
+	mux.Handle("PUT /review/{id}/status", admin(http.HandlerFunc(handlers.moderateReview)))
+
+	mux.Handle("GET /me/ratings", read(http.HandlerFunc(handlers.getRatingHistory)))
+	mux.Handle("GET /me/reviews", read(http.HandlerFunc(handlers.getMyReviews)))
+	mux.Handle("GET /me/watchlist", read(http.HandlerFunc(handlers.getWatchlist)))
+	mux.Handle("GET /me/watchlist/export", readTransfer(http.HandlerFunc(handlers.exportWatchlist)))
+	mux.Handle("PUT /me/watchlist/{film_id}", read(http.HandlerFunc(handlers.addToWatchlist)))
+	mux.Handle("DELETE /me/watchlist/{film_id}", read(http.HandlerFunc(handlers.removeFromWatchlist)))
+	mux.Handle("GET /me/history", read(http.HandlerFunc(handlers.getHistory)))
+	mux.Handle("POST /me/history", read(http.HandlerFunc(handlers.addHistoryEntry)))
+	mux.Handle("GET /me/history/export", readTransfer(http.HandlerFunc(handlers.exportHistory)))
+	mux.Handle("GET /me/history/{id}", read(http.HandlerFunc(handlers.getHistoryEntry)))
+	mux.Handle("PUT /me/history/{id}", read(http.HandlerFunc(handlers.updateHistoryEntry)))
+	mux.Handle("DELETE /me/history/{id}", read(http.HandlerFunc(handlers.deleteHistoryEntry)))
+
+	mux.Handle("GET /user", admin(http.HandlerFunc(handlers.getUsers)))
+	mux.Handle("POST /user", admin(http.HandlerFunc(handlers.createUser)))
+	mux.Handle("GET /user/{id}", admin(http.HandlerFunc(handlers.getUser)))
+	mux.Handle("PUT /user/{id}", admin(http.HandlerFunc(handlers.updateUser)))
+	mux.Handle("PATCH /user/{id}", admin(http.HandlerFunc(handlers.patchUser)))
+	mux.Handle("DELETE /user/{id}", admin(http.HandlerFunc(handlers.deleteUser)))
+
+	mux.Handle("GET /search", read(http.HandlerFunc(handlers.search)))
+
+	mux.Handle("POST /import", adminTransfer(http.HandlerFunc(handlers.importFilms)))
+	mux.Handle("GET /export", adminTransfer(http.HandlerFunc(handlers.export)))
+
+	mux.Handle("POST /auth/sign-in", timeout(http.HandlerFunc(handlers.SignIn)))
+	mux.Handle("POST /auth/sign-up", timeout(http.HandlerFunc(handlers.SignUp)))
+	mux.Handle("POST /auth/refresh", timeout(http.HandlerFunc(handlers.Refresh)))
+	mux.Handle("POST /auth/logout", timeout(http.HandlerFunc(handlers.Logout)))
+
+	// The auth routes used to answer any method under a trailing slash,
+	// sign-in was misspelt and refresh was documented as GET. The old paths
+	// stay for the clients built against them until they move over.
+	mux.Handle("POST /auth/sing-in/{$}", deprecated("/auth/sign-in", handlers.SignIn))
+	mux.Handle("POST /auth/sign-up/{$}", deprecated("/auth/sign-up", handlers.SignUp))
+	mux.Handle("GET /auth/refresh", deprecated("/auth/refresh", handlers.Refresh))
+	mux.Handle("GET /auth/refresh/{$}", deprecated("/auth/refresh", handlers.Refresh))
+	mux.Handle("POST /auth/logout/{$}", deprecated("/auth/logout", handlers.Logout))
+
+	return middleware.Chain(routeProblems(mux),
+		middleware.RequestID(),
+		middleware.Logging(),
+		middleware.Recovery(),
+	), nil
+}
 
-	mux.HandleFunc("/user/", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodGet {
-			handlers.VerifyToken(w, r, http.HandlerFunc(handlers.getUser))
-		} else if r.Method == http.MethodPut {
-			handlers.VerifyToken(w, r, http.HandlerFunc(handlers.updateUser))
-		} else if r.Method == http.MethodDelete {
-			handlers.VerifyToken(w, r, http.HandlerFunc(handlers.deleteUser))
+// routeProblems answers the requests no route takes with a problem like
+// every other error, 404 for an unknown path and 405 for a known path
+// called with another method. The Allow header of the mux is kept.
+func routeProblems(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
 		}
+		mux.ServeHTTP(&routeProblemWriter{ResponseWriter: w, r: r}, r)
 	})
+}
 
-	mux.HandleFunc("/auth/sing-in/", func(w http.ResponseWriter, r *http.Request) {
-		handlers.SignIn(w, r)
-	})
-
-	mux.HandleFunc("/auth/refresh/", func(w http.ResponseWriter, r *http.Request) {
-		handlers.Refresh(w, r)
-	})
+// routeProblemWriter writes a problem in place of the 404 and 405 of the
+// mux and drops their text body. Other answers, such as the redirect to a
+// cleaned path, pass as they are.
+type routeProblemWriter struct {
+	http.ResponseWriter
+	r        *http.Request
+	replaced bool
+}
 
-	mux.HandleFunc("/auth/logout/", func(w http.ResponseWriter, r *http.Request) {
-		handlers.Logout(w, r)
-	})
+func (w *routeProblemWriter) WriteHeader(status int) {
+	switch status {
+	case http.StatusNotFound:
+		w.replaced = true
+		problem.Error(w.ResponseWriter, w.r, errNoRoute)
+	case http.StatusMethodNotAllowed:
+		w.replaced = true
+		problem.Error(w.ResponseWriter, w.r, errMethodNotAllowed)
+	default:
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
 
-	return mux, nil
+func (w *routeProblemWriter) Write(body []byte) (int, error) {
+	if w.replaced {
+		return len(body), nil
+	}
+	return w.ResponseWriter.Write(body)
 }
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"filmography/config"
+	"filmography/internal/entities"
+	"filmography/internal/problem"
+	"filmography/service"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// stubService answers the calls the routes under test make, any other
+// call panics on the nil Service.
+type stubService struct {
+	Service
+	// deadline records whether the context of the last call had one.
+	deadline *bool
+}
+
+func (s stubService) Verify(token string) (entities.TokenClaims, error) {
+	return entities.TokenClaims{UserID: "1", Role: entities.Admin, Type: service.AccessToken}, nil
+}
+
+func (s stubService) CheckToken(token string) bool {
+	return true
+}
+
+func (s stubService) record(ctx context.Context) {
+	_, *s.deadline = ctx.Deadline()
+}
+
+func (s stubService) GetGenres(ctx context.Context) ([]entities.GenreCount, error) {
+	s.record(ctx)
+	return []entities.GenreCount{}, nil
+}
+
+// GetGenre outlives the deadline of the request, see TestRequestTimeout.
+func (s stubService) GetGenre(ctx context.Context, id string) (entities.GenreEntity, error) {
+	s.record(ctx)
+	if !*s.deadline {
+		return entities.GenreEntity{}, service.ErrGenreNotFound
+	}
+	<-ctx.Done()
+	return entities.GenreEntity{}, fmt.Errorf("get genre failed: %w", ctx.Err())
+}
+
+func (s stubService) Export(ctx context.Context, w io.Writer, options service.ExportOptions) error {
+	s.record(ctx)
+	return nil
+}
+
+func (s stubService) ExportWatchlist(ctx context.Context, w io.Writer, userID string, format service.FileFormat) error {
+	s.record(ctx)
+	return nil
+}
+
+func (s stubService) Import(ctx context.Context, r io.Reader, options service.ImportOptions) (service.ImportReport, error) {
+	s.record(ctx)
+	return service.ImportReport{}, nil
+}
+
+func (s stubService) SingIn(ctx context.Context, authInfo entities.Auth) (*entities.Token, error) {
+	return &entities.Token{Access: "access", RT: "refresh"}, nil
+}
+
+func (s stubService) Refresh(ctx context.Context, refreshToken string) (*entities.Token, error) {
+	return &entities.Token{Access: "access", RT: "refresh"}, nil
+}
+
+func TestRequestTimeout(t *testing.T) {
+	var deadline bool
+	handler, err := SetRequestHandlers(stubService{deadline: &deadline}, config.Config{RequestTimeout: 30})
+	if err != nil {
+		t.Fatalf("SetRequestHandlers() error = %v", err)
+	}
+
+	tests := []struct {
+		method, target string
+		wantDeadline   bool
+	}{
+		{http.MethodGet, "/genre", true},
+		{http.MethodGet, "/export", false},
+		{http.MethodGet, "/me/watchlist/export", false},
+		{http.MethodPost, "/import?format=json", false},
+	}
+
+	for _, tt := range tests {
+		deadline = !tt.wantDeadline
+		r := httptest.NewRequest(tt.method, tt.target, strings.NewReader("[]"))
+		r.Header.Set("Authorization", "Bearer token")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.target, w.Code, http.StatusOK)
+		}
+		if deadline != tt.wantDeadline {
+			t.Errorf("%s %s: context deadline = %v, want %v", tt.method, tt.target, deadline, tt.wantDeadline)
+		}
+	}
+
+	// A handler that runs out of the deadline answers with a timeout
+	// problem instead of an internal error.
+	handler, err = SetRequestHandlers(stubService{deadline: &deadline}, config.Config{RequestTimeout: 1})
+	if err != nil {
+		t.Fatalf("SetRequestHandlers() error = %v", err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/genre/1", nil)
+	r.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	details := problem.Details{}
+	if err := json.NewDecoder(w.Body).Decode(&details); err != nil {
+		t.Fatalf("decode problem failed: %v", err)
+	}
+	if w.Code != http.StatusServiceUnavailable || details.Code != service.CodeTimeout {
+		t.Errorf("GET /genre/1 after the deadline = %d %s, want %d %s", w.Code, details.Code, http.StatusServiceUnavailable, service.CodeTimeout)
+	}
+	if got := w.Header().Get("Content-Type"); got != problem.ContentType {
+		t.Errorf("GET /genre/1 after the deadline: Content-Type = %q, want %q", got, problem.ContentType)
+	}
+}
+
+func TestRouting(t *testing.T) {
+	var deadline bool
+	handler, err := SetRequestHandlers(stubService{deadline: &deadline}, config.Config{})
+	if err != nil {
+		t.Fatalf("SetRequestHandlers() error = %v", err)
+	}
+
+	tests := []struct {
+		method, target string
+		wantStatus     int
+		wantAllow      string
+		wantDeprecated bool
+	}{
+		{http.MethodPost, "/auth/sign-in", http.StatusOK, "", false},
+		{http.MethodPost, "/auth/refresh", http.StatusOK, "", false},
+		{http.MethodGet, "/genre", http.StatusOK, "", false},
+
+		// The old auth paths answer as before and are marked deprecated.
+		{http.MethodPost, "/auth/sing-in/", http.StatusOK, "", true},
+		{http.MethodGet, "/auth/refresh", http.StatusOK, "", true},
+		{http.MethodGet, "/auth/refresh/", http.StatusOK, "", true},
+
+		{http.MethodGet, "/auth/sign-in", http.StatusMethodNotAllowed, "POST", false},
+		{http.MethodGet, "/auth/sing-in/", http.StatusMethodNotAllowed, "POST", false},
+		{http.MethodDelete, "/genre", http.StatusMethodNotAllowed, "GET, HEAD, POST", false},
+		{http.MethodPost, "/film/1", http.StatusMethodNotAllowed, "DELETE, GET, HEAD, PATCH, PUT", false},
+
+		{http.MethodGet, "/films", http.StatusNotFound, "", false},
+		{http.MethodGet, "/film/1/cast", http.StatusNotFound, "", false},
+		{http.MethodPost, "/auth/sing-in/again", http.StatusNotFound, "", false},
+		{http.MethodPost, "/auth/sign-in/", http.StatusNotFound, "", false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(`{"login": "root", "password": "rootpass123"}`))
+		r.Header.Set("Authorization", "Bearer token")
+		r.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh"})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.target, w.Code, tt.wantStatus)
+		}
+		if got := w.Header().Get("Allow"); got != tt.wantAllow {
+			t.Errorf("%s %s: Allow = %q, want %q", tt.method, tt.target, got, tt.wantAllow)
+		}
+		if got := w.Header().Get("Deprecation") == "true"; got != tt.wantDeprecated {
+			t.Errorf("%s %s: deprecated = %v, want %v", tt.method, tt.target, got, tt.wantDeprecated)
+		}
+		// The mux answers unknown routes with a problem like the handlers.
+		if w.Code >= http.StatusBadRequest {
+			if got := w.Header().Get("Content-Type"); got != problem.ContentType {
+				t.Errorf("%s %s: Content-Type = %q, want %q", tt.method, tt.target, got, problem.ContentType)
+			}
+			details := problem.Details{}
+			if err := json.NewDecoder(w.Body).Decode(&details); err != nil || details.Status != tt.wantStatus {
+				t.Errorf("%s %s: problem = %+v, %v, want status %d", tt.method, tt.target, details, err, tt.wantStatus)
+			}
+		}
+	}
+
+	// Redirects of the mux pass through as they are.
+	for _, target := range []string{"/swagger", "/genre/../genre"} {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code < http.StatusMultipleChoices || w.Code >= http.StatusBadRequest || w.Header().Get("Location") == "" {
+			t.Errorf("GET %s: status = %d, Location = %q, want a redirect", target, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"filmography/internal/problem"
+	"filmography/service"
+	"io"
+	"mime"
+	"net/http"
+)
+
+type ImportService interface {
+	Import(ctx context.Context, r io.Reader, options service.ImportOptions) (service.ImportReport, error)
+}
+
+// importFormats maps the content types of import files to their format.
+var importFormats = map[string]service.FileFormat{
+	"application/json":     service.FormatJSON,
+	"text/csv":             service.FormatCSV,
+	"application/x-ndjson": service.FormatJSONLines,
+	"application/jsonl":    service.FormatJSONLines,
+}
+
+// importFilms загружает фильмы и актеров из файла.
+// @Summary Массовый импорт фильмов
+// @Description Загружает фильмы с составом, съемочной группой, жанрами и тегами и актеров из JSON, CSV или JSON Lines в формате GET /export. Люди ищутся по имени и дате рождения, жанры по названию, и создаются, если их нет, фильм с уже существующим id заменяется. Если в записи нет credits, genres или tags, сохраненные значения остаются. Фильмы сохраняются пачками в транзакциях, ошибочные строки пропускаются и перечисляются в отчете. CSV с фильмами содержит колонки id, title, description, release_date, rating, cast, credits, genres, tags, где cast — список name|gender|birthday через точку с запятой, credits — список role|name|gender|birthday|character|billing, genres и tags — названия через точку с запятой. CSV с актерами — колонки id, name, gender, birthday. В JSON актеры отличаются полем type.
+// @Tags Import
+// @Security ApiKeyAuth
+// @Accept json
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param format query string false "Формат файла, по умолчанию из Content-Type" Enums(json, csv, ndjson)
+// @Param dry_run query bool false "Проверить файл, ничего не сохраняя"
+// @Success 200 {object} service.ImportReport "Отчет об импорте"
+// @Failure 422 {object} problem.Details "Неизвестный формат или неверный заголовок CSV"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 500 {object} problem.Details "Ошибка при импорте"
+// @Router /import [post]
+func (handlers Handlers) importFilms(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := parseBoolParam(r.URL.Query(), "dry_run")
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	format := service.FileFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		format = importFormats[mediaType]
+	}
+
+	report, err := handlers.svc.Import(r.Context(), r.Body, service.ImportOptions{
+		Format:    format,
+		DryRun:    dryRun,
+		BatchSize: handlers.cfg.ImportBatchSize,
+	})
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(report)
+	if err != nil {
+		return
+	}
+}
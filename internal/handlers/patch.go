@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"errors"
+	"filmography/internal/patch"
+	"filmography/service"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// acceptPatch lists the patch formats PATCH requests accept.
+var acceptPatch = strings.Join([]string{patch.MergePatchType, patch.JSONPatchType}, ", ")
+
+// readPatch reads the patch in the body of the request, the format is told
+// by Content-Type. An unknown format is answered with Accept-Patch.
+func readPatch(w http.ResponseWriter, r *http.Request) (patch.Patch, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, service.WrapError(service.CodeMalformedRequest, "request body cannot be read", err)
+	}
+
+	p, err := patch.Parse(mediaType, body)
+	switch {
+	case errors.Is(err, patch.ErrUnsupported):
+		w.Header().Set("Accept-Patch", acceptPatch)
+		return nil, service.WrapError(service.CodeUnsupportedMedia, "Content-Type must be one of "+acceptPatch, err)
+	case err != nil:
+		return nil, service.WrapError(service.CodeMalformedRequest, err.Error(), err)
+	}
+	return p, nil
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"filmography/internal/entities"
+	"filmography/service"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// ListResponse is the envelope of every paginated list. NextCursor
+// continues the list behind its last item, unlike the offset it does not
+// skip or repeat items when the list changes in between.
+type ListResponse[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func newListResponse[T entities.Sortable](items []T, total int, query entities.ListQuery) ListResponse[T] {
+	response := ListResponse[T]{
+		Items:  items,
+		Total:  total,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}
+	if next := query.Offset + len(items); len(items) > 0 && next < total {
+		response.NextCursor = encodeCursor(next, query.Sort, entities.SortKeys(items[len(items)-1], query.Sort))
+	}
+	return response
+}
+
+// cursor is the decoded form of the opaque next_cursor value: the sort
+// keys of the last item of a page, see entities.ListQuery.After, the sort
+// they were taken for and the number of items up to the item.
+type cursor struct {
+	Offset int             `json:"o"`
+	Sort   string          `json:"s"`
+	Keys   json.RawMessage `json:"k"`
+}
+
+func encodeCursor(offset int, sort []entities.SortField, keys []any) string {
+	k, _ := json.Marshal(keys)
+	data, _ := json.Marshal(cursor{Offset: offset, Sort: formatSort(sort), Keys: k})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns the offset and the sort keys of a cursor made for
+// the sort. The keys get the types of the sort keys of T.
+func decodeCursor[T entities.Sortable](value string, sort []entities.SortField) (int, []any, error) {
+	errInvalid := invalidParam("cursor", "is not a valid cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return 0, nil, errInvalid
+	}
+	c := cursor{}
+	if err := json.Unmarshal(data, &c); err != nil || c.Offset < 0 {
+		return 0, nil, errInvalid
+	}
+	if c.Sort != formatSort(sort) {
+		return 0, nil, invalidParam("cursor", "was made for another sort")
+	}
+	raw := make([]json.RawMessage, 0)
+	if err := json.Unmarshal(c.Keys, &raw); err != nil || len(raw) != len(sort)+1 {
+		return 0, nil, errInvalid
+	}
+
+	var item T
+	keys := make([]any, 0, len(raw))
+	for i, s := range append(slices.Clone(sort), entities.SortField{Field: "id"}) {
+		zero := item.SortKey(s.Field)
+		if zero == nil {
+			return 0, nil, errInvalid
+		}
+		key := reflect.New(reflect.TypeOf(zero))
+		if err := json.Unmarshal(raw[i], key.Interface()); err != nil {
+			return 0, nil, errInvalid
+		}
+		keys = append(keys, key.Elem().Interface())
+	}
+	return c.Offset, keys, nil
+}
+
+// formatSort renders sort the way the sort parameter is written.
+func formatSort(sort []entities.SortField) string {
+	fields := make([]string, 0, len(sort))
+	for _, s := range sort {
+		if s.Desc {
+			fields = append(fields, "-"+s.Field)
+		} else {
+			fields = append(fields, s.Field)
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// parseListQuery reads limit, offset, cursor and sort from the query
+// string of a list of T. Without a sort parameter the list is ordered by
+// defaultSort. A cursor takes precedence over offset.
+func parseListQuery[T entities.Sortable](values url.Values, sortFields []string, defaultSort ...entities.SortField) (entities.ListQuery, error) {
+	query := entities.ListQuery{Limit: defaultLimit}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return entities.ListQuery{}, invalidParam("limit", fmt.Sprintf("must be between 1 and %d", maxLimit))
+		}
+		query.Limit = limit
+	}
+
+	if v := values.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return entities.ListQuery{}, invalidParam("offset", "must be a non-negative integer")
+		}
+		query.Offset = offset
+	}
+
+	if v := values.Get("sort"); v != "" {
+		for _, field := range strings.Split(v, ",") {
+			sort := entities.SortField{Field: strings.TrimSpace(field)}
+			if strings.HasPrefix(sort.Field, "-") {
+				sort.Field = sort.Field[1:]
+				sort.Desc = true
+			}
+			if !slices.Contains(sortFields, sort.Field) {
+				return entities.ListQuery{}, invalidParam("sort", fmt.Sprintf("unknown sort field %q", sort.Field))
+			}
+			query.Sort = append(query.Sort, sort)
+		}
+	}
+	if query.Sort == nil {
+		query.Sort = defaultSort
+	}
+
+	if v := values.Get("cursor"); v != "" {
+		offset, keys, err := decodeCursor[T](v, query.Sort)
+		if err != nil {
+			return entities.ListQuery{}, err
+		}
+		query.Offset = offset
+		query.After = keys
+	}
+
+	return query, nil
+}
+
+func parseFloatParam(values url.Values, name string) (*float64, error) {
+	v := values.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, invalidParam(name, "must be a number")
+	}
+	return &f, nil
+}
+
+func parseDateParam(values url.Values, name string) (*time.Time, error) {
+	v := values.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return nil, invalidParam(name, "must be a date in YYYY-MM-DD format")
+	}
+	return &t, nil
+}
+
+// parseListParam reads a list given as comma separated values, the
+// parameter may be repeated.
+func parseListParam(values url.Values, name string) []string {
+	list := make([]string, 0)
+	for _, v := range values[name] {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// parseMatchParam reads whether list filters match any or all values.
+func parseMatchParam(values url.Values) (bool, error) {
+	switch values.Get("match") {
+	case "", "any":
+		return false, nil
+	case "all":
+		return true, nil
+	}
+	return false, invalidParam("match", "must be any or all")
+}
+
+// invalidParam reports a single malformed query parameter.
+func invalidParam(name, message string) error {
+	return service.ValidationError([]service.FieldError{{Field: name, Message: message}})
+}
+
+func parseBoolParam(values url.Values, name string) (bool, error) {
+	v := values.Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, invalidParam(name, "must be true or false")
+	}
+	return b, nil
+}
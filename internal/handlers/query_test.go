@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"filmography/internal/entities"
+	"filmography/service"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListCursor(t *testing.T) {
+	films := []entities.FilmEntity{
+		{ID: "f1", Title: "Heat", Rating: 8.3, ReleaseDate: time.Date(1995, 12, 15, 0, 0, 0, 0, time.UTC)},
+		{ID: "f2", Title: "Ronin", Rating: 7.2, ReleaseDate: time.Date(1998, 9, 25, 0, 0, 0, 0, time.UTC)},
+	}
+	values := url.Values{"limit": {"2"}, "sort": {"-rating,release_date"}}
+	query, err := parseListQuery[entities.FilmEntity](values, entities.FilmSortFields)
+	if err != nil {
+		t.Fatalf("parseListQuery() error = %v", err)
+	}
+
+	response := newListResponse(films, 5, query)
+	if response.NextCursor == "" {
+		t.Fatalf("newListResponse() has no next cursor")
+	}
+	if last := newListResponse(films, 2, query); last.NextCursor != "" {
+		t.Errorf("newListResponse() of the last page has next cursor %q", last.NextCursor)
+	}
+
+	values.Set("cursor", response.NextCursor)
+	next, err := parseListQuery[entities.FilmEntity](values, entities.FilmSortFields)
+	if err != nil {
+		t.Fatalf("parseListQuery(next cursor) error = %v", err)
+	}
+	wantAfter := []any{7.2, time.Date(1998, 9, 25, 0, 0, 0, 0, time.UTC), "f2"}
+	if next.Offset != 2 || !reflect.DeepEqual(next.After, wantAfter) {
+		t.Errorf("parseListQuery(next cursor) = offset %d after %#v, want offset 2 after %#v", next.Offset, next.After, wantAfter)
+	}
+
+	// The default sort is part of the cursor like a requested one.
+	entries := []entities.HistoryEntry{{ID: "h1", WatchedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}}
+	historyValues := url.Values{"limit": {"1"}}
+	historyQuery, err := parseListQuery[entities.HistoryEntry](historyValues, entities.HistorySortFields, entities.SortField{Field: "watched_at", Desc: true})
+	if err != nil {
+		t.Fatalf("parseListQuery(history) error = %v", err)
+	}
+	historyValues.Set("cursor", newListResponse(entries, 2, historyQuery).NextCursor)
+	historyQuery, err = parseListQuery[entities.HistoryEntry](historyValues, entities.HistorySortFields, entities.SortField{Field: "watched_at", Desc: true})
+	if err != nil {
+		t.Fatalf("parseListQuery(history cursor) error = %v", err)
+	}
+	if want := []any{time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), "h1"}; !reflect.DeepEqual(historyQuery.After, want) {
+		t.Errorf("parseListQuery(history cursor) after = %#v, want %#v", historyQuery.After, want)
+	}
+
+	tests := []struct {
+		name   string
+		values url.Values
+	}{
+		{"other sort", url.Values{"sort": {"title"}, "cursor": {response.NextCursor}}},
+		{"default sort", url.Values{"cursor": {response.NextCursor}}},
+		{"not base64", url.Values{"sort": {"-rating,release_date"}, "cursor": {"!!"}}},
+		{"not json", url.Values{"sort": {"-rating,release_date"}, "cursor": {base64.RawURLEncoding.EncodeToString([]byte(`[1]`))}}},
+		{"negative offset", url.Values{"sort": {"-rating,release_date"}, "cursor": {base64.RawURLEncoding.EncodeToString([]byte(`{"o":-1,"s":"-rating,release_date","k":[7.2,"1998-09-25T00:00:00Z","f2"]}`))}}},
+		{"missing key", url.Values{"sort": {"-rating,release_date"}, "cursor": {base64.RawURLEncoding.EncodeToString([]byte(`{"o":2,"s":"-rating,release_date","k":[7.2,"f2"]}`))}}},
+		{"wrong key type", url.Values{"sort": {"-rating,release_date"}, "cursor": {base64.RawURLEncoding.EncodeToString([]byte(`{"o":2,"s":"-rating,release_date","k":["high","1998-09-25T00:00:00Z","f2"]}`))}}},
+		{"not a time", url.Values{"sort": {"-rating,release_date"}, "cursor": {base64.RawURLEncoding.EncodeToString([]byte(`{"o":2,"s":"-rating,release_date","k":[7.2,"yesterday","f2"]}`))}}},
+	}
+
+	for _, tt := range tests {
+		_, err := parseListQuery[entities.FilmEntity](tt.values, entities.FilmSortFields)
+		var svcErr *service.Error
+		if !errors.As(err, &svcErr) || svcErr.Code != service.CodeValidationFailed {
+			t.Errorf("%s: parseListQuery() error = %v, want %s", tt.name, err, service.CodeValidationFailed)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"filmography/internal/entities"
+	"filmography/internal/middleware"
+	"filmography/internal/problem"
+	"filmography/service"
+	"net/http"
+)
+
+type RatingService interface {
+	RateFilm(ctx context.Context, filmID string, userID string, rating float64) (entities.RatingSummary, error)
+	GetFilmRating(ctx context.Context, filmID string, userID string) (entities.FilmRating, error)
+	GetRatingHistory(ctx context.Context, query entities.RatingQuery) ([]entities.FilmRating, int, error)
+}
+
+type RateFilmRequest struct {
+	Rating *float64 `json:"rating"`
+}
+
+type RateFilmResponse struct {
+	Rating  float64                `json:"rating"`
+	Summary entities.RatingSummary `json:"summary"`
+}
+
+// rateFilm ставит или меняет оценку фильма текущим юзером.
+// @Summary Оценивает фильм
+// @Description Ставит фильму оценку текущего юзера от 0 до 10, повторная оценка заменяет прежнюю.
+// @Tags Rating
+// @Security ApiKeyAuth
+// @Param id path string true "ID фильма"
+// @Accept json
+// @Produce json
+// @Param rating body RateFilmRequest true "Оценка"
+// @Success 200 {object} RateFilmResponse "Оценка и сводка оценок фильма"
+// @Failure 400 {object} problem.Details "Ошибка при декодировании JSON"
+// @Failure 422 {object} problem.Details "Ошибка валидации"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Фильм не найден"
+// @Failure 500 {object} problem.Details "Ошибка при сохранении оценки"
+// @Router /film/{id}/rating [put]
+func (handlers Handlers) rateFilm(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	request := RateFilmRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		problem.Error(w, r, errMalformedJSON)
+		return
+	}
+	if request.Rating == nil {
+		problem.Error(w, r, service.ValidationError([]service.FieldError{{Field: "rating", Message: "is required"}}))
+		return
+	}
+
+	summary, err := handlers.svc.RateFilm(r.Context(), r.PathValue("id"), claims.UserID, *request.Rating)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(RateFilmResponse{
+		Rating:  entities.RoundRating(*request.Rating),
+		Summary: summary,
+	})
+	if err != nil {
+		return
+	}
+}
+
+// getFilmRating возвращает оценку фильма текущим юзером.
+// @Summary Возвращает оценку фильма
+// @Description Возвращает оценку, которую текущий юзер поставил фильму с указанным ID.
+// @Tags Rating
+// @Security ApiKeyAuth
+// @Param id path string true "ID фильма"
+// @Produce json
+// @Success 200 {object} entities.FilmRating "Оценка фильма"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Оценка не найдена"
+// @Failure 500 {object} problem.Details "Ошибка при получении оценки"
+// @Router /film/{id}/rating [get]
+func (handlers Handlers) getFilmRating(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	rating, err := handlers.svc.GetFilmRating(r.Context(), r.PathValue("id"), claims.UserID)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(rating)
+	if err != nil {
+		return
+	}
+}
+
+// getRatingHistory возвращает историю оценок текущего юзера.
+// @Summary Возвращает историю оценок
+// @Description Возвращает страницу всех оценок текущего юзера, начиная с последней, включая замененные.
+// @Tags Rating
+// @Security ApiKeyAuth
+// @Param limit query int false "Размер страницы (1-100)" default(20)
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой"
+// @Param film_id query string false "ID фильма"
+// @Produce json
+// @Success 200 {object} ListResponse[entities.FilmRating] "Страница оценок"
+// @Failure 422 {object} problem.Details "Неверные параметры запроса"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 500 {object} problem.Details "Ошибка при получении оценок"
+// @Router /me/ratings [get]
+func (handlers Handlers) getRatingHistory(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	values := r.URL.Query()
+	listQuery, err := parseListQuery[entities.FilmRating](values, nil, entities.RatingHistorySort...)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+	query := entities.RatingQuery{ListQuery: listQuery, UserID: claims.UserID, FilmID: values.Get("film_id")}
+
+	ratings, total, err := handlers.svc.GetRatingHistory(r.Context(), query)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(newListResponse(ratings, total, listQuery))
+	if err != nil {
+		return
+	}
+}
@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"filmography/internal/entities"
+	"filmography/internal/middleware"
+	"filmography/internal/problem"
+	"filmography/service"
+	"net/http"
+	"net/url"
+)
+
+type ReviewService interface {
+	CreateReview(ctx context.Context, filmID string, userID string, text string) (entities.ReviewEntity, error)
+	GetFilmReviews(ctx context.Context, query entities.ReviewQuery) ([]entities.ReviewEntity, int, error)
+	GetReviews(ctx context.Context, query entities.ReviewQuery) ([]entities.ReviewEntity, int, error)
+	GetReview(ctx context.Context, id string, user entities.TokenClaims) (entities.ReviewEntity, error)
+	UpdateReview(ctx context.Context, id string, text string, user entities.TokenClaims) (entities.ReviewEntity, error)
+	ModerateReview(ctx context.Context, id string, status entities.ReviewStatus, moderatorID string) (entities.ReviewEntity, error)
+	DeleteReview(ctx context.Context, id string, user entities.TokenClaims) error
+}
+
+type ReviewRequest struct {
+	Text string `json:"text"`
+}
+
+type ModerateReviewRequest struct {
+	Status entities.ReviewStatus `json:"status"`
+}
+
+// createReview добавляет отзыв текущего юзера на фильм.
+// @Summary Добавляет отзыв
+// @Description Добавляет отзыв текущего юзера на фильм, отзыв публикуется после модерации. Юзер может оставить один отзыв на фильм.
+// @Tags Review
+// @Security ApiKeyAuth
+// @Param id path string true "ID фильма"
+// @Accept json
+// @Produce json
+// @Param review body ReviewRequest true "Текст отзыва"
+// @Success 201 {object} entities.ReviewEntity "Отзыв"
+// @Failure 400 {object} problem.Details "Ошибка при декодировании JSON"
+// @Failure 422 {object} problem.Details "Ошибка валидации"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Фильм не найден"
+// @Failure 409 {object} problem.Details "Юзер уже оставил отзыв на фильм"
+// @Failure 500 {object} problem.Details "Ошибка при добавлении отзыва"
+// @Router /film/{id}/reviews [post]
+func (handlers Handlers) createReview(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	request := ReviewRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		problem.Error(w, r, errMalformedJSON)
+		return
+	}
+
+	review, err := handlers.svc.CreateReview(r.Context(), r.PathValue("id"), claims.UserID, request.Text)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(review)
+	if err != nil {
+		return
+	}
+}
+
+// getFilmReviews возвращает опубликованные отзывы на фильм.
+// @Summary Возвращает отзывы на фильм
+// @Description Возвращает страницу опубликованных отзывов на фильм, по умолчанию сначала новые.
+// @Tags Review
+// @Security ApiKeyAuth
+// @Param id path string true "ID фильма"
+// @Param limit query int false "Размер страницы (1-100)" default(20)
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой"
+// @Param sort query string false "Поля сортировки: created_at, updated_at; минус для убывания" default(-created_at)
+// @Produce json
+// @Success 200 {object} ListResponse[entities.ReviewEntity] "Страница отзывов"
+// @Failure 422 {object} problem.Details "Неверные параметры запроса"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Фильм не найден"
+// @Failure 500 {object} problem.Details "Ошибка при получении отзывов"
+// @Router /film/{id}/reviews [get]
+func (handlers Handlers) getFilmReviews(w http.ResponseWriter, r *http.Request) {
+	listQuery, err := parseReviewListQuery(r.URL.Query(), true)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+	query := entities.ReviewQuery{ListQuery: listQuery, FilmID: r.PathValue("id")}
+
+	reviews, total, err := handlers.svc.GetFilmReviews(r.Context(), query)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(newListResponse(reviews, total, listQuery))
+	if err != nil {
+		return
+	}
+}
+
+// getReviews возвращает очередь модерации.
+// @Summary Возвращает очередь модерации
+// @Description Возвращает страницу отзывов в указанном статусе, по умолчанию ожидающих модерации, сначала старые.
+// @Tags Review
+// @Security ApiKeyAuth
+// @Param status query string false "Статус: pending, published, rejected" default(pending)
+// @Param film_id query string false "ID фильма"
+// @Param user_id query string false "ID автора"
+// @Param limit query int false "Размер страницы (1-100)" default(20)
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой"
+// @Param sort query string false "Поля сортировки: created_at, updated_at; минус для убывания" default(created_at)
+// @Produce json
+// @Success 200 {object} ListResponse[entities.ReviewEntity] "Страница отзывов"
+// @Failure 422 {object} problem.Details "Неверные параметры запроса"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Фильм не найден"
+// @Failure 500 {object} problem.Details "Ошибка при получении отзывов"
+// @Router /review [get]
+func (handlers Handlers) getReviews(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	listQuery, err := parseReviewListQuery(values, false)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+	query := entities.ReviewQuery{
+		ListQuery: listQuery,
+		FilmID:    values.Get("film_id"),
+		UserID:    values.Get("user_id"),
+		Status:    entities.ReviewStatus(values.Get("status")),
+	}
+	if query.Status == "" {
+		query.Status = entities.ReviewPending
+	}
+
+	reviews, total, err := handlers.svc.GetReviews(r.Context(), query)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(newListResponse(reviews, total, listQuery))
+	if err != nil {
+		return
+	}
+}
+
+// getMyReviews возвращает отзывы текущего юзера.
+// @Summary Возвращает свои отзывы
+// @Description Возвращает страницу отзывов текущего юзера в любом статусе, сначала новые.
+// @Tags Review
+// @Security ApiKeyAuth
+// @Param status query string false "Статус: pending, published, rejected"
+// @Param limit query int false "Размер страницы (1-100)" default(20)
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой"
+// @Param sort query string false "Поля сортировки: created_at, updated_at; минус для убывания" default(-created_at)
+// @Produce json
+// @Success 200 {object} ListResponse[entities.ReviewEntity] "Страница отзывов"
+// @Failure 422 {object} problem.Details "Неверные параметры запроса"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 500 {object} problem.Details "Ошибка при получении отзывов"
+// @Router /me/reviews [get]
+func (handlers Handlers) getMyReviews(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	values := r.URL.Query()
+	listQuery, err := parseReviewListQuery(values, true)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+	query := entities.ReviewQuery{
+		ListQuery: listQuery,
+		UserID:    claims.UserID,
+		Status:    entities.ReviewStatus(values.Get("status")),
+	}
+
+	reviews, total, err := handlers.svc.GetReviews(r.Context(), query)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(newListResponse(reviews, total, listQuery))
+	if err != nil {
+		return
+	}
+}
+
+// getReview возвращает отзыв по его ID.
+// @Summary Возвращает отзыв
+// @Description Возвращает опубликованный отзыв, свой отзыв в любом статусе или, для админов, любой отзыв.
+// @Tags Review
+// @Security ApiKeyAuth
+// @Param id path string true "ID отзыва"
+// @Produce json
+// @Success 200 {object} entities.ReviewEntity "Отзыв"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Отзыв не найден"
+// @Failure 500 {object} problem.Details "Ошибка при получении отзыва"
+// @Router /review/{id} [get]
+func (handlers Handlers) getReview(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	review, err := handlers.svc.GetReview(r.Context(), r.PathValue("id"), claims)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(review)
+	if err != nil {
+		return
+	}
+}
+
+// updateReview изменяет текст отзыва.
+// @Summary Изменяет отзыв
+// @Description Изменяет текст своего отзыва, измененный отзыв снова проходит модерацию.
+// @Tags Review
+// @Security ApiKeyAuth
+// @Param id path string true "ID отзыва"
+// @Accept json
+// @Produce json
+// @Param review body ReviewRequest true "Текст отзыва"
+// @Success 200 {object} entities.ReviewEntity "Отзыв"
+// @Failure 400 {object} problem.Details "Ошибка при декодировании JSON"
+// @Failure 422 {object} problem.Details "Ошибка валидации"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Отзыв другого юзера"
+// @Failure 404 {object} problem.Details "Отзыв не найден"
+// @Failure 500 {object} problem.Details "Ошибка при изменении отзыва"
+// @Router /review/{id} [put]
+func (handlers Handlers) updateReview(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	request := ReviewRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		problem.Error(w, r, errMalformedJSON)
+		return
+	}
+
+	review, err := handlers.svc.UpdateReview(r.Context(), r.PathValue("id"), request.Text, claims)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(review)
+	if err != nil {
+		return
+	}
+}
+
+// moderateReview публикует или отклоняет отзыв.
+// @Summary Модерирует отзыв
+// @Description Публикует или отклоняет (скрывает) отзыв.
+// @Tags Review
+// @Security ApiKeyAuth
+// @Param id path string true "ID отзыва"
+// @Accept json
+// @Produce json
+// @Param status body ModerateReviewRequest true "Новый статус: published или rejected"
+// @Success 200 {object} entities.ReviewEntity "Отзыв"
+// @Failure 400 {object} problem.Details "Ошибка при декодировании JSON"
+// @Failure 422 {object} problem.Details "Ошибка валидации"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Отзыв не найден"
+// @Failure 500 {object} problem.Details "Ошибка при модерации отзыва"
+// @Router /review/{id}/status [put]
+func (handlers Handlers) moderateReview(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	request := ModerateReviewRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		problem.Error(w, r, errMalformedJSON)
+		return
+	}
+
+	review, err := handlers.svc.ModerateReview(r.Context(), r.PathValue("id"), request.Status, claims.UserID)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(review)
+	if err != nil {
+		return
+	}
+}
+
+// deleteReview удаляет отзыв.
+// @Summary Удаляет отзыв
+// @Description Удаляет свой отзыв, админы могут удалить любой.
+// @Tags Review
+// @Security ApiKeyAuth
+// @Param id path string true "ID отзыва"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Отзыв другого юзера"
+// @Failure 404 {object} problem.Details "Отзыв не найден"
+// @Failure 500 {object} problem.Details "Ошибка при удалении отзыва"
+// @Router /review/{id} [delete]
+func (handlers Handlers) deleteReview(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	err := handlers.svc.DeleteReview(r.Context(), r.PathValue("id"), claims)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	response := map[string]string{
+		"message": "review is successfully deleted",
+	}
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		return
+	}
+}
+
+// parseReviewListQuery reads the page of a review list, without a sort it
+// is ordered by creation, newest first when newest is set.
+func parseReviewListQuery(values url.Values, newest bool) (entities.ListQuery, error) {
+	return parseListQuery[entities.ReviewEntity](values, entities.ReviewSortFields, entities.SortField{Field: "created_at", Desc: newest})
+}
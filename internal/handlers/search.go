@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"filmography/internal/entities"
+	"filmography/internal/problem"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type SearchService interface {
+	Search(ctx context.Context, query string, limit int) ([]entities.SearchResult, error)
+}
+
+type SearchResponse struct {
+	Query string                  `json:"query"`
+	Items []entities.SearchResult `json:"items"`
+}
+
+// search ищет фильмы и актеров.
+// @Summary Полнотекстовый поиск
+// @Description Ищет фильмы по названию и описанию и актеров по имени. Каждое слово запроса ищется как префикс, результаты отсортированы по релевантности, совпадения выделены тегом mark, остальной текст фрагмента экранирован как HTML.
+// @Tags Search
+// @Security ApiKeyAuth
+// @Param q query string true "Поисковый запрос"
+// @Param limit query int false "Количество результатов (1-100)" default(20)
+// @Produce json
+// @Success 200 {object} SearchResponse "Результаты поиска"
+// @Failure 422 {object} problem.Details "Неверные параметры запроса"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 500 {object} problem.Details "Ошибка при поиске"
+// @Router /search [get]
+func (handlers Handlers) search(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		problem.Error(w, r, invalidParam("q", "must not be empty"))
+		return
+	}
+
+	limit := defaultLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxLimit {
+			problem.Error(w, r, invalidParam("limit", fmt.Sprintf("must be between 1 and %d", maxLimit)))
+			return
+		}
+		limit = n
+	}
+
+	results, err := handlers.svc.Search(r.Context(), query, limit)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(SearchResponse{Query: query, Items: results})
+	if err != nil {
+		return
+	}
+}
@@ -4,56 +4,60 @@ import (
 	"context"
 	"encoding/json"
 	"filmography/internal/entities"
+	"filmography/internal/patch"
+	"filmography/internal/problem"
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"net/http"
 )
 
 type UserService interface {
-	CreateUser(ctx context.Context, user entities.UserEntity) error
-	GetUsers(ctx context.Context) ([]entities.UserEntity, error)
+	CreateUser(ctx context.Context, user entities.UserEntity, password string) error
+	GetUsers(ctx context.Context, query entities.UserQuery) ([]entities.UserEntity, int, error)
 	GetUser(ctx context.Context, id string) (entities.UserEntity, error)
 	UpdateUser(ctx context.Context, id string, user entities.UserEntity) error
-	DeleteUser(ctx context.Context, id string) error
+	PatchUser(ctx context.Context, id string, p patch.Patch, version int) (entities.UserEntity, error)
+	DeleteUser(ctx context.Context, id string, version int) error
+}
+
+type CreateUserRequest struct {
+	Username string        `json:"username"`
+	Password string        `json:"password"`
+	Role     entities.Role `json:"role"`
 }
 
 // createUser создает нового юзера.
 // @Summary Создает юзера.
 // @Description Создает нового юзера на основе переданных данных.
 // @Tags User
+// @Security ApiKeyAuth
 // @Accept json
 // @Produce json
-// @Param user body entities.UserEntity true "Данные юзера"
+// @Param user body CreateUserRequest true "Данные юзера"
 // @Success 201 {object} map[string]string
-// @Failure 400 {string} string "Ошибка при декодировании JSON"
-// @Failure 500 {string} string "Ошибка при создании юзера"
+// @Failure 400 {object} problem.Details "Ошибка при декодировании JSON"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 409 {object} problem.Details "Юзер уже существует"
+// @Failure 422 {object} problem.Details "Ошибка валидации"
+// @Failure 500 {object} problem.Details "Ошибка при создании юзера"
 // @Router /user [post]
 func (handlers Handlers) createUser(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		fmt.Fprintf(w, "Method %s not allowed", r.Method)
-		return
-	}
-
-	var user entities.UserEntity
+	var request CreateUserRequest
 	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&user)
+	err := decoder.Decode(&request)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Error decoding request body: %v", err)
-		logrus.WithFields(logrus.Fields{
-			"error": err,
-		}).Error("decode request body failed")
+		problem.Error(w, r, errMalformedJSON)
 		return
 	}
 
-	err = handlers.svc.CreateUser(context.Background(), user)
+	user := entities.UserEntity{
+		Username: request.Username,
+		Role:     request.Role,
+	}
+	err = handlers.svc.CreateUser(r.Context(), user, request.Password)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Error creating user: %v", err)
-		logrus.WithFields(logrus.Fields{
-			"error": err,
-		}).Error("create user failed")
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -63,31 +67,38 @@ func (handlers Handlers) createUser(w http.ResponseWriter, r *http.Request) {
 
 // getUsers возвращает список юзеров.
 // @Summary Возвращает список юзеров
-// @Description Возвращает список всех юзеров.
+// @Description Возвращает страницу юзеров с учетом фильтров и сортировки.
 // @Tags User
+// @Security ApiKeyAuth
+// @Param limit query int false "Размер страницы (1-100)" default(20)
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой"
+// @Param sort query string false "Поля сортировки: username, role; минус для убывания"
+// @Param role query string false "Роль"
 // @Produce json
-// @Success 200 {array} entities.UserEntity "Список юзеров"
-// @Failure 500 {string} string "Ошибка при получении юзеров"
+// @Success 200 {object} ListResponse[entities.UserEntity] "Страница юзеров"
+// @Failure 422 {object} problem.Details "Неверные параметры запроса"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 500 {object} problem.Details "Ошибка при получении юзеров"
 // @Router /user [get]
 func (handlers Handlers) getUsers(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		fmt.Fprintf(w, "Method %s not allowed", r.Method)
+	values := r.URL.Query()
+	listQuery, err := parseListQuery[entities.UserEntity](values, entities.UserSortFields)
+	if err != nil {
+		problem.Error(w, r, err)
 		return
 	}
+	query := entities.UserQuery{ListQuery: listQuery, Role: entities.Role(values.Get("role"))}
 
-	users, err := handlers.svc.GetUsers(context.Background())
+	users, total, err := handlers.svc.GetUsers(r.Context(), query)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Error getting users: %v", err)
-		logrus.WithFields(logrus.Fields{
-			"error": err,
-		}).Error("get users failed")
+		problem.Error(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(users)
+	err = json.NewEncoder(w).Encode(newListResponse(users, total, listQuery))
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"error": err,
@@ -100,32 +111,31 @@ func (handlers Handlers) getUsers(w http.ResponseWriter, r *http.Request) {
 // @Summary Возвращает информацию о юзере
 // @Description Возвращает информацию о юзере по указанному ID.
 // @Tags User
-// @Param id query string true "ID юзера"
+// @Security ApiKeyAuth
+// @Param id path string true "ID юзера"
+// @Param If-None-Match header string false "ETag, полученный ранее"
 // @Produce json
 // @Success 200 {object} entities.UserEntity "Информация о юзере"
-// @Failure 500 {string} string "Ошибка при получении юзере"
+// @Header 200 {string} ETag "Хеш ответа, меняется с любым его полем"
+// @Success 304 "Юзер не изменился"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Юзер не найден"
+// @Failure 500 {object} problem.Details "Ошибка при получении юзере"
 // @Router /user/{id} [get]
 func (handlers Handlers) getUser(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		fmt.Fprintf(w, "Method %s not allowed", r.Method)
-		return
-	}
-
-	id := r.URL.Query().Get("id")
+	id := r.PathValue("id")
 	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Missing id parameter")
+		problem.Error(w, r, invalidParam("id", "must not be empty"))
 		return
 	}
 
-	user, err := handlers.svc.GetUser(context.Background(), id)
+	user, err := handlers.svc.GetUser(r.Context(), id)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Error getting user: %v", err)
-		logrus.WithFields(logrus.Fields{
-			"error": err,
-		}).Error("get user failed")
+		problem.Error(w, r, err)
+		return
+	}
+	if notModified(w, r, user) {
 		return
 	}
 
@@ -139,51 +149,60 @@ func (handlers Handlers) getUser(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// userVersion returns the version of the user a write is conditional on,
+// see ifMatch.
+func (handlers Handlers) userVersion(r *http.Request, id string) (int, error) {
+	return ifMatch(r, func() (entities.UserEntity, int, error) {
+		user, err := handlers.svc.GetUser(r.Context(), id)
+		return user, user.Version, err
+	})
+}
+
 // updateUser обновляет информацию о юзере.
 // @Summary Обновляет информацию о юзере
 // @Description Обновляет информацию о юзере с указанным ID на основе переданных данных.
 // @Tags User
-// @Param id query string true "ID юзера"
+// @Security ApiKeyAuth
+// @Param id path string true "ID юзера"
+// @Param If-Match header string false "ETag, полученный ранее; без заголовка запрос безусловный"
 // @Accept json
 // @Produce json
 // @Param user body entities.UserEntity true "Данные юзера"
 // @Success 201 {object} map[string]string
-// @Failure 400 {string} string "Ошибка при декодировании JSON"
-// @Failure 500 {string} string "Ошибка при обновлении юзера"
+// @Failure 400 {object} problem.Details "Ошибка при декодировании JSON"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 422 {object} problem.Details "Ошибка валидации"
+// @Failure 404 {object} problem.Details "Юзер не найден"
+// @Failure 409 {object} problem.Details "Юзер уже существует"
+// @Failure 412 {object} problem.Details "Юзер изменился после получения ETag"
+// @Failure 500 {object} problem.Details "Ошибка при обновлении юзера"
 // @Router /user/{id} [put]
 func (handlers Handlers) updateUser(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		fmt.Fprintf(w, "Method %s not allowed", r.Method)
+	id := r.PathValue("id")
+	if id == "" {
+		problem.Error(w, r, invalidParam("id", "must not be empty"))
 		return
 	}
 
-	id := r.URL.Query().Get("id")
-	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Missing id parameter")
+	version, err := handlers.userVersion(r, id)
+	if err != nil {
+		problem.Error(w, r, err)
 		return
 	}
 
 	var user entities.UserEntity
 	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&user)
+	err = decoder.Decode(&user)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Error decoding request body: %v", err)
-		logrus.WithFields(logrus.Fields{
-			"error": err,
-		}).Error("decode request body failed")
+		problem.Error(w, r, errMalformedJSON)
 		return
 	}
+	user.Version = version
 
-	err = handlers.svc.UpdateUser(context.Background(), id, user)
+	err = handlers.svc.UpdateUser(r.Context(), id, user)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Error updating user: %v", err)
-		logrus.WithFields(logrus.Fields{
-			"error": err,
-		}).Error("update user failed")
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -192,35 +211,92 @@ func (handlers Handlers) updateUser(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// patchUser частично обновляет юзера.
+// @Summary Частично обновляет юзера
+// @Description Применяет JSON Merge Patch (RFC 7396) или JSON Patch (RFC 6902) к юзеру в том виде, в котором его возвращает GET /user/{id}. Результат проверяется так же, как при создании, и сохраняется целиком или не сохраняется вовсе. Пароль патчем не меняется.
+// @Tags User
+// @Security ApiKeyAuth
+// @Param id path string true "ID юзера"
+// @Param If-Match header string false "ETag, полученный ранее; без заголовка запрос безусловный"
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param patch body object true "Патч юзера"
+// @Success 200 {object} entities.UserEntity "Юзер после изменения"
+// @Header 200 {string} ETag "Хеш ответа, меняется с любым его полем"
+// @Failure 400 {object} problem.Details "Некорректный патч"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 422 {object} problem.Details "Ошибка валидации"
+// @Failure 404 {object} problem.Details "Юзер не найден"
+// @Failure 409 {object} problem.Details "Юзер уже существует или патч не применим"
+// @Failure 412 {object} problem.Details "Юзер изменился после получения ETag"
+// @Failure 415 {object} problem.Details "Неизвестный формат патча"
+// @Failure 500 {object} problem.Details "Ошибка при обновлении юзера"
+// @Router /user/{id} [patch]
+func (handlers Handlers) patchUser(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		problem.Error(w, r, invalidParam("id", "must not be empty"))
+		return
+	}
+
+	version, err := handlers.userVersion(r, id)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	p, err := readPatch(w, r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	user, err := handlers.svc.PatchUser(r.Context(), id, p, version)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	setETag(w, user)
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(user)
+	if err != nil {
+		return
+	}
+}
+
 // deleteUser удаляет юзера по его ID.
 // @Summary Удаляет юзера
 // @Description Удаляет юзера с указанным ID.
 // @Tags User
-// @Param id query string true "ID юзера"
+// @Security ApiKeyAuth
+// @Param id path string true "ID юзера"
+// @Param If-Match header string false "ETag, полученный ранее; без заголовка запрос безусловный"
 // @Success 200 {object} map[string]string
-// @Failure 500 {string} string "Ошибка при удалении юзера"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Юзер не найден"
+// @Failure 412 {object} problem.Details "Юзер изменился после получения ETag"
+// @Failure 500 {object} problem.Details "Ошибка при удалении юзера"
 // @Router /user/{id} [delete]
 func (handlers Handlers) deleteUser(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		fmt.Fprintf(w, "Method %s not allowed", r.Method)
+	id := r.PathValue("id")
+	if id == "" {
+		problem.Error(w, r, invalidParam("id", "must not be empty"))
 		return
 	}
 
-	id := r.URL.Query().Get("id")
-	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Missing id parameter")
+	version, err := handlers.userVersion(r, id)
+	if err != nil {
+		problem.Error(w, r, err)
 		return
 	}
 
-	err := handlers.svc.DeleteUser(context.Background(), id)
+	err = handlers.svc.DeleteUser(r.Context(), id, version)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Error deleting user: %v", err)
-		logrus.WithFields(logrus.Fields{
-			"error": err,
-		}).Error("delete user failed")
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -0,0 +1,432 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"filmography/internal/entities"
+	"filmography/internal/middleware"
+	"filmography/internal/problem"
+	"filmography/service"
+	"io"
+	"net/http"
+	"time"
+)
+
+type WatchService interface {
+	AddToWatchlist(ctx context.Context, userID string, filmID string, position int) (entities.WatchlistEntry, error)
+	GetWatchlist(ctx context.Context, query entities.WatchQuery) ([]entities.WatchlistEntry, int, error)
+	RemoveFromWatchlist(ctx context.Context, userID string, filmID string) error
+	ExportWatchlist(ctx context.Context, w io.Writer, userID string, format service.FileFormat) error
+	AddHistoryEntry(ctx context.Context, entry entities.HistoryEntry) (entities.HistoryEntry, error)
+	GetHistory(ctx context.Context, query entities.WatchQuery) ([]entities.HistoryEntry, int, error)
+	GetHistoryEntry(ctx context.Context, userID string, id string) (entities.HistoryEntry, error)
+	UpdateHistoryEntry(ctx context.Context, entry entities.HistoryEntry) (entities.HistoryEntry, error)
+	DeleteHistoryEntry(ctx context.Context, userID string, id string) error
+	ExportHistory(ctx context.Context, w io.Writer, userID string, format service.FileFormat) error
+}
+
+type WatchlistRequest struct {
+	Position int `json:"position"`
+}
+
+type HistoryRequest struct {
+	FilmID    string    `json:"film_id"`
+	WatchedAt time.Time `json:"watched_at"`
+	Rating    *float64  `json:"rating"`
+}
+
+// getWatchlist возвращает список фильмов к просмотру текущего юзера.
+// @Summary Возвращает список к просмотру
+// @Description Возвращает страницу списка фильмов к просмотру текущего юзера, по умолчанию в порядке юзера.
+// @Tags Watch
+// @Security ApiKeyAuth
+// @Param limit query int false "Размер страницы (1-100)" default(20)
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой"
+// @Param sort query string false "Поля сортировки: position, added_at, title, release_date; минус для убывания" default(position)
+// @Produce json
+// @Success 200 {object} ListResponse[entities.WatchlistEntry] "Страница списка"
+// @Failure 422 {object} problem.Details "Неверные параметры запроса"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 500 {object} problem.Details "Ошибка при получении списка"
+// @Router /me/watchlist [get]
+func (handlers Handlers) getWatchlist(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	listQuery, err := parseListQuery[entities.WatchlistEntry](r.URL.Query(), entities.WatchlistSortFields, entities.SortField{Field: "position"})
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	entries, total, err := handlers.svc.GetWatchlist(r.Context(), entities.WatchQuery{ListQuery: listQuery, UserID: claims.UserID})
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(newListResponse(entries, total, listQuery))
+	if err != nil {
+		return
+	}
+}
+
+// addToWatchlist добавляет фильм в список к просмотру.
+// @Summary Добавляет фильм в список к просмотру
+// @Description Добавляет фильм в список к просмотру текущего юзера или переставляет его, если он уже в списке. Без позиции новый фильм добавляется в конец, а фильм из списка остается на месте.
+// @Tags Watch
+// @Security ApiKeyAuth
+// @Param film_id path string true "ID фильма"
+// @Accept json
+// @Produce json
+// @Param entry body WatchlistRequest false "Позиция в списке, начиная с 1"
+// @Success 200 {object} entities.WatchlistEntry "Запись списка"
+// @Failure 400 {object} problem.Details "Ошибка при декодировании JSON"
+// @Failure 422 {object} problem.Details "Ошибка валидации"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Фильм не найден"
+// @Failure 500 {object} problem.Details "Ошибка при добавлении в список"
+// @Router /me/watchlist/{film_id} [put]
+func (handlers Handlers) addToWatchlist(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	request := WatchlistRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		problem.Error(w, r, errMalformedJSON)
+		return
+	}
+
+	entry, err := handlers.svc.AddToWatchlist(r.Context(), claims.UserID, r.PathValue("film_id"), request.Position)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(entry)
+	if err != nil {
+		return
+	}
+}
+
+// removeFromWatchlist убирает фильм из списка к просмотру.
+// @Summary Убирает фильм из списка к просмотру
+// @Description Убирает фильм из списка к просмотру текущего юзера.
+// @Tags Watch
+// @Security ApiKeyAuth
+// @Param film_id path string true "ID фильма"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Фильма нет в списке"
+// @Failure 500 {object} problem.Details "Ошибка при удалении из списка"
+// @Router /me/watchlist/{film_id} [delete]
+func (handlers Handlers) removeFromWatchlist(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	err := handlers.svc.RemoveFromWatchlist(r.Context(), claims.UserID, r.PathValue("film_id"))
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	response := map[string]string{
+		"message": "film is successfully removed from the watchlist",
+	}
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		return
+	}
+}
+
+// exportWatchlist выгружает список к просмотру.
+// @Summary Выгрузка списка к просмотру
+// @Description Выгружает список к просмотру текущего юзера в JSON, CSV или NDJSON в порядке юзера.
+// @Tags Watch
+// @Security ApiKeyAuth
+// @Produce json
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "Формат файла" Enums(json, csv, ndjson) default(json)
+// @Success 200 {array} service.WatchlistRecord "Список к просмотру"
+// @Failure 422 {object} problem.Details "Неверные параметры запроса"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 500 {object} problem.Details "Ошибка при выгрузке"
+// @Router /me/watchlist/export [get]
+func (handlers Handlers) exportWatchlist(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	format := service.FileFormat(r.URL.Query().Get("format"))
+	if err := service.ValidateFormat(&format); err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	handlers.streamExport(w, r, format, "watchlist", func(body io.Writer) error {
+		return handlers.svc.ExportWatchlist(r.Context(), body, claims.UserID, format)
+	})
+}
+
+// getHistory возвращает историю просмотров текущего юзера.
+// @Summary Возвращает историю просмотров
+// @Description Возвращает страницу просмотров текущего юзера, по умолчанию сначала последние.
+// @Tags Watch
+// @Security ApiKeyAuth
+// @Param film_id query string false "ID фильма"
+// @Param limit query int false "Размер страницы (1-100)" default(20)
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор next_cursor предыдущей страницы, действует только с той же сортировкой"
+// @Param sort query string false "Поля сортировки: watched_at, rating, title; минус для убывания" default(-watched_at)
+// @Produce json
+// @Success 200 {object} ListResponse[entities.HistoryEntry] "Страница просмотров"
+// @Failure 422 {object} problem.Details "Неверные параметры запроса"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 500 {object} problem.Details "Ошибка при получении истории"
+// @Router /me/history [get]
+func (handlers Handlers) getHistory(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	values := r.URL.Query()
+	listQuery, err := parseListQuery[entities.HistoryEntry](values, entities.HistorySortFields, entities.SortField{Field: "watched_at", Desc: true})
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+	query := entities.WatchQuery{ListQuery: listQuery, UserID: claims.UserID, FilmID: values.Get("film_id")}
+
+	entries, total, err := handlers.svc.GetHistory(r.Context(), query)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(newListResponse(entries, total, listQuery))
+	if err != nil {
+		return
+	}
+}
+
+// addHistoryEntry добавляет просмотр в историю.
+// @Summary Добавляет просмотр
+// @Description Отмечает фильм просмотренным текущим юзером в указанный день, по умолчанию сегодня, с необязательной личной оценкой. Просмотренный фильм убирается из списка к просмотру.
+// @Tags Watch
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param entry body HistoryRequest true "Просмотр"
+// @Success 201 {object} entities.HistoryEntry "Просмотр"
+// @Failure 400 {object} problem.Details "Ошибка при декодировании JSON"
+// @Failure 422 {object} problem.Details "Ошибка валидации"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Фильм не найден"
+// @Failure 500 {object} problem.Details "Ошибка при добавлении просмотра"
+// @Router /me/history [post]
+func (handlers Handlers) addHistoryEntry(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	request := HistoryRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		problem.Error(w, r, errMalformedJSON)
+		return
+	}
+
+	entry, err := handlers.svc.AddHistoryEntry(r.Context(), entities.HistoryEntry{
+		UserID:    claims.UserID,
+		FilmID:    request.FilmID,
+		WatchedAt: request.WatchedAt,
+		Rating:    request.Rating,
+	})
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(entry)
+	if err != nil {
+		return
+	}
+}
+
+// getHistoryEntry возвращает просмотр по его ID.
+// @Summary Возвращает просмотр
+// @Description Возвращает просмотр текущего юзера по указанному ID.
+// @Tags Watch
+// @Security ApiKeyAuth
+// @Param id path string true "ID просмотра"
+// @Produce json
+// @Success 200 {object} entities.HistoryEntry "Просмотр"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Просмотр не найден"
+// @Failure 500 {object} problem.Details "Ошибка при получении просмотра"
+// @Router /me/history/{id} [get]
+func (handlers Handlers) getHistoryEntry(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	entry, err := handlers.svc.GetHistoryEntry(r.Context(), claims.UserID, r.PathValue("id"))
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(entry)
+	if err != nil {
+		return
+	}
+}
+
+// updateHistoryEntry изменяет просмотр.
+// @Summary Изменяет просмотр
+// @Description Изменяет дату и личную оценку просмотра текущего юзера, фильм не меняется.
+// @Tags Watch
+// @Security ApiKeyAuth
+// @Param id path string true "ID просмотра"
+// @Accept json
+// @Produce json
+// @Param entry body HistoryRequest true "Дата и оценка просмотра"
+// @Success 200 {object} entities.HistoryEntry "Просмотр"
+// @Failure 400 {object} problem.Details "Ошибка при декодировании JSON"
+// @Failure 422 {object} problem.Details "Ошибка валидации"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Просмотр не найден"
+// @Failure 500 {object} problem.Details "Ошибка при изменении просмотра"
+// @Router /me/history/{id} [put]
+func (handlers Handlers) updateHistoryEntry(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	request := HistoryRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		problem.Error(w, r, errMalformedJSON)
+		return
+	}
+
+	entry, err := handlers.svc.UpdateHistoryEntry(r.Context(), entities.HistoryEntry{
+		ID:        r.PathValue("id"),
+		UserID:    claims.UserID,
+		WatchedAt: request.WatchedAt,
+		Rating:    request.Rating,
+	})
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(entry)
+	if err != nil {
+		return
+	}
+}
+
+// deleteHistoryEntry удаляет просмотр.
+// @Summary Удаляет просмотр
+// @Description Удаляет просмотр текущего юзера из истории.
+// @Tags Watch
+// @Security ApiKeyAuth
+// @Param id path string true "ID просмотра"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 404 {object} problem.Details "Просмотр не найден"
+// @Failure 500 {object} problem.Details "Ошибка при удалении просмотра"
+// @Router /me/history/{id} [delete]
+func (handlers Handlers) deleteHistoryEntry(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	err := handlers.svc.DeleteHistoryEntry(r.Context(), claims.UserID, r.PathValue("id"))
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	response := map[string]string{
+		"message": "history entry is successfully deleted",
+	}
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		return
+	}
+}
+
+// exportHistory выгружает историю просмотров.
+// @Summary Выгрузка истории просмотров
+// @Description Выгружает историю просмотров текущего юзера в JSON, CSV или NDJSON, сначала старые.
+// @Tags Watch
+// @Security ApiKeyAuth
+// @Produce json
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "Формат файла" Enums(json, csv, ndjson) default(json)
+// @Success 200 {array} service.HistoryRecord "История просмотров"
+// @Failure 422 {object} problem.Details "Неверные параметры запроса"
+// @Failure 401 {object} problem.Details "Требуется токен доступа"
+// @Failure 403 {object} problem.Details "Недостаточно прав"
+// @Failure 500 {object} problem.Details "Ошибка при выгрузке"
+// @Router /me/history/export [get]
+func (handlers Handlers) exportHistory(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		problem.Error(w, r, service.ErrTokenMissing)
+		return
+	}
+
+	format := service.FileFormat(r.URL.Query().Get("format"))
+	if err := service.ValidateFormat(&format); err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	handlers.streamExport(w, r, format, "history", func(body io.Writer) error {
+		return handlers.svc.ExportHistory(r.Context(), body, claims.UserID, format)
+	})
+}
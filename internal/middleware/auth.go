@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"filmography/internal/problem"
+	"filmography/service"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+type TokenVerifier interface {
+	Verify(token string) (entities.TokenClaims, error)
+	CheckToken(token string) bool
+}
+
+// Auth lets the request through only if it carries a valid access token
+// issued for one of the given roles. The claims of the token are put into
+// the request context.
+func Auth(verifier TokenVerifier, roles ...entities.Role) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := strings.Split(r.Header.Get("Authorization"), " ")
+			if len(token) < 2 {
+				problem.Error(w, r, service.ErrTokenMissing)
+				return
+			}
+			accessToken := token[1]
+
+			claims, err := verifier.Verify(accessToken)
+			if err != nil {
+				problem.Error(w, r, err)
+				return
+			}
+
+			if claims.Type != service.AccessToken {
+				problem.Error(w, r, service.ErrWrongTokenType)
+				return
+			}
+
+			if !verifier.CheckToken(accessToken) {
+				problem.Error(w, r, service.ErrLoggedOut)
+				return
+			}
+
+			if !slices.Contains(roles, claims.Role) {
+				problem.Error(w, r, service.ErrForbidden)
+				logrus.WithFields(logrus.Fields{
+					"user":       claims.UserID,
+					"role":       claims.Role,
+					"path":       r.URL.Path,
+					"request_id": RequestIDFromContext(r.Context()),
+				}).Warn("access denied")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), claimsKey, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"encoding/json"
+	"filmography/internal/entities"
+	"filmography/internal/problem"
+	"filmography/service"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeVerifier struct {
+	claims    map[string]entities.TokenClaims
+	loggedOut map[string]bool
+}
+
+func (v fakeVerifier) Verify(token string) (entities.TokenClaims, error) {
+	if token == "expired" {
+		return entities.TokenClaims{}, service.ErrTokenExpired
+	}
+	claims, ok := v.claims[token]
+	if !ok {
+		return entities.TokenClaims{}, service.WrapError(service.CodeTokenInvalid, "wrong token", fmt.Errorf("token parse failed: malformed"))
+	}
+	return claims, nil
+}
+
+func (v fakeVerifier) CheckToken(token string) bool {
+	return !v.loggedOut[token]
+}
+
+func TestAuth(t *testing.T) {
+	verifier := fakeVerifier{
+		claims: map[string]entities.TokenClaims{
+			"admin":   {UserID: "1", Role: entities.Admin, Type: service.AccessToken},
+			"user":    {UserID: "2", Role: entities.User, Type: service.AccessToken},
+			"refresh": {UserID: "1", Role: entities.Admin, Type: service.RefreshToken},
+			"gone":    {UserID: "1", Role: entities.Admin, Type: service.AccessToken},
+		},
+		loggedOut: map[string]bool{"gone": true},
+	}
+
+	tests := []struct {
+		name       string
+		header     string
+		roles      []entities.Role
+		wantStatus int
+		wantCode   service.ErrorCode
+		wantCalled bool
+	}{
+		{"no token", "", []entities.Role{entities.Admin}, http.StatusUnauthorized, service.CodeTokenMissing, false},
+		{"malformed header", "Bearer", []entities.Role{entities.Admin}, http.StatusUnauthorized, service.CodeTokenMissing, false},
+		{"expired token", "Bearer expired", []entities.Role{entities.Admin}, http.StatusUnauthorized, service.CodeTokenExpired, false},
+		{"invalid token", "Bearer garbage", []entities.Role{entities.Admin}, http.StatusForbidden, service.CodeTokenInvalid, false},
+		{"refresh token", "Bearer refresh", []entities.Role{entities.Admin}, http.StatusForbidden, service.CodeTokenInvalid, false},
+		{"logged out", "Bearer gone", []entities.Role{entities.Admin}, http.StatusForbidden, service.CodeTokenInvalid, false},
+		{"wrong role", "Bearer user", []entities.Role{entities.Admin}, http.StatusForbidden, service.CodeForbidden, false},
+		{"admin", "Bearer admin", []entities.Role{entities.Admin}, http.StatusOK, "", true},
+		{"user on read route", "Bearer user", []entities.Role{entities.User, entities.Admin}, http.StatusOK, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				claims, ok := ClaimsFromContext(r.Context())
+				if !ok || claims.Type != service.AccessToken {
+					t.Errorf("claims not in context: %+v", claims)
+				}
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/film", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			Auth(verifier, tt.roles...)(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if called != tt.wantCalled {
+				t.Errorf("handler called = %v, want %v", called, tt.wantCalled)
+			}
+			if tt.wantCode != "" {
+				details := problem.Details{}
+				if err := json.NewDecoder(rec.Body).Decode(&details); err != nil {
+					t.Fatalf("decode problem: %v", err)
+				}
+				if details.Code != tt.wantCode || rec.Header().Get("Content-Type") != problem.ContentType {
+					t.Errorf("problem code = %q (%s), want %q", details.Code, rec.Header().Get("Content-Type"), tt.wantCode)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// Deprecated marks the responses of a route kept for old clients with the
+// Deprecation header and links the route that replaces it. Every call is
+// logged, so that the route can be dropped once nobody uses it.
+func Deprecated(successor string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+
+			logrus.WithFields(logrus.Fields{
+				"method":     r.Method,
+				"path":       r.URL.Path,
+				"successor":  successor,
+				"request_id": RequestIDFromContext(r.Context()),
+			}).Warn("deprecated route called")
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
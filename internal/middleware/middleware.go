@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"net/http"
+)
+
+// Middleware wraps a handler with additional behaviour.
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h with the middlewares so that the first one is the
+// outermost and sees the request first.
+func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+type contextKey int
+
+const (
+	claimsKey contextKey = iota
+	requestIDKey
+)
+
+// ClaimsFromContext returns the claims of the token the request was
+// authorized with by Auth.
+func ClaimsFromContext(ctx context.Context) (entities.TokenClaims, bool) {
+	claims, ok := ctx.Value(claimsKey).(entities.TokenClaims)
+	return claims, ok
+}
+
+// RequestIDFromContext returns the ID assigned to the request by RequestID.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"filmography/internal/problem"
+	"filmography/service"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recovery turns a panic in the handler into a 500 response.
+func Recovery() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if err := recover(); err != nil {
+					if err == http.ErrAbortHandler {
+						panic(err)
+					}
+
+					logrus.WithFields(logrus.Fields{
+						"error":      err,
+						"stack":      string(debug.Stack()),
+						"request_id": RequestIDFromContext(r.Context()),
+					}).Error("handler panicked")
+					problem.Write(w, r, problem.Details{
+						Status: http.StatusInternalServerError,
+						Code:   service.CodeInternal,
+						Detail: "internal server error",
+					})
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
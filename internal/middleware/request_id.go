@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID takes the request ID from the X-Request-ID header or generates
+// a new one, stores it in the context and echoes it in the response.
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" || len(id) > 128 {
+				id = uuid.NewString()
+			}
+
+			w.Header().Set(RequestIDHeader, id)
+			ctx := context.WithValue(r.Context(), requestIDKey, id)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
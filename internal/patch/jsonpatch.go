@@ -0,0 +1,335 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Operation is a single step of a JSON Patch.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+
+	value any
+}
+
+// JSONPatch is a JSON Patch, a list of operations applied in order.
+type JSONPatch []Operation
+
+func parseJSONPatch(body []byte) (JSONPatch, error) {
+	var ops JSONPatch
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	for i := range ops {
+		if err := ops[i].check(); err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrMalformed, i, err)
+		}
+	}
+	return ops, nil
+}
+
+// check validates the members of the operation and decodes its value.
+func (op *Operation) check() error {
+	if op.Path == nil {
+		return fmt.Errorf("path is missing")
+	}
+	if _, err := parsePointer(*op.Path); err != nil {
+		return err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		// An explicit null is a value, only a missing member is not.
+		if op.Value == nil {
+			return fmt.Errorf("value is missing")
+		}
+		value, err := decode(op.Value)
+		if err != nil {
+			return fmt.Errorf("value: %v", err)
+		}
+		op.value = value
+	case "move", "copy":
+		if op.From == nil {
+			return fmt.Errorf("from is missing")
+		}
+		if _, err := parsePointer(*op.From); err != nil {
+			return err
+		}
+		if op.Op == "move" && strings.HasPrefix(*op.Path, *op.From+"/") {
+			return fmt.Errorf("cannot move %q into itself", *op.From)
+		}
+	case "remove":
+	default:
+		return fmt.Errorf("unknown op %q", op.Op)
+	}
+	return nil
+}
+
+func (p JSONPatch) Apply(doc []byte) ([]byte, error) {
+	root, err := decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("decode document failed: %w", err)
+	}
+
+	for i, op := range p {
+		root, err = op.apply(root)
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrConflict, i, err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+// apply returns the document with the operation done. It changes root in
+// place, Apply decodes a fresh copy for every patch.
+func (op Operation) apply(root any) (any, error) {
+	path, _ := parsePointer(*op.Path)
+
+	switch op.Op {
+	case "add":
+		return add(root, path, clone(op.value))
+	case "remove":
+		root, _, err := remove(root, path)
+		return root, err
+	case "replace":
+		if len(path) == 0 {
+			return clone(op.value), nil
+		}
+		root, _, err := remove(root, path)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, clone(op.value))
+	case "move":
+		from, _ := parsePointer(*op.From)
+		root, value, err := remove(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+	case "copy":
+		from, _ := parsePointer(*op.From)
+		value, err := get(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, clone(value))
+	case "test":
+		value, err := get(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(value, op.value) {
+			return nil, fmt.Errorf("test of %q failed", *op.Path)
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path %q does not start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// index parses an array index token, it may be at most max.
+func index(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') || token[0] == '+' {
+		return 0, fmt.Errorf("%q is not an array index", token)
+	}
+	if i > max {
+		return 0, fmt.Errorf("index %d is out of range", i)
+	}
+	return i, nil
+}
+
+func get(node any, path []string) (any, error) {
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+			node = child
+		case []any:
+			i, err := index(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("%q not found in a scalar", token)
+		}
+	}
+	return node, nil
+}
+
+// add returns node with value added at path. The parent of the target has
+// to exist, an array element is inserted and "-" appends it.
+func add(node any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token, last := path[0], len(path) == 1
+	switch n := node.(type) {
+	case map[string]any:
+		if last {
+			n[token] = value
+			return n, nil
+		}
+		child, ok := n[token]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", token)
+		}
+		child, err := add(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[token] = child
+		return n, nil
+	case []any:
+		if last {
+			i := len(n)
+			if token != "-" {
+				var err error
+				if i, err = index(token, len(n)); err != nil {
+					return nil, err
+				}
+			}
+			return slices.Insert(n, i, value), nil
+		}
+		i, err := index(token, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		child, err := add(n[i], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = child
+		return n, nil
+	}
+	return nil, fmt.Errorf("%q not found in a scalar", token)
+}
+
+// remove returns node without the value at path, and that value.
+func remove(node any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("cannot remove the whole document")
+	}
+
+	token, last := path[0], len(path) == 1
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("member %q not found", token)
+		}
+		if last {
+			delete(n, token)
+			return n, child, nil
+		}
+		child, removed, err := remove(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		n[token] = child
+		return n, removed, nil
+	case []any:
+		i, err := index(token, len(n)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if last {
+			removed := n[i]
+			return slices.Delete(n, i, i+1), removed, nil
+		}
+		child, removed, err := remove(n[i], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		n[i] = child
+		return n, removed, nil
+	}
+	return nil, nil, fmt.Errorf("%q not found in a scalar", token)
+}
+
+// clone copies a decoded value, so that operations never share one.
+func clone(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(v))
+		for name, member := range v {
+			c[name] = clone(member)
+		}
+		return c
+	case []any:
+		c := make([]any, len(v))
+		for i, element := range v {
+			c[i] = clone(element)
+		}
+		return c
+	}
+	return value
+}
+
+// equal compares decoded values the way the test operation does: numbers
+// by value, objects regardless of the order of their members.
+func equal(a, b any) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fx, okx := new(big.Float).SetString(x.String())
+		fy, oky := new(big.Float).SetString(y.String())
+		return okx && oky && fx.Cmp(fy) == 0
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for name, member := range x {
+			other, ok := y[name]
+			if !ok || !equal(member, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
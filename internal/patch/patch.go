@@ -0,0 +1,99 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to JSON documents.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Media types of the supported patch formats.
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	// ErrUnsupported means the media type is not a known patch format.
+	ErrUnsupported = errors.New("unsupported patch format")
+	// ErrMalformed means the patch is not a valid document of its format.
+	ErrMalformed = errors.New("malformed patch")
+	// ErrConflict means the patch does not fit the document: a path is
+	// missing or a test operation failed.
+	ErrConflict = errors.New("patch does not apply")
+)
+
+// Patch changes a JSON document. Apply leaves doc untouched and either
+// applies the whole patch or fails.
+type Patch interface {
+	Apply(doc []byte) ([]byte, error)
+}
+
+// Parse reads a patch of the given media type.
+func Parse(mediaType string, body []byte) (Patch, error) {
+	switch mediaType {
+	case MergePatchType:
+		return parseMergePatch(body)
+	case JSONPatchType:
+		return parseJSONPatch(body)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupported, mediaType)
+}
+
+// decode reads a JSON value keeping numbers as they are written.
+func decode(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("trailing data after the JSON value")
+	}
+	return v, nil
+}
+
+// MergePatch is a JSON Merge Patch: objects are merged member by member,
+// null removes a member and any other value replaces the target.
+type MergePatch struct {
+	patch any
+}
+
+func parseMergePatch(body []byte) (MergePatch, error) {
+	v, err := decode(body)
+	if err != nil {
+		return MergePatch{}, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return MergePatch{patch: v}, nil
+}
+
+func (p MergePatch) Apply(doc []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("decode document failed: %w", err)
+	}
+	return json.Marshal(merge(target, p.patch))
+}
+
+func merge(target, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = make(map[string]any, len(members))
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(object, name)
+			continue
+		}
+		object[name] = merge(object[name], value)
+	}
+	return object
+}
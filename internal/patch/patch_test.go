@@ -0,0 +1,145 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// assertJSON compares JSON documents regardless of the member order.
+func assertJSON(t *testing.T, name string, got []byte, want string) {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("%s: result %s is not JSON: %v", name, got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("%s: want %s is not JSON: %v", name, want, err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("%s = %s, want %s", name, got, want)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	// The examples of RFC 7396, appendix A.
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		p, err := Parse(MergePatchType, []byte(tt.patch))
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v", tt.patch, err)
+		}
+		got, err := p.Apply([]byte(tt.doc))
+		if err != nil {
+			t.Fatalf("Apply(%s, %s) error = %v", tt.doc, tt.patch, err)
+		}
+		assertJSON(t, "Apply("+tt.doc+", "+tt.patch+")", got, tt.want)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	// Mostly the examples of RFC 6902, appendix A.
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"add member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"add element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"append element", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc"]}]`, `{"foo":["bar",["abc"]]}`},
+		{"remove member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"move member", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"copy", `{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`, `{"foo":{"bar":1},"baz":{"bar":2}}`},
+		{"test", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{"escaped path", `{"a/b":{"m~n":1}}`, `[{"op":"replace","path":"/a~1b/m~0n","value":null}]`, `{"a/b":{"m~n":null}}`},
+		{"whole document", `{"foo":"bar"}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+	}
+
+	for _, tt := range tests {
+		p, err := Parse(JSONPatchType, []byte(tt.patch))
+		if err != nil {
+			t.Fatalf("%s: Parse() error = %v", tt.name, err)
+		}
+		got, err := p.Apply([]byte(tt.doc))
+		if err != nil {
+			t.Fatalf("%s: Apply() error = %v", tt.name, err)
+		}
+		assertJSON(t, tt.name, got, tt.want)
+	}
+}
+
+func TestJSONPatchConflict(t *testing.T) {
+	tests := []struct {
+		name, doc, patch string
+	}{
+		{"failed test", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`},
+		{"missing parent", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`},
+		{"remove missing", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`},
+		{"index out of range", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":1}]`},
+		{"leading zero", `{"foo":["bar","baz"]}`, `[{"op":"remove","path":"/foo/01"}]`},
+		{"last op fails", `{"foo":"bar"}`, `[{"op":"replace","path":"/foo","value":1},{"op":"test","path":"/foo","value":2}]`},
+	}
+
+	for _, tt := range tests {
+		p, err := Parse(JSONPatchType, []byte(tt.patch))
+		if err != nil {
+			t.Fatalf("%s: Parse() error = %v", tt.name, err)
+		}
+		doc := []byte(tt.doc)
+		_, err = p.Apply(doc)
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("%s: Apply() error = %v, want %v", tt.name, err, ErrConflict)
+		}
+		if string(doc) != tt.doc {
+			t.Errorf("%s: Apply() changed the document to %s", tt.name, doc)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name, mediaType, body string
+		want                  error
+	}{
+		{"unknown media type", "application/json", `{}`, ErrUnsupported},
+		{"merge patch not JSON", MergePatchType, `{"a":`, ErrMalformed},
+		{"merge patch trailing data", MergePatchType, `{} {}`, ErrMalformed},
+		{"JSON patch not a list", JSONPatchType, `{"op":"add"}`, ErrMalformed},
+		{"unknown op", JSONPatchType, `[{"op":"merge","path":"/a"}]`, ErrMalformed},
+		{"missing path", JSONPatchType, `[{"op":"remove"}]`, ErrMalformed},
+		{"relative path", JSONPatchType, `[{"op":"remove","path":"a"}]`, ErrMalformed},
+		{"missing value", JSONPatchType, `[{"op":"add","path":"/a"}]`, ErrMalformed},
+		{"missing from", JSONPatchType, `[{"op":"copy","path":"/a"}]`, ErrMalformed},
+		{"move into itself", JSONPatchType, `[{"op":"move","from":"/a","path":"/a/b"}]`, ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.mediaType, []byte(tt.body))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: Parse() error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"filmography/service"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+const ContentType = "application/problem+json"
+
+// Details is an RFC 7807 problem details object extended with the stable
+// error code and the rejected fields.
+type Details struct {
+	Type     string               `json:"type"`
+	Title    string               `json:"title"`
+	Status   int                  `json:"status"`
+	Detail   string               `json:"detail,omitempty"`
+	Instance string               `json:"instance,omitempty"`
+	Code     service.ErrorCode    `json:"code"`
+	Errors   []service.FieldError `json:"errors,omitempty"`
+}
+
+var statuses = map[service.ErrorCode]int{
+	service.CodeMalformedRequest:   http.StatusBadRequest,
+	service.CodeValidationFailed:   http.StatusUnprocessableEntity,
+	service.CodeFilmNotFound:       http.StatusNotFound,
+	service.CodeActorNotFound:      http.StatusNotFound,
+	service.CodeUserNotFound:       http.StatusNotFound,
+	service.CodeRatingNotFound:     http.StatusNotFound,
+	service.CodeReviewNotFound:     http.StatusNotFound,
+	service.CodeNotOnWatchlist:     http.StatusNotFound,
+	service.CodeHistoryNotFound:    http.StatusNotFound,
+	service.CodeGenreNotFound:      http.StatusNotFound,
+	service.CodeUnknownActor:       http.StatusUnprocessableEntity,
+	service.CodeUnknownGenre:       http.StatusUnprocessableEntity,
+	service.CodeConflict:           http.StatusConflict,
+	service.CodeInvalidReference:   http.StatusUnprocessableEntity,
+	service.CodeUserExists:         http.StatusConflict,
+	service.CodeReviewExists:       http.StatusConflict,
+	service.CodeGenreExists:        http.StatusConflict,
+	service.CodeVersionMismatch:    http.StatusPreconditionFailed,
+	service.CodePatchConflict:      http.StatusConflict,
+	service.CodeUnsupportedMedia:   http.StatusUnsupportedMediaType,
+	service.CodeInvalidCredentials: http.StatusUnauthorized,
+	service.CodeTokenMissing:       http.StatusUnauthorized,
+	service.CodeTokenExpired:       http.StatusUnauthorized,
+	service.CodeTokenInvalid:       http.StatusForbidden,
+	service.CodeTokenRevoked:       http.StatusUnauthorized,
+	service.CodeTokenReused:        http.StatusUnauthorized,
+	service.CodeForbidden:          http.StatusForbidden,
+	service.CodeNotFound:           http.StatusNotFound,
+	service.CodeMethodNotAllowed:   http.StatusMethodNotAllowed,
+	service.CodeTimeout:            http.StatusServiceUnavailable,
+	service.CodeInternal:           http.StatusInternalServerError,
+}
+
+// Status returns the HTTP status the code is reported with.
+func Status(code service.ErrorCode) int {
+	if status, ok := statuses[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error writes err as a problem. A request that ran out of its deadline is
+// reported as timeout, other errors that are not a *service.Error are
+// logged and reported as internal_error without their message.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	var svcErr *service.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		svcErr = service.WrapError(service.ErrTimeout.Code, service.ErrTimeout.Message, err)
+	case !errors.As(err, &svcErr):
+		svcErr = service.WrapError(service.CodeInternal, "internal server error", err)
+	}
+
+	status := Status(svcErr.Code)
+	if status >= http.StatusInternalServerError {
+		logrus.WithFields(logrus.Fields{
+			"error":  err,
+			"method": r.Method,
+			"path":   r.URL.Path,
+		}).Error("request failed")
+	}
+
+	Write(w, r, Details{
+		Status: status,
+		Code:   svcErr.Code,
+		Detail: svcErr.Message,
+		Errors: svcErr.Fields,
+	})
+}
+
+// Write fills in the defaults of details and writes it as the response.
+func Write(w http.ResponseWriter, r *http.Request, details Details) {
+	if details.Type == "" {
+		details.Type = "/problems/" + string(details.Code)
+	}
+	if details.Title == "" {
+		details.Title = http.StatusText(details.Status)
+	}
+	if details.Instance == "" {
+		details.Instance = r.URL.Path
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(details.Status)
+	_ = json.NewEncoder(w).Encode(details)
+}
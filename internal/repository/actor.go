@@ -4,30 +4,46 @@ import (
 	"context"
 	"filmography/internal/entities"
 	"fmt"
+	"slices"
 	"time"
-
-	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
 func (r Repo) CreateActor(ctx context.Context, actor entities.ActorEntity) error {
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	row := r.db.QueryRowContext(queryCtx, "INSERT INTO actors (id, name, gender, birthday) VALUES($1, $2, $3, $4)", actor.ID, actor.Name, actor.Gender, actor.Birthday)
-	if row.Err() != nil {
-		return fmt.Errorf("query row context order failed: %w", row.Err())
+	_, err := r.db.ExecContext(queryCtx, "INSERT INTO people (id, name, gender, birthday) VALUES($1, $2, $3, $4)", actor.ID, actor.Name, actor.Gender, actor.Birthday)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
 	}
 
 	return nil
 }
 
-func (r Repo) GetActors(ctx context.Context) ([]entities.ActorEntity, error) {
+func (r Repo) GetActors(ctx context.Context, query entities.ActorQuery) ([]entities.ActorEntity, int, error) {
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	rows, err := r.db.QueryContext(queryCtx, "SELECT * FROM actors")
+	b := listBuilder{}
+	if query.Gender != "" {
+		b.add("gender = ?", query.Gender)
+	}
+	if query.BornBefore != nil {
+		b.add("birthday < ?", *query.BornBefore)
+	}
+
+	var total int
+	err := r.db.QueryRowContext(queryCtx, "SELECT COUNT(*) FROM people"+b.whereClause(), b.args...).Scan(&total)
 	if err != nil {
-		return nil, fmt.Errorf("query context failed: %w", err)
+		return nil, 0, fmt.Errorf("count failed: %w", err)
+	}
+
+	b.seek("", query.ListQuery, entities.ActorSortFields)
+	page := "SELECT id, name, gender, birthday, version FROM people" + b.whereClause() +
+		orderClause("", query.Sort, entities.ActorSortFields) + b.pageClause(query.ListQuery)
+	rows, err := r.db.QueryContext(queryCtx, page, b.args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query context failed: %w", err)
 	}
 	defer rows.Close()
 
@@ -35,30 +51,33 @@ func (r Repo) GetActors(ctx context.Context) ([]entities.ActorEntity, error) {
 
 	for rows.Next() {
 		actor := entities.ActorEntity{}
-		err := rows.Scan(&actor.ID, &actor.Name, &actor.Gender, &actor.Birthday)
+		err := rows.Scan(&actor.ID, &actor.Name, &actor.Gender, &actor.Birthday, &actor.Version)
 		if err != nil {
-			return nil, fmt.Errorf("scan failed: %w", err)
+			return nil, 0, fmt.Errorf("scan failed: %w", err)
 		}
 
 		actors = append(actors, actor)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows failed: %w", err)
+	}
 
-	return actors, nil
+	return actors, total, nil
 }
 
 func (r Repo) GetActor(ctx context.Context, id string) (entities.ActorEntity, error) {
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	row := r.db.QueryRowContext(queryCtx, "SELECT * FROM actors WHERE id = $1", id)
+	row := r.db.QueryRowContext(queryCtx, "SELECT id, name, gender, birthday, version FROM people WHERE id = $1", id)
 	if row.Err() != nil {
-		return entities.ActorEntity{}, fmt.Errorf("query context failed: %w", row.Err())
+		return entities.ActorEntity{}, fmt.Errorf("query context failed: %w", r.dbError(row.Err()))
 	}
 
 	actor := entities.ActorEntity{}
-	err := row.Scan(&actor.ID, &actor.Name, &actor.Gender, &actor.Birthday)
+	err := row.Scan(&actor.ID, &actor.Name, &actor.Gender, &actor.Birthday, &actor.Version)
 	if err != nil {
-		return entities.ActorEntity{}, fmt.Errorf("scan failed: %w", err)
+		return entities.ActorEntity{}, fmt.Errorf("scan failed: %w", r.dbError(err))
 	}
 
 	return actor, nil
@@ -68,34 +87,74 @@ func (r Repo) GetFilmsByActor(ctx context.Context, actorID string) ([]entities.F
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	rows, err := r.db.QueryContext(queryCtx, "SELECT films.* FROM films INNER JOIN actors_films ON films.id = actors_films.film_id WHERE actors_films.actor_id = $1", actorID)
+	err := r.exists(queryCtx, "people", actorID)
+	if err != nil {
+		return nil, fmt.Errorf("actor %q: %w", actorID, err)
+	}
+
+	rows, err := r.db.QueryContext(queryCtx, filmsWithActors("films")+" WHERE f.id IN (SELECT film_id FROM credits WHERE person_id = $1 AND role = 'actor') ORDER BY f.release_date, f.id, "+castOrder, actorID)
 	if err != nil {
 		return nil, fmt.Errorf("query context failed: %w", err)
 	}
 	defer rows.Close()
 
-	films := make([]entities.FilmEntity, 0)
+	films, err := scanFilmsWithActors(rows)
+	if err != nil {
+		return nil, fmt.Errorf("scan films with actors failed: %w", err)
+	}
+
+	err = r.loadRelations(queryCtx, films)
+	if err != nil {
+		return nil, fmt.Errorf("load relations failed: %w", err)
+	}
+
+	return films, nil
+}
+
+// GetCreditsByPerson returns every credit of the person in any role,
+// ordered by entities.ComparePersonCredits.
+func (r Repo) GetCreditsByPerson(ctx context.Context, personID string) ([]entities.PersonCredit, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := r.exists(queryCtx, "people", personID)
+	if err != nil {
+		return nil, fmt.Errorf("person %q: %w", personID, err)
+	}
+
+	rows, err := r.db.QueryContext(queryCtx, "SELECT f.id, f.title, f.release_date, c.role, c.character_name, c.billing FROM credits c INNER JOIN films f ON f.id = c.film_id WHERE c.person_id = $1", personID)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	credits := make([]entities.PersonCredit, 0)
 
 	for rows.Next() {
-		film := entities.FilmEntity{}
-		err := rows.Scan(&film.ID, &film.Title, &film.ReleaseDate)
+		credit := entities.PersonCredit{}
+		err := rows.Scan(&credit.FilmID, &credit.Title, &credit.ReleaseDate, &credit.Role, &credit.Character, &credit.Billing)
 		if err != nil {
 			return nil, fmt.Errorf("scan failed: %w", err)
 		}
 
-		films = append(films, film)
+		credits = append(credits, credit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
 	}
 
-	return films, nil
+	slices.SortFunc(credits, entities.ComparePersonCredits)
+	return credits, nil
 }
 
 func (r Repo) UpdateActor(ctx context.Context, id string, actor entities.ActorEntity) error {
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	res, err := r.db.ExecContext(queryCtx, "UPDATE actors SET name = $1, gender = $2, birthday = $3 WHERE id = $4", actor.Name, actor.Gender, actor.Birthday, id)
+	res, err := r.db.ExecContext(queryCtx, "UPDATE people SET name = $1, gender = $2, birthday = $3, version = version + 1 WHERE id = $4 AND ($5 = 0 OR version = $5)",
+		actor.Name, actor.Gender, actor.Birthday, id, actor.Version)
 	if err != nil {
-		return fmt.Errorf("exec context failed: %w", err)
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
 	}
 
 	num, err := res.RowsAffected()
@@ -103,18 +162,18 @@ func (r Repo) UpdateActor(ctx context.Context, id string, actor entities.ActorEn
 		return fmt.Errorf("rows affected failed: %w", err)
 	}
 	if num == 0 {
-		return fmt.Errorf("actor does not exists")
+		return fmt.Errorf("actor %q: %w", id, versionError(queryCtx, r.db, "people", id))
 	}
 	return nil
 }
 
-func (r Repo) DeleteActor(ctx context.Context, id string) error {
+func (r Repo) DeleteActor(ctx context.Context, id string, version int) error {
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	res, err := r.db.ExecContext(queryCtx, "DELETE FROM actors WHERE id = $1", id)
+	res, err := r.db.ExecContext(queryCtx, "DELETE FROM people WHERE id = $1 AND ($2 = 0 OR version = $2)", id, version)
 	if err != nil {
-		return fmt.Errorf("exec context failed: %w", err)
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
 	}
 
 	num, err := res.RowsAffected()
@@ -122,7 +181,7 @@ func (r Repo) DeleteActor(ctx context.Context, id string) error {
 		return fmt.Errorf("rows affected failed: %w", err)
 	}
 	if num == 0 {
-		return fmt.Errorf("actor does not exists")
+		return fmt.Errorf("actor %q: %w", id, versionError(queryCtx, r.db, "people", id))
 	}
 	return nil
 }
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Dialect adapts the queries of Repo to a database. The queries are written
+// for Postgres, with $N placeholders, and only the few places that differ
+// between the databases go through the dialect.
+type Dialect interface {
+	// Rebind rewrites the $N placeholders of query.
+	Rebind(query string) string
+	// RowLock is the clause that locks the selected rows for the rest of a
+	// transaction, empty when transactions are serialized anyway.
+	RowLock() string
+	// Date casts the SQL expression expr to a date.
+	Date(expr string) string
+	// Error translates a driver error into the entities sentinels, keeping
+	// the original error in the chain. Unknown errors are returned as is.
+	Error(err error) error
+}
+
+// sqlDB runs queries on the database, rewritten for its dialect.
+type sqlDB struct {
+	*sql.DB
+	dialect Dialect
+}
+
+func (db sqlDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return db.DB.ExecContext(ctx, db.dialect.Rebind(query), args...)
+}
+
+func (db sqlDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return db.DB.QueryContext(ctx, db.dialect.Rebind(query), args...)
+}
+
+func (db sqlDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return db.DB.QueryRowContext(ctx, db.dialect.Rebind(query), args...)
+}
+
+func (db sqlDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (sqlTx, error) {
+	tx, err := db.DB.BeginTx(ctx, opts)
+	return sqlTx{Tx: tx, dialect: db.dialect}, err
+}
+
+func (db sqlDB) dbError(err error) error {
+	return dbError(db.dialect, err)
+}
+
+// sqlTx runs queries in a transaction, rewritten for its dialect.
+type sqlTx struct {
+	*sql.Tx
+	dialect Dialect
+}
+
+func (tx sqlTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return tx.Tx.ExecContext(ctx, tx.dialect.Rebind(query), args...)
+}
+
+func (tx sqlTx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return tx.Tx.QueryContext(ctx, tx.dialect.Rebind(query), args...)
+}
+
+func (tx sqlTx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return tx.Tx.QueryRowContext(ctx, tx.dialect.Rebind(query), args...)
+}
+
+func (tx sqlTx) dbError(err error) error {
+	return dbError(tx.dialect, err)
+}
+
+// lockRow locks the row of table with the id until the transaction ends,
+// if the dialect needs that.
+func (tx sqlTx) lockRow(ctx context.Context, table string, id string) error {
+	lock := tx.dialect.RowLock()
+	if lock == "" {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, "SELECT 1 FROM "+table+" WHERE id = $1 "+lock, id)
+	if err != nil {
+		return fmt.Errorf("lock %s failed: %w", table, tx.dbError(err))
+	}
+	return nil
+}
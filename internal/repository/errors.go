@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"filmography/internal/entities"
+	"fmt"
+)
+
+// dbError translates driver errors into the entities sentinels, the
+// original error stays in the chain for logging.
+func dbError(dialect Dialect, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", entities.ErrNotFound, err)
+	}
+	return dialect.Error(err)
+}
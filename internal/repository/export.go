@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"fmt"
+	"strconv"
+)
+
+// exportPageSize is the number of films or actors an export reads at a
+// time.
+const exportPageSize = 100
+
+// ExportFilms calls fn for every film with its cast, crew, genres and
+// tags, ordered by ID. The films are read a page at a time and fn runs
+// between the queries, so the export holds no connection while it writes
+// and is bounded by ctx alone instead of the usual five seconds.
+func (r Repo) ExportFilms(ctx context.Context, fn func(film entities.CreditedFilm) error) error {
+	after := ""
+	for {
+		films, err := r.exportPage(ctx, after)
+		if err != nil {
+			return err
+		}
+
+		for _, film := range films {
+			if err := fn(film); err != nil {
+				return err
+			}
+		}
+		if len(films) < exportPageSize {
+			return nil
+		}
+		after = films[len(films)-1].ID
+	}
+}
+
+// exportPage reads the films following the one with ID after, from the
+// first one if after is empty.
+func (r Repo) exportPage(ctx context.Context, after string) ([]entities.CreditedFilm, error) {
+	b := listBuilder{}
+	if after != "" {
+		b.add("id > ?", after)
+	}
+	page := "SELECT id, title, description, release_date, rating, rating_sum, rating_count, version FROM films" + b.whereClause() +
+		" ORDER BY id LIMIT " + strconv.Itoa(exportPageSize)
+	rows, err := r.db.QueryContext(ctx, filmsWithActors("("+page+")")+" ORDER BY f.id, "+castOrder, b.args...)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	films, err := scanFilmsWithActors(rows)
+	if err != nil {
+		return nil, fmt.Errorf("scan films with actors failed: %w", err)
+	}
+
+	err = r.loadRelations(ctx, films)
+	if err != nil {
+		return nil, fmt.Errorf("load relations failed: %w", err)
+	}
+
+	credited := make([]entities.CreditedFilm, 0, len(films))
+	for _, film := range films {
+		credited = append(credited, entities.CreditedFilm{FilmEntity: film, Crew: make([]entities.ActorEntity, 0)})
+	}
+	err = r.loadCrew(ctx, credited)
+	if err != nil {
+		return nil, fmt.Errorf("load crew failed: %w", err)
+	}
+	return credited, nil
+}
+
+// loadCrew fills in the people credited on films who are not in their
+// cast, ordered by name.
+func (r Repo) loadCrew(ctx context.Context, films []entities.CreditedFilm) error {
+	if len(films) == 0 {
+		return nil
+	}
+	index := make(map[string]int, len(films))
+	ids := make([]string, 0, len(films))
+	for i, film := range films {
+		index[film.ID] = i
+		ids = append(ids, film.ID)
+	}
+
+	b := listBuilder{}
+	b.addList("c.film_id IN (?)", ids)
+	b.add("c.role <> ?", entities.CreditActor)
+	b.add("NOT EXISTS (SELECT 1 FROM credits a WHERE a.film_id = c.film_id AND a.person_id = c.person_id AND a.role = ?)", entities.CreditActor)
+	rows, err := r.db.QueryContext(ctx, "SELECT DISTINCT c.film_id, p.id, p.name, p.gender, p.birthday, p.version FROM credits c INNER JOIN people p ON p.id = c.person_id"+b.whereClause()+" ORDER BY p.name, p.id", b.args...)
+	if err != nil {
+		return fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var filmID string
+		person := entities.ActorEntity{}
+		err := rows.Scan(&filmID, &person.ID, &person.Name, &person.Gender, &person.Birthday, &person.Version)
+		if err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		films[index[filmID]].Crew = append(films[index[filmID]].Crew, person)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows failed: %w", err)
+	}
+	return nil
+}
+
+// ExportActors calls fn for every actor ordered by ID. The actors are read
+// a page at a time like the films of ExportFilms, so fn never runs with a
+// connection held.
+func (r Repo) ExportActors(ctx context.Context, fn func(actor entities.ActorEntity) error) error {
+	after := ""
+	for {
+		actors, err := r.exportActorPage(ctx, after)
+		if err != nil {
+			return err
+		}
+
+		for _, actor := range actors {
+			if err := fn(actor); err != nil {
+				return err
+			}
+		}
+		if len(actors) < exportPageSize {
+			return nil
+		}
+		after = actors[len(actors)-1].ID
+	}
+}
+
+// exportActorPage reads the actors following the one with ID after, from
+// the first one if after is empty.
+func (r Repo) exportActorPage(ctx context.Context, after string) ([]entities.ActorEntity, error) {
+	b := listBuilder{}
+	if after != "" {
+		b.add("id > ?", after)
+	}
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name, gender, birthday FROM people"+b.whereClause()+
+		" ORDER BY id LIMIT "+strconv.Itoa(exportPageSize), b.args...)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	actors := make([]entities.ActorEntity, 0, exportPageSize)
+	for rows.Next() {
+		actor := entities.ActorEntity{}
+		err := rows.Scan(&actor.ID, &actor.Name, &actor.Gender, &actor.Birthday)
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		actors = append(actors, actor)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+	return actors, nil
+}
@@ -2,75 +2,168 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"filmography/internal/entities"
 	"fmt"
+	"slices"
+	"strconv"
 	"time"
-
-	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
+// filmsWithActors selects the films of source joined with their cast,
+// source is either a table or a parenthesized subquery.
+func filmsWithActors(source string) string {
+	return `SELECT f.id, f.title, f.description, f.release_date, f.rating, f.rating_sum, f.rating_count, f.version, a.id, a.name, a.gender, a.birthday, a.version
+FROM ` + source + ` f
+LEFT JOIN credits c ON c.film_id = f.id AND c.role = 'actor'
+LEFT JOIN people a ON a.id = c.person_id`
+}
+
+// castOrder orders the cast joined by filmsWithActors by billing, the
+// unbilled actors come last by name.
+const castOrder = "c.billing = 0, c.billing, a.name, a.id"
+
 func (r Repo) CreateFilm(ctx context.Context, film entities.FilmEntity) error {
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	row := r.db.QueryRowContext(queryCtx, "INSERT INTO films (id, title, description, release_date, rating) VALUES($1, $2, $3, $4, $5)", film.ID, film.Title, film.Description, film.ReleaseDate, film.Rating)
-	if row.Err() != nil {
-		return fmt.Errorf("query row context order failed: %w", row.Err())
+	tx, err := r.db.BeginTx(queryCtx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(queryCtx, "INSERT INTO films (id, title, description, release_date, rating) VALUES($1, $2, $3, $4, $5)", film.ID, film.Title, film.Description, film.ReleaseDate, film.Rating)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
+	}
+
+	err = setFilmCredits(queryCtx, tx, film.ID, entities.FilmCredits(film))
+	if err != nil {
+		return fmt.Errorf("set film credits failed: %w", err)
+	}
+
+	err = setFilmTaxonomy(queryCtx, tx, film.ID, film)
+	if err != nil {
+		return fmt.Errorf("set film taxonomy failed: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit failed: %w", err)
 	}
 
 	return nil
 }
 
-func (r Repo) GetFilms(ctx context.Context) ([]entities.FilmEntity, error) {
+func (r Repo) GetFilms(ctx context.Context, query entities.FilmQuery) ([]entities.FilmEntity, int, error) {
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	rows, err := r.db.QueryContext(queryCtx, "SELECT * FROM films")
+	b := filmFilter(query)
+
+	var total int
+	err := r.db.QueryRowContext(queryCtx, "SELECT COUNT(*) FROM films"+b.whereClause(), b.args...).Scan(&total)
 	if err != nil {
-		return nil, fmt.Errorf("query context failed: %w", err)
+		return nil, 0, fmt.Errorf("count failed: %w", err)
 	}
-	defer rows.Close()
 
-	films := make([]entities.FilmEntity, 0)
+	b.seek("", query.ListQuery, entities.FilmSortFields)
+	page := "SELECT id, title, description, release_date, rating, rating_sum, rating_count, version FROM films" + b.whereClause() +
+		orderClause("", query.Sort, entities.FilmSortFields) + b.pageClause(query.ListQuery)
+	rows, err := r.db.QueryContext(queryCtx, filmsWithActors("("+page+")")+orderClause("f.", query.Sort, entities.FilmSortFields)+", "+castOrder, b.args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
 
-	for rows.Next() {
-		film := entities.FilmEntity{}
-		err := rows.Scan(&film.ID, &film.Title, &film.Description, &film.ReleaseDate, &film.Rating)
-		if err != nil {
-			return nil, fmt.Errorf("scan failed: %w", err)
-		}
+	films, err := scanFilmsWithActors(rows)
+	if err != nil {
+		return nil, 0, fmt.Errorf("scan films with actors failed: %w", err)
+	}
 
-		films = append(films, film)
+	err = r.loadRelations(queryCtx, films)
+	if err != nil {
+		return nil, 0, fmt.Errorf("load relations failed: %w", err)
 	}
 
-	return films, nil
+	return films, total, nil
 }
 
 func (r Repo) GetFilm(ctx context.Context, id string) (entities.FilmEntity, error) {
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	row := r.db.QueryRowContext(queryCtx, "SELECT * FROM films")
-	if row.Err() != nil {
-		return entities.FilmEntity{}, fmt.Errorf("query context failed: %w", row.Err())
+	rows, err := r.db.QueryContext(queryCtx, filmsWithActors("films")+" WHERE f.id = $1 ORDER BY "+castOrder, id)
+	if err != nil {
+		return entities.FilmEntity{}, fmt.Errorf("query context failed: %w", r.dbError(err))
+	}
+	defer rows.Close()
+
+	films, err := scanFilmsWithActors(rows)
+	if err != nil {
+		return entities.FilmEntity{}, fmt.Errorf("scan films with actors failed: %w", err)
+	}
+	if len(films) == 0 {
+		return entities.FilmEntity{}, fmt.Errorf("film %q: %w", id, entities.ErrNotFound)
 	}
 
-	film := entities.FilmEntity{}
-	err := row.Scan(&film.ID, &film.Title, &film.Description, &film.ReleaseDate, &film.Rating)
+	err = r.loadRelations(queryCtx, films)
 	if err != nil {
-		return entities.FilmEntity{}, fmt.Errorf("scan failed: %w", err)
+		return entities.FilmEntity{}, fmt.Errorf("load relations failed: %w", err)
+	}
+
+	return films[0], nil
+}
+
+func (r Repo) GetActorsByFilm(ctx context.Context, filmID string) ([]entities.ActorEntity, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := r.exists(queryCtx, "films", filmID)
+	if err != nil {
+		return nil, fmt.Errorf("film %q: %w", filmID, err)
+	}
+
+	rows, err := r.db.QueryContext(queryCtx, "SELECT a.id, a.name, a.gender, a.birthday, a.version FROM people a INNER JOIN credits c ON a.id = c.person_id WHERE c.film_id = $1 AND c.role = 'actor' ORDER BY "+castOrder, filmID)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	actors := make([]entities.ActorEntity, 0)
+
+	for rows.Next() {
+		actor := entities.ActorEntity{}
+		err := rows.Scan(&actor.ID, &actor.Name, &actor.Gender, &actor.Birthday, &actor.Version)
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+
+		actors = append(actors, actor)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
 	}
 
-	return film, nil
+	return actors, nil
 }
 
 func (r Repo) UpdateFilm(ctx context.Context, id string, film entities.FilmEntity) error {
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	res, err := r.db.ExecContext(queryCtx, "UPDATE films SET title = $1, descriprion = $2, release_date = $3, rating = $4 WHERE id = $5", film.Title, film.Description, film.ReleaseDate, film.Rating, id)
+	tx, err := r.db.BeginTx(queryCtx, nil)
 	if err != nil {
-		return fmt.Errorf("exec context failed: %w", err)
+		return fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(queryCtx, "UPDATE films SET title = $1, description = $2, release_date = $3, rating = $4, version = version + 1 WHERE id = $5 AND ($6 = 0 OR version = $6)",
+		film.Title, film.Description, film.ReleaseDate, film.Rating, id, film.Version)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
 	}
 
 	num, err := res.RowsAffected()
@@ -78,18 +171,34 @@ func (r Repo) UpdateFilm(ctx context.Context, id string, film entities.FilmEntit
 		return fmt.Errorf("rows affected failed: %w", err)
 	}
 	if num == 0 {
-		return fmt.Errorf("film does not exists")
+		return fmt.Errorf("film %q: %w", id, versionError(queryCtx, tx, "films", id))
 	}
+
+	err = setFilmCredits(queryCtx, tx, id, entities.FilmCredits(film))
+	if err != nil {
+		return fmt.Errorf("set film credits failed: %w", err)
+	}
+
+	err = setFilmTaxonomy(queryCtx, tx, id, film)
+	if err != nil {
+		return fmt.Errorf("set film taxonomy failed: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+
 	return nil
 }
 
-func (r Repo) DeleteFilm(ctx context.Context, id string) error {
+func (r Repo) DeleteFilm(ctx context.Context, id string, version int) error {
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	res, err := r.db.ExecContext(queryCtx, "DELETE FROM films WHERE id = $1", id)
+	res, err := r.db.ExecContext(queryCtx, "DELETE FROM films WHERE id = $1 AND ($2 = 0 OR version = $2)", id, version)
 	if err != nil {
-		return fmt.Errorf("exec context failed: %w", err)
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
 	}
 
 	num, err := res.RowsAffected()
@@ -97,7 +206,320 @@ func (r Repo) DeleteFilm(ctx context.Context, id string) error {
 		return fmt.Errorf("rows affected failed: %w", err)
 	}
 	if num == 0 {
-		return fmt.Errorf("film does not exists")
+		return fmt.Errorf("film %q: %w", id, versionError(queryCtx, r.db, "films", id))
+	}
+	return nil
+}
+
+// setFilmCredits replaces the credits of the film. People are linked by
+// ID only, an unknown ID aborts the whole transaction.
+func setFilmCredits(ctx context.Context, tx sqlTx, filmID string, credits []entities.CreditEntity) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM credits WHERE film_id = $1", filmID)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
 	}
+
+	for _, credit := range credits {
+		res, err := tx.ExecContext(ctx, "INSERT INTO credits (person_id, film_id, role, character_name, billing) SELECT id, $2, $3, $4, $5 FROM people WHERE id = $1",
+			credit.PersonID, filmID, credit.Role, credit.Character, credit.Billing)
+		if err != nil {
+			if errors.Is(tx.dbError(err), entities.ErrNotFound) {
+				return fmt.Errorf("person %q: %w", credit.PersonID, entities.ErrUnknownActor)
+			}
+			return fmt.Errorf("exec context failed: %w", tx.dbError(err))
+		}
+
+		num, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected failed: %w", err)
+		}
+		if num == 0 {
+			return fmt.Errorf("person %q: %w", credit.PersonID, entities.ErrUnknownActor)
+		}
+	}
+
 	return nil
 }
+
+// setFilmCast makes the existing actors the cast of the film. The other
+// credits are kept, and so are the character and billing of the actors
+// who stay in the cast.
+func setFilmCast(ctx context.Context, tx sqlTx, filmID string, actors []entities.ActorEntity) error {
+	ids := make([]string, 0, len(actors))
+	for _, actor := range actors {
+		ids = append(ids, actor.ID)
+	}
+
+	b := listBuilder{}
+	b.add("film_id = ?", filmID)
+	b.add("role = ?", entities.CreditActor)
+	if len(ids) > 0 {
+		b.addList("person_id NOT IN (?)", ids)
+	}
+	_, err := tx.ExecContext(ctx, "DELETE FROM credits"+b.whereClause(), b.args...)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	for _, id := range ids {
+		_, err := tx.ExecContext(ctx, "INSERT INTO credits (person_id, film_id, role) VALUES($1, $2, $3) ON CONFLICT DO NOTHING", id, filmID, entities.CreditActor)
+		if err != nil {
+			return fmt.Errorf("exec context failed: %w", tx.dbError(err))
+		}
+	}
+
+	return nil
+}
+
+// filmFilter collects the conditions of query on the films table.
+func filmFilter(query entities.FilmQuery) listBuilder {
+	b := listBuilder{}
+	if query.MinRating != nil {
+		b.add("rating >= ?", *query.MinRating)
+	}
+	if query.ReleasedAfter != nil {
+		b.add("release_date > ?", *query.ReleasedAfter)
+	}
+	if len(query.Genres) > 0 {
+		b.addList(linkedTo("genres_films", "genre_id", len(query.Genres), query.MatchAll), query.Genres)
+	}
+	if len(query.Tags) > 0 {
+		b.addList(linkedTo("film_tags", "tag", len(query.Tags), query.MatchAll), query.Tags)
+	}
+	return b
+}
+
+// linkedTo is the condition of a film linked in table to any of n distinct
+// values of column, or to all of them.
+func linkedTo(table, column string, n int, all bool) string {
+	cond := "id IN (SELECT film_id FROM " + table + " WHERE " + column + " IN (?)"
+	if all {
+		cond += " GROUP BY film_id HAVING COUNT(*) = " + strconv.Itoa(n)
+	}
+	return cond + ")"
+}
+
+// setFilmTaxonomy replaces the genres and tags of the film with those of
+// film. Genres are linked by ID like the cast, tags are stored as given.
+func setFilmTaxonomy(ctx context.Context, tx sqlTx, filmID string, film entities.FilmEntity) error {
+	err := setFilmGenres(ctx, tx, filmID, film.Genres)
+	if err != nil {
+		return err
+	}
+	return setFilmTags(ctx, tx, filmID, film.Tags)
+}
+
+func setFilmGenres(ctx context.Context, tx sqlTx, filmID string, genres []entities.GenreEntity) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM genres_films WHERE film_id = $1", filmID)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	linked := make(map[string]struct{}, len(genres))
+	for _, genre := range genres {
+		if _, ok := linked[genre.ID]; ok {
+			continue
+		}
+		linked[genre.ID] = struct{}{}
+
+		res, err := tx.ExecContext(ctx, "INSERT INTO genres_films (genre_id, film_id) SELECT id, $2 FROM genres WHERE id = $1", genre.ID, filmID)
+		if err != nil {
+			if errors.Is(tx.dbError(err), entities.ErrNotFound) {
+				return fmt.Errorf("genre %q: %w", genre.ID, entities.ErrUnknownGenre)
+			}
+			return fmt.Errorf("exec context failed: %w", err)
+		}
+
+		num, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected failed: %w", err)
+		}
+		if num == 0 {
+			return fmt.Errorf("genre %q: %w", genre.ID, entities.ErrUnknownGenre)
+		}
+	}
+
+	return nil
+}
+
+func setFilmTags(ctx context.Context, tx sqlTx, filmID string, tags []string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM film_tags WHERE film_id = $1", filmID)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", err)
+	}
+
+	tagged := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		if _, ok := tagged[tag]; ok {
+			continue
+		}
+		tagged[tag] = struct{}{}
+
+		_, err := tx.ExecContext(ctx, "INSERT INTO film_tags (film_id, tag) VALUES($1, $2)", filmID, tag)
+		if err != nil {
+			return fmt.Errorf("exec context failed: %w", tx.dbError(err))
+		}
+	}
+
+	return nil
+}
+
+// loadRelations fills in the credits, genres and tags of films with one
+// query for each instead of one per film.
+func (r Repo) loadRelations(ctx context.Context, films []entities.FilmEntity) error {
+	if len(films) == 0 {
+		return nil
+	}
+	index := make(map[string]int, len(films))
+	ids := make([]string, 0, len(films))
+	for i, film := range films {
+		films[i].Credits = make([]entities.CreditEntity, 0)
+		films[i].Genres = make([]entities.GenreEntity, 0)
+		films[i].Tags = make([]string, 0)
+		index[film.ID] = i
+		ids = append(ids, film.ID)
+	}
+
+	err := r.loadCredits(ctx, films, index, ids)
+	if err != nil {
+		return err
+	}
+	err = r.loadGenres(ctx, films, index, ids)
+	if err != nil {
+		return err
+	}
+	return r.loadTags(ctx, films, index, ids)
+}
+
+// loadCredits orders the credits with entities.CompareCredits.
+func (r Repo) loadCredits(ctx context.Context, films []entities.FilmEntity, index map[string]int, ids []string) error {
+	b := listBuilder{}
+	b.addList("c.film_id IN (?)", ids)
+	rows, err := r.db.QueryContext(ctx, "SELECT c.film_id, c.person_id, p.name, c.role, c.character_name, c.billing FROM credits c INNER JOIN people p ON p.id = c.person_id"+b.whereClause(), b.args...)
+	if err != nil {
+		return fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var filmID string
+		credit := entities.CreditEntity{}
+		err := rows.Scan(&filmID, &credit.PersonID, &credit.Name, &credit.Role, &credit.Character, &credit.Billing)
+		if err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		films[index[filmID]].Credits = append(films[index[filmID]].Credits, credit)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows failed: %w", err)
+	}
+
+	for i := range films {
+		slices.SortFunc(films[i].Credits, entities.CompareCredits)
+	}
+	return nil
+}
+
+func (r Repo) loadGenres(ctx context.Context, films []entities.FilmEntity, index map[string]int, ids []string) error {
+	b := listBuilder{}
+	b.addList("gf.film_id IN (?)", ids)
+	rows, err := r.db.QueryContext(ctx, "SELECT gf.film_id, g.id, g.name FROM genres_films gf INNER JOIN genres g ON g.id = gf.genre_id"+b.whereClause()+" ORDER BY g.name, g.id", b.args...)
+	if err != nil {
+		return fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var filmID string
+		genre := entities.GenreEntity{}
+		err := rows.Scan(&filmID, &genre.ID, &genre.Name)
+		if err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		films[index[filmID]].Genres = append(films[index[filmID]].Genres, genre)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows failed: %w", err)
+	}
+	return nil
+}
+
+func (r Repo) loadTags(ctx context.Context, films []entities.FilmEntity, index map[string]int, ids []string) error {
+	b := listBuilder{}
+	b.addList("film_id IN (?)", ids)
+	rows, err := r.db.QueryContext(ctx, "SELECT film_id, tag FROM film_tags"+b.whereClause()+" ORDER BY tag", b.args...)
+	if err != nil {
+		return fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var filmID, tag string
+		err := rows.Scan(&filmID, &tag)
+		if err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		films[index[filmID]].Tags = append(films[index[filmID]].Tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows failed: %w", err)
+	}
+	return nil
+}
+
+// scanFilmsWithActors folds the rows of filmsWithActors into films,
+// one row per film and actor pair, keeping the order of the query.
+func scanFilmsWithActors(rows *sql.Rows) ([]entities.FilmEntity, error) {
+	films := make([]entities.FilmEntity, 0)
+	index := make(map[string]int)
+
+	for rows.Next() {
+		film, actor, err := scanFilmWithActor(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		i, ok := index[film.ID]
+		if !ok {
+			films = append(films, film)
+			i = len(films) - 1
+			index[film.ID] = i
+		}
+
+		if actor != nil {
+			films[i].Actors = append(films[i].Actors, *actor)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return films, nil
+}
+
+// scanFilmWithActor scans a row of filmsWithActors. The film comes with an
+// empty cast, actor is nil for a film without cast.
+func scanFilmWithActor(rows *sql.Rows) (entities.FilmEntity, *entities.ActorEntity, error) {
+	film := entities.FilmEntity{Actors: make([]entities.ActorEntity, 0)}
+	var actorID, actorName, actorGender sql.NullString
+	var actorBirthday sql.NullTime
+	var actorVersion sql.NullInt64
+	var ratingSum float64
+	var ratingCount int
+	err := rows.Scan(&film.ID, &film.Title, &film.Description, &film.ReleaseDate, &film.Rating, &ratingSum, &ratingCount, &film.Version, &actorID, &actorName, &actorGender, &actorBirthday, &actorVersion)
+	if err != nil {
+		return entities.FilmEntity{}, nil, fmt.Errorf("scan failed: %w", err)
+	}
+	film.UserRating = entities.NewRatingSummary(ratingSum, ratingCount)
+	if !actorID.Valid {
+		return film, nil, nil
+	}
+
+	return film, &entities.ActorEntity{
+		ID:       actorID.String,
+		Name:     actorName.String,
+		Gender:   actorGender.String,
+		Birthday: actorBirthday.Time,
+		Version:  int(actorVersion.Int64),
+	}, nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+func (r Repo) CreateGenre(ctx context.Context, genre entities.GenreEntity) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(queryCtx, "INSERT INTO genres (id, name) VALUES($1, $2)", genre.ID, genre.Name)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
+	}
+
+	return nil
+}
+
+func (r Repo) GetGenre(ctx context.Context, id string) (entities.GenreEntity, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	genre := entities.GenreEntity{}
+	err := r.db.QueryRowContext(queryCtx, "SELECT id, name FROM genres WHERE id = $1", id).Scan(&genre.ID, &genre.Name)
+	if err != nil {
+		return entities.GenreEntity{}, fmt.Errorf("scan failed: %w", r.dbError(err))
+	}
+
+	return genre, nil
+}
+
+// GetGenreCounts returns every genre ordered by name with the number of
+// films matching query it has. The pagination of query is ignored.
+func (r Repo) GetGenreCounts(ctx context.Context, query entities.FilmQuery) ([]entities.GenreCount, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	b := filmFilter(query)
+	rows, err := r.db.QueryContext(queryCtx, `SELECT g.id, g.name, COUNT(f.id)
+FROM genres g
+LEFT JOIN genres_films gf ON gf.genre_id = g.id
+LEFT JOIN (SELECT id FROM films`+b.whereClause()+`) f ON f.id = gf.film_id
+GROUP BY g.id, g.name
+ORDER BY g.name, g.id`, b.args...)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make([]entities.GenreCount, 0)
+
+	for rows.Next() {
+		count := entities.GenreCount{}
+		err := rows.Scan(&count.ID, &count.Name, &count.Films)
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return counts, nil
+}
+
+// GetTagCounts returns up to limit tags of the films matching query, the
+// most used first. The pagination of query is ignored.
+func (r Repo) GetTagCounts(ctx context.Context, query entities.FilmQuery, limit int) ([]entities.TagCount, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	b := filmFilter(query)
+	films := "SELECT id FROM films" + b.whereClause()
+	b.args = append(b.args, limit)
+	rows, err := r.db.QueryContext(queryCtx, "SELECT tag, COUNT(*) FROM film_tags WHERE film_id IN ("+films+") GROUP BY tag ORDER BY COUNT(*) DESC, tag LIMIT $"+strconv.Itoa(len(b.args)), b.args...)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make([]entities.TagCount, 0)
+
+	for rows.Next() {
+		count := entities.TagCount{}
+		err := rows.Scan(&count.Tag, &count.Films)
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return counts, nil
+}
+
+func (r Repo) UpdateGenre(ctx context.Context, id string, genre entities.GenreEntity) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(queryCtx, "UPDATE genres SET name = $1 WHERE id = $2", genre.Name, id)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return fmt.Errorf("genre %q: %w", id, entities.ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteGenre deletes the genre, the films that had it keep their other
+// genres.
+func (r Repo) DeleteGenre(ctx context.Context, id string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(queryCtx, "DELETE FROM genres WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return fmt.Errorf("genre %q: %w", id, entities.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmography/internal/entities"
+	"fmt"
+	"time"
+)
+
+// ImportFilms stores a batch of films in one transaction. Films replace
+// the stored film with the same ID, the people they credit are matched by
+// name and birthday and created when missing, genres are matched by name.
+// Credits, genres and tags the films leave nil are kept.
+func (r Repo) ImportFilms(ctx context.Context, films []entities.CreditedFilm, dryRun bool) ([]error, error) {
+	return r.importBatch(ctx, len(films), dryRun, func(ctx context.Context, tx sqlTx, i int) error {
+		return importFilm(ctx, tx, films[i])
+	})
+}
+
+// ImportActors stores a batch of actors in one transaction, matching them
+// by name and birthday like the cast of imported films.
+func (r Repo) ImportActors(ctx context.Context, actors []entities.ActorEntity, dryRun bool) ([]error, error) {
+	return r.importBatch(ctx, len(actors), dryRun, func(ctx context.Context, tx sqlTx, i int) error {
+		_, err := upsertActor(ctx, tx, actors[i])
+		return err
+	})
+}
+
+// importBatch applies the n records of a batch in one transaction. Every
+// record is applied under its own savepoint, so a failing record is
+// reported at its index and the rest of the batch is still committed, or
+// rolled back in a dry run.
+func (r Repo) importBatch(ctx context.Context, n int, dryRun bool, apply func(ctx context.Context, tx sqlTx, i int) error) ([]error, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(queryCtx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	errs := make([]error, n)
+	for i := range n {
+		_, err := tx.ExecContext(queryCtx, "SAVEPOINT import_record")
+		if err != nil {
+			return nil, fmt.Errorf("savepoint failed: %w", err)
+		}
+
+		errs[i] = apply(queryCtx, tx, i)
+		if errs[i] != nil {
+			_, err = tx.ExecContext(queryCtx, "ROLLBACK TO SAVEPOINT import_record")
+			if err != nil {
+				return nil, fmt.Errorf("rollback to savepoint failed: %w", err)
+			}
+		}
+		_, err = tx.ExecContext(queryCtx, "RELEASE SAVEPOINT import_record")
+		if err != nil {
+			return nil, fmt.Errorf("release savepoint failed: %w", err)
+		}
+	}
+
+	if dryRun {
+		return errs, nil
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return errs, nil
+}
+
+func importFilm(ctx context.Context, tx sqlTx, film entities.CreditedFilm) error {
+	// ids maps the IDs of the people of the file to the stored ones.
+	ids := make(map[string]string, len(film.Actors)+len(film.Crew))
+	cast := make([]entities.ActorEntity, 0, len(film.Actors))
+	for _, actor := range film.Actors {
+		id, err := upsertActor(ctx, tx, actor)
+		if err != nil {
+			return fmt.Errorf("actor %q: %w", actor.Name, err)
+		}
+		ids[actor.ID] = id
+		cast = append(cast, entities.ActorEntity{ID: id})
+	}
+	for _, person := range film.Crew {
+		id, err := upsertActor(ctx, tx, person)
+		if err != nil {
+			return fmt.Errorf("person %q: %w", person.Name, err)
+		}
+		ids[person.ID] = id
+	}
+
+	_, err := tx.ExecContext(ctx, `INSERT INTO films (id, title, description, release_date, rating) VALUES($1, $2, $3, $4, $5)
+ON CONFLICT (id) DO UPDATE SET title = excluded.title, description = excluded.description, release_date = excluded.release_date, rating = excluded.rating, version = films.version + 1`,
+		film.ID, film.Title, film.Description, film.ReleaseDate, film.Rating)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", tx.dbError(err))
+	}
+
+	if film.Credits == nil {
+		err = setFilmCast(ctx, tx, film.ID, cast)
+		if err != nil {
+			return fmt.Errorf("set film cast failed: %w", err)
+		}
+	} else {
+		credits := make([]entities.CreditEntity, 0, len(film.Credits))
+		for _, credit := range film.Credits {
+			if id, ok := ids[credit.PersonID]; ok {
+				credit.PersonID = id
+			}
+			credits = append(credits, credit)
+		}
+		err = setFilmCredits(ctx, tx, film.ID, entities.FilmCredits(entities.FilmEntity{Actors: cast, Credits: credits}))
+		if err != nil {
+			return fmt.Errorf("set film credits failed: %w", err)
+		}
+	}
+
+	if film.Genres != nil {
+		genres := make([]entities.GenreEntity, 0, len(film.Genres))
+		for _, genre := range film.Genres {
+			id, err := upsertGenre(ctx, tx, genre)
+			if err != nil {
+				return fmt.Errorf("genre %q: %w", genre.Name, err)
+			}
+			genres = append(genres, entities.GenreEntity{ID: id})
+		}
+		err = setFilmGenres(ctx, tx, film.ID, genres)
+		if err != nil {
+			return fmt.Errorf("set film genres failed: %w", err)
+		}
+	}
+
+	if film.Tags != nil {
+		err = setFilmTags(ctx, tx, film.ID, film.Tags)
+		if err != nil {
+			return fmt.Errorf("set film tags failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// upsertActor returns the ID of the actor with the same name and birthday,
+// updating its gender when one is given, or creates the actor.
+func upsertActor(ctx context.Context, tx sqlTx, actor entities.ActorEntity) (string, error) {
+	var id string
+	err := tx.QueryRowContext(ctx, "SELECT id FROM people WHERE name = $1 AND "+tx.dialect.Date("birthday")+" = "+tx.dialect.Date("$2")+" ORDER BY id LIMIT 1", actor.Name, actor.Birthday).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = tx.ExecContext(ctx, "INSERT INTO people (id, name, gender, birthday) VALUES($1, $2, $3, $4)", actor.ID, actor.Name, actor.Gender, actor.Birthday)
+		if err != nil {
+			return "", fmt.Errorf("exec context failed: %w", tx.dbError(err))
+		}
+		return actor.ID, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("scan failed: %w", tx.dbError(err))
+	}
+
+	if actor.Gender != "" {
+		_, err = tx.ExecContext(ctx, "UPDATE people SET gender = $1, version = version + 1 WHERE id = $2", actor.Gender, id)
+		if err != nil {
+			return "", fmt.Errorf("exec context failed: %w", tx.dbError(err))
+		}
+	}
+	return id, nil
+}
+
+// upsertGenre returns the ID of the genre with the name of genre, ignoring
+// case like the unique index, or creates the genre.
+func upsertGenre(ctx context.Context, tx sqlTx, genre entities.GenreEntity) (string, error) {
+	var id string
+	err := tx.QueryRowContext(ctx, "SELECT id FROM genres WHERE lower(name) = lower($1)", genre.Name).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = tx.ExecContext(ctx, "INSERT INTO genres (id, name) VALUES($1, $2)", genre.ID, genre.Name)
+		if err != nil {
+			return "", fmt.Errorf("exec context failed: %w", tx.dbError(err))
+		}
+		return genre.ID, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("scan failed: %w", tx.dbError(err))
+	}
+	return id, nil
+}
@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"filmography/internal/entities"
+	"fmt"
+	"slices"
+)
+
+func (r *Repo) CreateActor(ctx context.Context, actor entities.ActorEntity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.actors[actor.ID]; ok {
+		return fmt.Errorf("actor %q: %w", actor.ID, entities.ErrConflict)
+	}
+	actor.Version = 1
+	r.actors[actor.ID] = actor
+	return nil
+}
+
+func (r *Repo) GetActors(ctx context.Context, query entities.ActorQuery) ([]entities.ActorEntity, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	actors, total := list(values(r.actors), func(actor entities.ActorEntity) bool {
+		if query.Gender != "" && actor.Gender != query.Gender {
+			return false
+		}
+		if query.BornBefore != nil && !actor.Birthday.Before(*query.BornBefore) {
+			return false
+		}
+		return true
+	}, query.ListQuery, entities.ActorSortFields)
+	return actors, total, nil
+}
+
+func (r *Repo) GetActor(ctx context.Context, id string) (entities.ActorEntity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	actor, ok := r.actors[id]
+	if !ok {
+		return entities.ActorEntity{}, fmt.Errorf("actor %q: %w", id, entities.ErrNotFound)
+	}
+	return actor, nil
+}
+
+func (r *Repo) GetFilmsByActor(ctx context.Context, actorID string) ([]entities.FilmEntity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.actors[actorID]; !ok {
+		return nil, fmt.Errorf("actor %q: %w", actorID, entities.ErrNotFound)
+	}
+
+	films := make([]entities.FilmEntity, 0)
+	for id, credits := range r.credits {
+		if hasCredit(credits, actorID, entities.CreditActor) {
+			films = append(films, r.film(id))
+		}
+	}
+	slices.SortFunc(films, func(a, b entities.FilmEntity) int {
+		if c := a.ReleaseDate.Compare(b.ReleaseDate); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return films, nil
+}
+
+func (r *Repo) GetCreditsByPerson(ctx context.Context, personID string) ([]entities.PersonCredit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.actors[personID]; !ok {
+		return nil, fmt.Errorf("person %q: %w", personID, entities.ErrNotFound)
+	}
+
+	credits := make([]entities.PersonCredit, 0)
+	for filmID, filmCredits := range r.credits {
+		film := r.films[filmID]
+		for _, credit := range filmCredits {
+			if credit.PersonID != personID {
+				continue
+			}
+			credits = append(credits, entities.PersonCredit{
+				FilmID:      film.ID,
+				Title:       film.Title,
+				ReleaseDate: film.ReleaseDate,
+				Role:        credit.Role,
+				Character:   credit.Character,
+				Billing:     credit.Billing,
+			})
+		}
+	}
+	slices.SortFunc(credits, entities.ComparePersonCredits)
+	return credits, nil
+}
+
+func (r *Repo) UpdateActor(ctx context.Context, id string, actor entities.ActorEntity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.actors[id]
+	if !ok {
+		return fmt.Errorf("actor %q: %w", id, entities.ErrNotFound)
+	}
+	if err := checkVersion(stored.Version, actor.Version); err != nil {
+		return fmt.Errorf("actor %q: %w", id, err)
+	}
+	actor.ID = id
+	actor.Version = stored.Version + 1
+	r.actors[id] = actor
+	return nil
+}
+
+func (r *Repo) DeleteActor(ctx context.Context, id string, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.actors[id]
+	if !ok {
+		return fmt.Errorf("actor %q: %w", id, entities.ErrNotFound)
+	}
+	if err := checkVersion(stored.Version, version); err != nil {
+		return fmt.Errorf("actor %q: %w", id, err)
+	}
+	delete(r.actors, id)
+	for filmID, credits := range r.credits {
+		r.credits[filmID] = slices.DeleteFunc(credits, func(credit entities.CreditEntity) bool {
+			return credit.PersonID == id
+		})
+	}
+	return nil
+}
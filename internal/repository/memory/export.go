@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"slices"
+	"strings"
+)
+
+// ExportFilms calls fn for every film with its cast, crew, genres and
+// tags, ordered by ID. The films are copied first so that fn runs without
+// holding the lock.
+func (r *Repo) ExportFilms(ctx context.Context, fn func(film entities.CreditedFilm) error) error {
+	r.mu.RLock()
+	ids := make([]string, 0, len(r.films))
+	for id := range r.films {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	films := make([]entities.CreditedFilm, 0, len(ids))
+	for _, id := range ids {
+		films = append(films, r.creditedFilm(id))
+	}
+	r.mu.RUnlock()
+
+	for _, film := range films {
+		if err := fn(film); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportActors calls fn for every actor ordered by ID.
+func (r *Repo) ExportActors(ctx context.Context, fn func(actor entities.ActorEntity) error) error {
+	r.mu.RLock()
+	actors := values(r.actors)
+	slices.SortFunc(actors, func(a, b entities.ActorEntity) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	r.mu.RUnlock()
+
+	for _, actor := range actors {
+		if err := fn(actor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// creditedFilm returns the stored film with the people credited on it
+// outside the cast, ordered by name. The caller must hold the lock.
+func (r *Repo) creditedFilm(id string) entities.CreditedFilm {
+	film := entities.CreditedFilm{FilmEntity: r.film(id), Crew: make([]entities.ActorEntity, 0)}
+	for _, credit := range film.Credits {
+		if hasCredit(film.Credits, credit.PersonID, entities.CreditActor) || slices.ContainsFunc(film.Crew, func(person entities.ActorEntity) bool {
+			return person.ID == credit.PersonID
+		}) {
+			continue
+		}
+		film.Crew = append(film.Crew, r.actors[credit.PersonID])
+	}
+	slices.SortFunc(film.Crew, func(a, b entities.ActorEntity) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return film
+}
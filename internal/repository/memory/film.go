@@ -0,0 +1,241 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"filmography/internal/entities"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+func (r *Repo) CreateFilm(ctx context.Context, film entities.FilmEntity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.films[film.ID]; ok {
+		return fmt.Errorf("film %q: %w", film.ID, entities.ErrConflict)
+	}
+	credits, err := r.creditsOf(entities.FilmCredits(film))
+	if err != nil {
+		return fmt.Errorf("set film credits failed: %w", err)
+	}
+	genres, err := r.genresOf(film.Genres)
+	if err != nil {
+		return fmt.Errorf("set film taxonomy failed: %w", err)
+	}
+
+	r.credits[film.ID] = credits
+	r.filmGenres[film.ID] = genres
+	r.tags[film.ID] = tagsOf(film.Tags)
+	film.Actors, film.Credits, film.Genres, film.Tags = nil, nil, nil, nil
+	film.Version = 1
+	r.films[film.ID] = film
+	return nil
+}
+
+func (r *Repo) GetFilms(ctx context.Context, query entities.FilmQuery) ([]entities.FilmEntity, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	films, total := list(values(r.films), func(film entities.FilmEntity) bool {
+		return r.matches(film, query)
+	}, query.ListQuery, entities.FilmSortFields)
+
+	for i := range films {
+		films[i] = r.film(films[i].ID)
+	}
+	return films, total, nil
+}
+
+func (r *Repo) GetFilm(ctx context.Context, id string) (entities.FilmEntity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.films[id]; !ok {
+		return entities.FilmEntity{}, fmt.Errorf("film %q: %w", id, entities.ErrNotFound)
+	}
+	return r.film(id), nil
+}
+
+func (r *Repo) GetActorsByFilm(ctx context.Context, filmID string) ([]entities.ActorEntity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.films[filmID]; !ok {
+		return nil, fmt.Errorf("film %q: %w", filmID, entities.ErrNotFound)
+	}
+	return r.film(filmID).Actors, nil
+}
+
+func (r *Repo) UpdateFilm(ctx context.Context, id string, film entities.FilmEntity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.films[id]
+	if !ok {
+		return fmt.Errorf("film %q: %w", id, entities.ErrNotFound)
+	}
+	if err := checkVersion(stored.Version, film.Version); err != nil {
+		return fmt.Errorf("film %q: %w", id, err)
+	}
+	credits, err := r.creditsOf(entities.FilmCredits(film))
+	if err != nil {
+		return fmt.Errorf("set film credits failed: %w", err)
+	}
+	genres, err := r.genresOf(film.Genres)
+	if err != nil {
+		return fmt.Errorf("set film taxonomy failed: %w", err)
+	}
+
+	r.credits[id] = credits
+	r.filmGenres[id] = genres
+	r.tags[id] = tagsOf(film.Tags)
+	film.ID = id
+	film.Actors, film.Credits, film.Genres, film.Tags = nil, nil, nil, nil
+	film.Version = stored.Version + 1
+	r.films[id] = film
+	return nil
+}
+
+func (r *Repo) DeleteFilm(ctx context.Context, id string, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.films[id]
+	if !ok {
+		return fmt.Errorf("film %q: %w", id, entities.ErrNotFound)
+	}
+	if err := checkVersion(stored.Version, version); err != nil {
+		return fmt.Errorf("film %q: %w", id, err)
+	}
+	delete(r.films, id)
+	delete(r.credits, id)
+	delete(r.filmGenres, id)
+	delete(r.tags, id)
+	delete(r.ratings, id)
+	r.history = slices.DeleteFunc(r.history, func(rating entities.FilmRating) bool {
+		return rating.FilmID == id
+	})
+	maps.DeleteFunc(r.reviews, func(_ string, review entities.ReviewEntity) bool {
+		return review.FilmID == id
+	})
+	for userID, entries := range r.watchlists {
+		r.watchlists[userID] = slices.DeleteFunc(entries, func(entry entities.WatchlistEntry) bool {
+			return entry.FilmID == id
+		})
+	}
+	maps.DeleteFunc(r.viewings, func(_ string, entry entities.HistoryEntry) bool {
+		return entry.FilmID == id
+	})
+	return nil
+}
+
+// film returns a copy of the stored film with its cast ordered by
+// billing, its credits and the summary of its ratings. The caller must
+// hold the lock.
+func (r *Repo) film(id string) entities.FilmEntity {
+	film := r.films[id]
+	var sum float64
+	for _, rating := range r.ratings[id] {
+		sum += rating.Rating
+	}
+	film.UserRating = entities.NewRatingSummary(sum, len(r.ratings[id]))
+	film.Credits = make([]entities.CreditEntity, 0, len(r.credits[id]))
+	for _, credit := range r.credits[id] {
+		credit.Name = r.actors[credit.PersonID].Name
+		film.Credits = append(film.Credits, credit)
+	}
+	slices.SortFunc(film.Credits, entities.CompareCredits)
+	film.Actors = make([]entities.ActorEntity, 0)
+	for _, credit := range film.Credits {
+		if credit.Role == entities.CreditActor {
+			film.Actors = append(film.Actors, r.actors[credit.PersonID])
+		}
+	}
+	film.Genres = make([]entities.GenreEntity, 0, len(r.filmGenres[id]))
+	for _, genreID := range r.filmGenres[id] {
+		film.Genres = append(film.Genres, r.genres[genreID])
+	}
+	slices.SortFunc(film.Genres, func(a, b entities.GenreEntity) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	film.Tags = append(make([]string, 0, len(r.tags[id])), r.tags[id]...)
+	slices.Sort(film.Tags)
+	return film
+}
+
+// matches reports whether the stored film is selected by the filters of
+// query. The caller must hold the lock.
+func (r *Repo) matches(film entities.FilmEntity, query entities.FilmQuery) bool {
+	if query.MinRating != nil && film.Rating < *query.MinRating {
+		return false
+	}
+	if query.ReleasedAfter != nil && !film.ReleaseDate.After(*query.ReleasedAfter) {
+		return false
+	}
+	return linkedTo(r.filmGenres[film.ID], query.Genres, query.MatchAll) &&
+		linkedTo(r.tags[film.ID], query.Tags, query.MatchAll)
+}
+
+// linkedTo reports whether linked holds any of values, or all of them.
+// Empty values match everything.
+func linkedTo(linked []string, values []string, all bool) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if slices.Contains(linked, v) != all {
+			return !all
+		}
+	}
+	return all
+}
+
+// genresOf returns the deduplicated IDs of genres, all of which must
+// exist. The caller must hold the lock.
+func (r *Repo) genresOf(genres []entities.GenreEntity) ([]string, error) {
+	ids := make([]string, 0, len(genres))
+	for _, genre := range genres {
+		if _, ok := r.genres[genre.ID]; !ok {
+			return nil, fmt.Errorf("genre %q: %w", genre.ID, entities.ErrUnknownGenre)
+		}
+		if !slices.Contains(ids, genre.ID) {
+			ids = append(ids, genre.ID)
+		}
+	}
+	return ids, nil
+}
+
+// tagsOf returns the deduplicated tags.
+func tagsOf(tags []string) []string {
+	unique := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if !slices.Contains(unique, tag) {
+			unique = append(unique, tag)
+		}
+	}
+	return unique
+}
+
+// creditsOf checks that every credited person exists. The caller must
+// hold the lock.
+func (r *Repo) creditsOf(credits []entities.CreditEntity) ([]entities.CreditEntity, error) {
+	for i, credit := range credits {
+		if _, ok := r.actors[credit.PersonID]; !ok {
+			return nil, fmt.Errorf("person %q: %w", credit.PersonID, entities.ErrUnknownActor)
+		}
+		credits[i].Name = ""
+	}
+	return credits, nil
+}
+
+func hasCredit(credits []entities.CreditEntity, personID string, role entities.CreditRole) bool {
+	return slices.ContainsFunc(credits, func(credit entities.CreditEntity) bool {
+		return credit.PersonID == personID && credit.Role == role
+	})
+}
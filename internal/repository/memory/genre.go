@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"filmography/internal/entities"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+func (r *Repo) CreateGenre(ctx context.Context, genre entities.GenreEntity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.genres[genre.ID]; ok {
+		return fmt.Errorf("genre %q: %w", genre.ID, entities.ErrConflict)
+	}
+	if r.genreNameTaken(genre.Name, "") {
+		return fmt.Errorf("genre %q: %w", genre.Name, entities.ErrConflict)
+	}
+
+	r.genres[genre.ID] = genre
+	return nil
+}
+
+func (r *Repo) GetGenre(ctx context.Context, id string) (entities.GenreEntity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	genre, ok := r.genres[id]
+	if !ok {
+		return entities.GenreEntity{}, fmt.Errorf("genre %q: %w", id, entities.ErrNotFound)
+	}
+	return genre, nil
+}
+
+// GetGenreCounts returns every genre ordered by name with the number of
+// films matching query it has. The pagination of query is ignored.
+func (r *Repo) GetGenreCounts(ctx context.Context, query entities.FilmQuery) ([]entities.GenreCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	films := make(map[string]int, len(r.genres))
+	for id, film := range r.films {
+		if !r.matches(film, query) {
+			continue
+		}
+		for _, genreID := range r.filmGenres[id] {
+			films[genreID]++
+		}
+	}
+
+	counts := make([]entities.GenreCount, 0, len(r.genres))
+	for id, genre := range r.genres {
+		counts = append(counts, entities.GenreCount{GenreEntity: genre, Films: films[id]})
+	}
+	slices.SortFunc(counts, func(a, b entities.GenreCount) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return counts, nil
+}
+
+// GetTagCounts returns up to limit tags of the films matching query, the
+// most used first. The pagination of query is ignored.
+func (r *Repo) GetTagCounts(ctx context.Context, query entities.FilmQuery, limit int) ([]entities.TagCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	films := make(map[string]int)
+	for id, film := range r.films {
+		if !r.matches(film, query) {
+			continue
+		}
+		for _, tag := range r.tags[id] {
+			films[tag]++
+		}
+	}
+
+	counts := make([]entities.TagCount, 0, len(films))
+	for tag, n := range films {
+		counts = append(counts, entities.TagCount{Tag: tag, Films: n})
+	}
+	slices.SortFunc(counts, func(a, b entities.TagCount) int {
+		if c := cmp.Compare(b.Films, a.Films); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	return counts[:min(limit, len(counts))], nil
+}
+
+func (r *Repo) UpdateGenre(ctx context.Context, id string, genre entities.GenreEntity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.genres[id]; !ok {
+		return fmt.Errorf("genre %q: %w", id, entities.ErrNotFound)
+	}
+	if r.genreNameTaken(genre.Name, id) {
+		return fmt.Errorf("genre %q: %w", genre.Name, entities.ErrConflict)
+	}
+
+	genre.ID = id
+	r.genres[id] = genre
+	return nil
+}
+
+// DeleteGenre deletes the genre, the films that had it keep their other
+// genres.
+func (r *Repo) DeleteGenre(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.genres[id]; !ok {
+		return fmt.Errorf("genre %q: %w", id, entities.ErrNotFound)
+	}
+	delete(r.genres, id)
+	for filmID, genres := range r.filmGenres {
+		r.filmGenres[filmID] = slices.DeleteFunc(genres, func(genreID string) bool {
+			return genreID == id
+		})
+	}
+	return nil
+}
+
+// genreNameTaken reports whether a genre other than the one with ID except
+// has the name, ignoring case like the unique index of the SQL backends.
+// The caller must hold the lock.
+func (r *Repo) genreNameTaken(name string, except string) bool {
+	for id, genre := range r.genres {
+		if id != except && strings.EqualFold(genre.Name, name) {
+			return true
+		}
+	}
+	return false
+}
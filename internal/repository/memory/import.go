@@ -0,0 +1,230 @@
+package memory
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ImportFilms stores a batch of films the way the SQL backends do: films
+// replace the stored film with the same ID, the people they credit are
+// matched by name and birthday and created when missing, genres are
+// matched by name. Credits, genres and tags the films leave nil are kept,
+// without credits so are the crew and the billing of actors who stay in
+// the cast. A failing film leaves the store untouched and is reported at
+// its index. A dry run restores the store once the batch is done.
+func (r *Repo) ImportFilms(ctx context.Context, films []entities.CreditedFilm, dryRun bool) ([]error, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if dryRun {
+		actors, stored, credits := maps.Clone(r.actors), maps.Clone(r.films), maps.Clone(r.credits)
+		genres, filmGenres, tags := maps.Clone(r.genres), maps.Clone(r.filmGenres), maps.Clone(r.tags)
+		defer func() {
+			r.actors, r.films, r.credits = actors, stored, credits
+			r.genres, r.filmGenres, r.tags = genres, filmGenres, tags
+		}()
+	}
+
+	errs := make([]error, len(films))
+	for i, film := range films {
+		errs[i] = r.importFilm(film)
+	}
+	return errs, nil
+}
+
+// ImportActors stores a batch of actors, matching them by name and
+// birthday like the cast of imported films.
+func (r *Repo) ImportActors(ctx context.Context, actors []entities.ActorEntity, dryRun bool) ([]error, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if dryRun {
+		stored := maps.Clone(r.actors)
+		defer func() {
+			r.actors = stored
+		}()
+	}
+
+	errs := make([]error, len(actors))
+	for i, actor := range actors {
+		id, ok := r.findActor(actor, nil)
+		switch {
+		case !ok:
+			if _, taken := r.actors[actor.ID]; taken {
+				errs[i] = fmt.Errorf("actor %q: %w", actor.Name, entities.ErrConflict)
+				continue
+			}
+			actor.Version = 1
+			r.actors[actor.ID] = actor
+		case actor.Gender != "":
+			existing := r.actors[id]
+			existing.Gender = actor.Gender
+			existing.Version++
+			r.actors[id] = existing
+		}
+	}
+	return errs, nil
+}
+
+// importFilm checks the whole film before it changes anything, so that a
+// failure needs no rollback. The caller must hold the lock.
+func (r *Repo) importFilm(film entities.CreditedFilm) error {
+	// changed holds the created and updated people of the film, ids maps
+	// the IDs of the file to the stored ones.
+	changed := make(map[string]entities.ActorEntity)
+	ids := make(map[string]string)
+	cast := make([]string, 0, len(film.Actors))
+
+	for _, actor := range film.Actors {
+		id, err := r.importPerson(actor, changed)
+		if err != nil {
+			return fmt.Errorf("actor %q: %w", actor.Name, err)
+		}
+		ids[actor.ID] = id
+		if !slices.Contains(cast, id) {
+			cast = append(cast, id)
+		}
+	}
+	for _, person := range film.Crew {
+		id, err := r.importPerson(person, changed)
+		if err != nil {
+			return fmt.Errorf("person %q: %w", person.Name, err)
+		}
+		ids[person.ID] = id
+	}
+
+	var credits []entities.CreditEntity
+	if film.Credits == nil {
+		credits = slices.DeleteFunc(slices.Clone(r.credits[film.ID]), func(credit entities.CreditEntity) bool {
+			return credit.Role == entities.CreditActor && !slices.Contains(cast, credit.PersonID)
+		})
+		for _, id := range cast {
+			if !hasCredit(credits, id, entities.CreditActor) {
+				credits = append(credits, entities.CreditEntity{PersonID: id, Role: entities.CreditActor})
+			}
+		}
+	} else {
+		actors := make([]entities.ActorEntity, 0, len(cast))
+		for _, id := range cast {
+			actors = append(actors, entities.ActorEntity{ID: id})
+		}
+		remapped := make([]entities.CreditEntity, 0, len(film.Credits))
+		for _, credit := range film.Credits {
+			if id, ok := ids[credit.PersonID]; ok {
+				credit.PersonID = id
+			}
+			if _, stored := r.actors[credit.PersonID]; !stored {
+				if _, pending := changed[credit.PersonID]; !pending {
+					return fmt.Errorf("person %q: %w", credit.PersonID, entities.ErrUnknownActor)
+				}
+			}
+			credit.Name = ""
+			remapped = append(remapped, credit)
+		}
+		credits = entities.FilmCredits(entities.FilmEntity{Actors: actors, Credits: remapped})
+	}
+
+	// created holds the genres the film adds.
+	created := make(map[string]entities.GenreEntity)
+	var genres []string
+	if film.Genres != nil {
+		genres = make([]string, 0, len(film.Genres))
+		for _, genre := range film.Genres {
+			id, ok := r.findGenre(genre.Name, created)
+			if !ok {
+				if _, taken := r.genres[genre.ID]; taken {
+					return fmt.Errorf("genre %q: %w", genre.Name, entities.ErrConflict)
+				}
+				id = genre.ID
+				created[id] = entities.GenreEntity{ID: id, Name: genre.Name}
+			}
+			if !slices.Contains(genres, id) {
+				genres = append(genres, id)
+			}
+		}
+	}
+
+	maps.Copy(r.actors, changed)
+	maps.Copy(r.genres, created)
+	tags := film.Tags
+	stored := film.FilmEntity
+	stored.Actors, stored.Credits, stored.Genres, stored.Tags = nil, nil, nil, nil
+	stored.Version = r.films[film.ID].Version + 1
+	r.films[film.ID] = stored
+	r.credits[film.ID] = credits
+	if genres != nil {
+		r.filmGenres[film.ID] = genres
+	}
+	if tags != nil {
+		r.tags[film.ID] = tagsOf(tags)
+	}
+	return nil
+}
+
+// importPerson matches person by name and birthday among the stored and
+// the pending people or adds it to pending, and returns the ID it goes by.
+// A matched person takes the gender of person when it has one. The caller
+// must hold the lock.
+func (r *Repo) importPerson(person entities.ActorEntity, pending map[string]entities.ActorEntity) (string, error) {
+	id, ok := r.findActor(person, pending)
+	if !ok {
+		_, stored := r.actors[person.ID]
+		_, taken := pending[person.ID]
+		if stored || taken {
+			return "", entities.ErrConflict
+		}
+		person.Version = 1
+		pending[person.ID] = person
+		return person.ID, nil
+	}
+	if person.Gender != "" {
+		existing, ok := pending[id]
+		if !ok {
+			existing = r.actors[id]
+			existing.Version++
+		}
+		existing.Gender = person.Gender
+		pending[id] = existing
+	}
+	return id, nil
+}
+
+// findGenre returns the ID of the genre with the name among the stored and
+// the pending ones, ignoring case. The caller must hold the lock.
+func (r *Repo) findGenre(name string, pending map[string]entities.GenreEntity) (string, bool) {
+	for _, candidates := range []map[string]entities.GenreEntity{r.genres, pending} {
+		for id, genre := range candidates {
+			if strings.EqualFold(genre.Name, name) {
+				return id, true
+			}
+		}
+	}
+	return "", false
+}
+
+// findActor returns the lowest ID of an actor with the name and birthday
+// of actor among the stored and the pending ones. The caller must hold
+// the lock.
+func (r *Repo) findActor(actor entities.ActorEntity, pending map[string]entities.ActorEntity) (string, bool) {
+	var found string
+	for _, candidates := range []map[string]entities.ActorEntity{r.actors, pending} {
+		for id, candidate := range candidates {
+			if candidate.Name == actor.Name && sameDay(candidate.Birthday, actor.Birthday) && (found == "" || id < found) {
+				found = id
+			}
+		}
+	}
+	return found, found != ""
+}
+
+// sameDay compares the dates the SQL backends store birthdays as.
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.UTC().Date()
+	by, bm, bd := b.UTC().Date()
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"cmp"
+	"filmography/internal/entities"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Repo keeps the catalogue in process memory. It is safe for concurrent
+// use and loses everything on restart, which makes it suitable for tests
+// and demo deployments only.
+type Repo struct {
+	mu     sync.RWMutex
+	actors map[string]entities.ActorEntity
+	films  map[string]entities.FilmEntity
+	// credits maps a film ID to its credits, the names are filled in when
+	// the film is read.
+	credits map[string][]entities.CreditEntity
+	// genres holds the genres by ID, filmGenres and tags map a film ID to
+	// the IDs of its genres and to its tags.
+	genres     map[string]entities.GenreEntity
+	filmGenres map[string][]string
+	tags       map[string][]string
+	users      map[string]entities.UserEntity
+	// ratings maps a film ID to the current ratings by user ID, history
+	// holds every rating in the order they were given, numbered by
+	// historyID.
+	ratings   map[string]map[string]entities.FilmRating
+	history   []entities.FilmRating
+	historyID int64
+	reviews   map[string]entities.ReviewEntity
+	// watchlists holds the watchlist of a user ID in order, the positions
+	// of the entries are their indexes plus one.
+	watchlists map[string][]entities.WatchlistEntry
+	viewings   map[string]entities.HistoryEntry
+}
+
+func New() *Repo {
+	return &Repo{
+		actors:     make(map[string]entities.ActorEntity),
+		films:      make(map[string]entities.FilmEntity),
+		credits:    make(map[string][]entities.CreditEntity),
+		genres:     make(map[string]entities.GenreEntity),
+		filmGenres: make(map[string][]string),
+		tags:       make(map[string][]string),
+		users:      make(map[string]entities.UserEntity),
+		ratings:    make(map[string]map[string]entities.FilmRating),
+		reviews:    make(map[string]entities.ReviewEntity),
+		watchlists: make(map[string][]entities.WatchlistEntry),
+		viewings:   make(map[string]entities.HistoryEntry),
+	}
+}
+
+func (r *Repo) Close() error {
+	return nil
+}
+
+// list filters, orders and pages items the way the SQL backends do: by the
+// allowed fields of the query, then by ID. A page behind a cursor starts
+// after the item the keys of query.After belong to.
+func list[T entities.Sortable](items []T, keep func(T) bool, query entities.ListQuery, allowed []string) ([]T, int) {
+	items = slices.DeleteFunc(items, func(item T) bool {
+		return !keep(item)
+	})
+
+	sort := make([]entities.SortField, 0, len(query.Sort)+1)
+	for _, s := range query.Sort {
+		if slices.Contains(allowed, s.Field) {
+			sort = append(sort, s)
+		}
+	}
+	sort = append(sort, entities.SortField{Field: "id"})
+	order := func(keys func(field string) any, than func(field string) any) int {
+		for _, s := range sort {
+			c := compareKeys(keys(s.Field), than(s.Field))
+			if s.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+	slices.SortFunc(items, func(a, b T) int {
+		return order(a.SortKey, b.SortKey)
+	})
+
+	total := len(items)
+	start := min(query.Offset, total)
+	if query.After != nil {
+		after := afterKeys(query)
+		start, _ = slices.BinarySearchFunc(items, after, func(item T, after func(field string) any) int {
+			if order(item.SortKey, after) <= 0 {
+				return -1
+			}
+			return 1
+		})
+	}
+	end := min(start+query.Limit, total)
+	return items[start:end], total
+}
+
+// afterKeys looks up the cursor keys of query by sort field.
+func afterKeys(query entities.ListQuery) func(field string) any {
+	keys := make(map[string]any, len(query.After))
+	for i, s := range query.Sort {
+		if i < len(query.After) {
+			keys[s.Field] = query.After[i]
+		}
+	}
+	keys["id"] = query.After[len(query.After)-1]
+	return func(field string) any {
+		return keys[field]
+	}
+}
+
+// compareKeys orders two sort keys of the same field.
+func compareKeys(a, b any) int {
+	switch a := a.(type) {
+	case string:
+		b, _ := b.(string)
+		return strings.Compare(a, b)
+	case float64:
+		b, _ := b.(float64)
+		return cmp.Compare(a, b)
+	case int:
+		b, _ := b.(int)
+		return cmp.Compare(a, b)
+	case int64:
+		b, _ := b.(int64)
+		return cmp.Compare(a, b)
+	case time.Time:
+		b, _ := b.(time.Time)
+		return a.Compare(b)
+	}
+	return 0
+}
+
+// checkVersion tells whether a conditional write expecting version may
+// change a record stored at the stored version.
+func checkVersion(stored, version int) error {
+	if version != entities.AnyVersion && version != stored {
+		return entities.ErrVersionMismatch
+	}
+	return nil
+}
+
+func values[K comparable, V any](m map[K]V) []V {
+	items := make([]V, 0, len(m))
+	for _, v := range m {
+		items = append(items, v)
+	}
+	return items
+}
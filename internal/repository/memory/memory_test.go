@@ -0,0 +1,13 @@
+package memory
+
+import (
+	"filmography/internal/repository/repotest"
+	"filmography/service"
+	"testing"
+)
+
+func TestRepo(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) service.Repo {
+		return New()
+	})
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"fmt"
+	"slices"
+)
+
+func (r *Repo) RateFilm(ctx context.Context, rating entities.FilmRating) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.films[rating.FilmID]; !ok {
+		return fmt.Errorf("film %q: %w", rating.FilmID, entities.ErrNotFound)
+	}
+	if _, ok := r.users[rating.UserID]; !ok {
+		return fmt.Errorf("user %q: %w", rating.UserID, entities.ErrInvalidReference)
+	}
+
+	rating.Rating = entities.RoundRating(rating.Rating)
+	if r.ratings[rating.FilmID] == nil {
+		r.ratings[rating.FilmID] = make(map[string]entities.FilmRating)
+	}
+	r.ratings[rating.FilmID][rating.UserID] = rating
+	r.historyID++
+	rating.ID = r.historyID
+	r.history = append(r.history, rating)
+	return nil
+}
+
+func (r *Repo) GetFilmRating(ctx context.Context, filmID string, userID string) (entities.FilmRating, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rating, ok := r.ratings[filmID][userID]
+	if !ok {
+		return entities.FilmRating{}, fmt.Errorf("rating of film %q: %w", filmID, entities.ErrNotFound)
+	}
+	return rating, nil
+}
+
+func (r *Repo) GetRatingHistory(ctx context.Context, query entities.RatingQuery) ([]entities.FilmRating, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	query.Sort = entities.RatingHistorySort
+	ratings, total := list(slices.Clone(r.history), func(rating entities.FilmRating) bool {
+		return rating.UserID == query.UserID && (query.FilmID == "" || rating.FilmID == query.FilmID)
+	}, query.ListQuery, entities.RatingSortFields)
+	return ratings, total, nil
+}
+
+func (r *Repo) GetRatingTotals(ctx context.Context) (entities.RatingTotals, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	totals := entities.RatingTotals{}
+	for _, ratings := range r.ratings {
+		for _, rating := range ratings {
+			totals.Sum += rating.Rating
+			totals.Count++
+		}
+	}
+	return totals, nil
+}
@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"fmt"
+)
+
+func (r *Repo) CreateReview(ctx context.Context, review entities.ReviewEntity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.films[review.FilmID]; !ok {
+		return fmt.Errorf("film %q: %w", review.FilmID, entities.ErrNotFound)
+	}
+	if _, ok := r.users[review.UserID]; !ok {
+		return fmt.Errorf("user %q: %w", review.UserID, entities.ErrInvalidReference)
+	}
+	if _, ok := r.reviews[review.ID]; ok {
+		return fmt.Errorf("review %q: %w", review.ID, entities.ErrConflict)
+	}
+	for _, other := range r.reviews {
+		if other.FilmID == review.FilmID && other.UserID == review.UserID {
+			return fmt.Errorf("review of film %q: %w", review.FilmID, entities.ErrConflict)
+		}
+	}
+
+	review.Author = ""
+	r.reviews[review.ID] = review
+	return nil
+}
+
+func (r *Repo) GetReviews(ctx context.Context, query entities.ReviewQuery) ([]entities.ReviewEntity, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if query.FilmID != "" {
+		if _, ok := r.films[query.FilmID]; !ok {
+			return nil, 0, fmt.Errorf("film %q: %w", query.FilmID, entities.ErrNotFound)
+		}
+	}
+
+	reviews, total := list(values(r.reviews), func(review entities.ReviewEntity) bool {
+		return (query.FilmID == "" || review.FilmID == query.FilmID) &&
+			(query.UserID == "" || review.UserID == query.UserID) &&
+			(query.Status == "" || review.Status == query.Status)
+	}, query.ListQuery, entities.ReviewSortFields)
+	for i := range reviews {
+		reviews[i] = r.review(reviews[i])
+	}
+	return reviews, total, nil
+}
+
+func (r *Repo) GetReview(ctx context.Context, id string) (entities.ReviewEntity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	review, ok := r.reviews[id]
+	if !ok {
+		return entities.ReviewEntity{}, fmt.Errorf("review %q: %w", id, entities.ErrNotFound)
+	}
+	return r.review(review), nil
+}
+
+func (r *Repo) UpdateReview(ctx context.Context, review entities.ReviewEntity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.reviews[review.ID]
+	if !ok {
+		return fmt.Errorf("review %q: %w", review.ID, entities.ErrNotFound)
+	}
+	if review.ModeratorID != "" {
+		if _, ok := r.users[review.ModeratorID]; !ok {
+			return fmt.Errorf("user %q: %w", review.ModeratorID, entities.ErrInvalidReference)
+		}
+	}
+
+	stored.Text = review.Text
+	stored.Status = review.Status
+	stored.UpdatedAt = review.UpdatedAt
+	stored.ModeratorID = review.ModeratorID
+	stored.ModeratedAt = review.ModeratedAt
+	r.reviews[review.ID] = stored
+	return nil
+}
+
+func (r *Repo) DeleteReview(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.reviews[id]; !ok {
+		return fmt.Errorf("review %q: %w", id, entities.ErrNotFound)
+	}
+	delete(r.reviews, id)
+	return nil
+}
+
+// review returns the stored review with the username of its author. The
+// caller must hold the lock.
+func (r *Repo) review(review entities.ReviewEntity) entities.ReviewEntity {
+	review.Author = r.users[review.UserID].Username
+	return review
+}
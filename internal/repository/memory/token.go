@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenStore is the in-memory counterpart of the Redis token store. Keys
+// expire lazily when they are read.
+type TokenStore struct {
+	mu sync.Mutex
+	// blacklist holds logged out access tokens.
+	blacklist map[string]time.Time
+	// refresh maps the jti of an unused refresh token to its expiry.
+	refresh map[string]time.Time
+	// revoked holds revoked refresh token families.
+	revoked map[string]time.Time
+}
+
+func NewTokenStore() *TokenStore {
+	return &TokenStore{
+		blacklist: make(map[string]time.Time),
+		refresh:   make(map[string]time.Time),
+		revoked:   make(map[string]time.Time),
+	}
+}
+
+func (s *TokenStore) AddToken(token string, expired time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.blacklist[token] = expiry(expired)
+	return nil
+}
+
+// GetToken reports whether the token is not blacklisted, like the Redis
+// store does.
+func (s *TokenStore) GetToken(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return !s.live(s.blacklist, token)
+}
+
+// AddRefreshToken marks the refresh token with the given jti as unused.
+func (s *TokenStore) AddRefreshToken(id string, family string, expired time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refresh[id] = expiry(expired)
+	return nil
+}
+
+// UseRefreshToken consumes the refresh token and reports whether it was
+// still unused.
+func (s *TokenStore) UseRefreshToken(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unused := s.live(s.refresh, id)
+	delete(s.refresh, id)
+	return unused, nil
+}
+
+func (s *TokenStore) RevokeFamily(family string, expired time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revoked[family] = expiry(expired)
+	return nil
+}
+
+func (s *TokenStore) IsFamilyRevoked(family string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.live(s.revoked, family)
+}
+
+func (s *TokenStore) Close() error {
+	return nil
+}
+
+// live reports whether key is in m and not expired, expired keys are
+// removed. The caller must hold the lock.
+func (s *TokenStore) live(m map[string]time.Time, key string) bool {
+	exp, ok := m[key]
+	if !ok {
+		return false
+	}
+	if !exp.IsZero() && !time.Now().Before(exp) {
+		delete(m, key)
+		return false
+	}
+	return true
+}
+
+// expiry turns a TTL into a deadline, like Redis a TTL of zero never
+// expires.
+func expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
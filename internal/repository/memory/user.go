@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+func (r *Repo) CreateUser(ctx context.Context, user entities.UserEntity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.ID]; ok {
+		return fmt.Errorf("user %q: %w", user.ID, entities.ErrConflict)
+	}
+	if r.usernameTaken(user.Username, "") {
+		return fmt.Errorf("username %q: %w", user.Username, entities.ErrConflict)
+	}
+	user.Version = 1
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *Repo) GetUsers(ctx context.Context, query entities.UserQuery) ([]entities.UserEntity, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users, total := list(values(r.users), func(user entities.UserEntity) bool {
+		return query.Role == "" || user.Role == query.Role
+	}, query.ListQuery, entities.UserSortFields)
+	return users, total, nil
+}
+
+func (r *Repo) GetUser(ctx context.Context, id string) (entities.UserEntity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return entities.UserEntity{}, fmt.Errorf("user %q: %w", id, entities.ErrNotFound)
+	}
+	return user, nil
+}
+
+func (r *Repo) GetUserByUsername(ctx context.Context, username string) (entities.UserEntity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return entities.UserEntity{}, entities.ErrUserNotFound
+}
+
+func (r *Repo) UpdateUser(ctx context.Context, id string, user entities.UserEntity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok {
+		return fmt.Errorf("user %q: %w", id, entities.ErrNotFound)
+	}
+	if err := checkVersion(stored.Version, user.Version); err != nil {
+		return fmt.Errorf("user %q: %w", id, err)
+	}
+	if r.usernameTaken(user.Username, id) {
+		return fmt.Errorf("username %q: %w", user.Username, entities.ErrConflict)
+	}
+	stored.Username = user.Username
+	stored.Role = user.Role
+	stored.Version++
+	r.users[id] = stored
+	return nil
+}
+
+// UpdateUserPassword replaces the password hash of the user.
+func (r *Repo) UpdateUserPassword(ctx context.Context, id string, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return fmt.Errorf("user %q: %w", id, entities.ErrNotFound)
+	}
+	user.PasswordHash = passwordHash
+	r.users[id] = user
+	return nil
+}
+
+func (r *Repo) DeleteUser(ctx context.Context, id string, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok {
+		return fmt.Errorf("user %q: %w", id, entities.ErrNotFound)
+	}
+	if err := checkVersion(stored.Version, version); err != nil {
+		return fmt.Errorf("user %q: %w", id, err)
+	}
+	delete(r.users, id)
+	for _, ratings := range r.ratings {
+		delete(ratings, id)
+	}
+	r.history = slices.DeleteFunc(r.history, func(rating entities.FilmRating) bool {
+		return rating.UserID == id
+	})
+	maps.DeleteFunc(r.reviews, func(_ string, review entities.ReviewEntity) bool {
+		return review.UserID == id
+	})
+	for reviewID, review := range r.reviews {
+		if review.ModeratorID == id {
+			review.ModeratorID = ""
+			r.reviews[reviewID] = review
+		}
+	}
+	delete(r.watchlists, id)
+	maps.DeleteFunc(r.viewings, func(_ string, entry entities.HistoryEntry) bool {
+		return entry.UserID == id
+	})
+	return nil
+}
+
+// usernameTaken reports whether a user other than except has the username.
+// The caller must hold the lock.
+func (r *Repo) usernameTaken(username string, except string) bool {
+	for id, user := range r.users {
+		if id != except && user.Username == username {
+			return true
+		}
+	}
+	return false
+}
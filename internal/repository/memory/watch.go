@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"fmt"
+	"slices"
+)
+
+func (r *Repo) AddToWatchlist(ctx context.Context, entry entities.WatchlistEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.films[entry.FilmID]; !ok {
+		return fmt.Errorf("film %q: %w", entry.FilmID, entities.ErrNotFound)
+	}
+	if _, ok := r.users[entry.UserID]; !ok {
+		return fmt.Errorf("user %q: %w", entry.UserID, entities.ErrInvalidReference)
+	}
+
+	entries := r.watchlists[entry.UserID]
+	position := entry.Position
+	if i := slices.IndexFunc(entries, func(e entities.WatchlistEntry) bool { return e.FilmID == entry.FilmID }); i >= 0 {
+		entry.AddedAt = entries[i].AddedAt
+		entries = slices.Delete(entries, i, i+1)
+		if position == 0 {
+			position = i + 1
+		}
+	}
+	if position == 0 || position > len(entries) {
+		position = len(entries) + 1
+	}
+
+	r.watchlists[entry.UserID] = slices.Insert(entries, position-1, entities.WatchlistEntry{
+		UserID:  entry.UserID,
+		FilmID:  entry.FilmID,
+		AddedAt: entry.AddedAt,
+	})
+	return nil
+}
+
+func (r *Repo) GetWatchlist(ctx context.Context, query entities.WatchQuery) ([]entities.WatchlistEntry, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make([]entities.WatchlistEntry, 0, len(r.watchlists[query.UserID]))
+	for i, entry := range r.watchlists[query.UserID] {
+		film := r.films[entry.FilmID]
+		entry.Title = film.Title
+		entry.ReleaseDate = film.ReleaseDate
+		entry.Position = i + 1
+		entries = append(entries, entry)
+	}
+
+	entries, total := list(entries, func(entry entities.WatchlistEntry) bool {
+		return query.FilmID == "" || entry.FilmID == query.FilmID
+	}, query.ListQuery, entities.WatchlistSortFields)
+	return entries, total, nil
+}
+
+func (r *Repo) RemoveFromWatchlist(ctx context.Context, userID string, filmID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := r.watchlists[userID]
+	i := slices.IndexFunc(entries, func(entry entities.WatchlistEntry) bool { return entry.FilmID == filmID })
+	if i < 0 {
+		return fmt.Errorf("watchlist entry %q: %w", filmID, entities.ErrNotFound)
+	}
+	r.watchlists[userID] = slices.Delete(entries, i, i+1)
+	return nil
+}
+
+// CreateHistoryEntry records the viewing and takes the film off the
+// watchlist of the user.
+func (r *Repo) CreateHistoryEntry(ctx context.Context, entry entities.HistoryEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.films[entry.FilmID]; !ok {
+		return fmt.Errorf("film %q: %w", entry.FilmID, entities.ErrNotFound)
+	}
+	if _, ok := r.users[entry.UserID]; !ok {
+		return fmt.Errorf("user %q: %w", entry.UserID, entities.ErrInvalidReference)
+	}
+	if _, ok := r.viewings[entry.ID]; ok {
+		return fmt.Errorf("history entry %q: %w", entry.ID, entities.ErrConflict)
+	}
+
+	entry.Title = ""
+	entry.Rating = roundedRating(entry.Rating)
+	r.viewings[entry.ID] = entry
+	r.watchlists[entry.UserID] = slices.DeleteFunc(r.watchlists[entry.UserID], func(listed entities.WatchlistEntry) bool {
+		return listed.FilmID == entry.FilmID
+	})
+	return nil
+}
+
+func (r *Repo) GetHistory(ctx context.Context, query entities.WatchQuery) ([]entities.HistoryEntry, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make([]entities.HistoryEntry, 0)
+	for _, entry := range r.viewings {
+		if entry.UserID == query.UserID {
+			entry.Title = r.films[entry.FilmID].Title
+			entries = append(entries, entry)
+		}
+	}
+
+	entries, total := list(entries, func(entry entities.HistoryEntry) bool {
+		return query.FilmID == "" || entry.FilmID == query.FilmID
+	}, query.ListQuery, entities.HistorySortFields)
+	return entries, total, nil
+}
+
+func (r *Repo) GetHistoryEntry(ctx context.Context, userID string, id string) (entities.HistoryEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.viewings[id]
+	if !ok || entry.UserID != userID {
+		return entities.HistoryEntry{}, fmt.Errorf("history entry %q: %w", id, entities.ErrNotFound)
+	}
+	entry.Title = r.films[entry.FilmID].Title
+	return entry, nil
+}
+
+func (r *Repo) UpdateHistoryEntry(ctx context.Context, entry entities.HistoryEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.viewings[entry.ID]
+	if !ok || stored.UserID != entry.UserID {
+		return fmt.Errorf("history entry %q: %w", entry.ID, entities.ErrNotFound)
+	}
+	stored.WatchedAt = entry.WatchedAt
+	stored.Rating = roundedRating(entry.Rating)
+	r.viewings[entry.ID] = stored
+	return nil
+}
+
+func (r *Repo) DeleteHistoryEntry(ctx context.Context, userID string, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.viewings[id]
+	if !ok || stored.UserID != userID {
+		return fmt.Errorf("history entry %q: %w", id, entities.ErrNotFound)
+	}
+	delete(r.viewings, id)
+	return nil
+}
+
+// roundedRating stores an optional rating with two decimals, like the
+// columns of the SQL backends.
+func roundedRating(rating *float64) *float64 {
+	if rating == nil {
+		return nil
+	}
+	rounded := entities.RoundRating(*rating)
+	return &rounded
+}
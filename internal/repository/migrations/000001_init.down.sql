@@ -1,5 +1,7 @@
+DROP TABLE IF EXISTS actors_films;
+
 DROP TABLE IF EXISTS actors;
 
 DROP TABLE IF EXISTS films;
 
-DROP TABLE IF EXISTS actors_films;
\ No newline at end of file
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS actors_search_idx;
+
+ALTER TABLE actors
+    DROP COLUMN IF EXISTS search;
+
+DROP INDEX IF EXISTS films_search_idx;
+
+ALTER TABLE films
+    DROP COLUMN IF EXISTS search;
@@ -0,0 +1,14 @@
+ALTER TABLE films
+    ADD COLUMN IF NOT EXISTS search tsvector
+        GENERATED ALWAYS AS (
+            setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+            setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+        ) STORED;
+
+CREATE INDEX IF NOT EXISTS films_search_idx ON films USING GIN (search);
+
+ALTER TABLE actors
+    ADD COLUMN IF NOT EXISTS search tsvector
+        GENERATED ALWAYS AS (to_tsvector('simple', coalesce(name, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS actors_search_idx ON actors USING GIN (search);
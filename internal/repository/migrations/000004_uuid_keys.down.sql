@@ -0,0 +1,85 @@
+BEGIN;
+
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_role_check,
+    DROP CONSTRAINT IF EXISTS users_username_key;
+
+ALTER TABLE films
+    ALTER COLUMN title DROP NOT NULL,
+    ALTER COLUMN description DROP NOT NULL,
+    ALTER COLUMN description DROP DEFAULT;
+
+ALTER TABLE actors
+    ALTER COLUMN name DROP NOT NULL,
+    ALTER COLUMN gender DROP NOT NULL,
+    ALTER COLUMN gender DROP DEFAULT;
+
+DROP INDEX IF EXISTS actors_films_film_id_idx;
+
+-- New serial keys are numbered in storage order, links are
+-- carried over before the uuid columns are dropped.
+ALTER TABLE actors
+    ADD COLUMN serial_id serial not null;
+
+ALTER TABLE films
+    ADD COLUMN serial_id serial not null;
+
+ALTER TABLE users
+    ADD COLUMN serial_id serial not null;
+
+ALTER TABLE actors_films
+    ADD COLUMN actor_serial_id int,
+    ADD COLUMN film_serial_id  int;
+
+UPDATE actors_films af
+SET actor_serial_id = a.serial_id
+FROM actors a
+WHERE a.id = af.actor_id;
+
+UPDATE actors_films af
+SET film_serial_id = f.serial_id
+FROM films f
+WHERE f.id = af.film_id;
+
+ALTER TABLE actors_films
+    DROP COLUMN actor_id,
+    DROP COLUMN film_id;
+
+ALTER TABLE actors_films
+    RENAME COLUMN actor_serial_id TO actor_id;
+
+ALTER TABLE actors_films
+    RENAME COLUMN film_serial_id TO film_id;
+
+ALTER TABLE actors
+    DROP COLUMN id;
+ALTER TABLE actors
+    RENAME COLUMN serial_id TO id;
+ALTER SEQUENCE actors_serial_id_seq RENAME TO actors_id_seq;
+ALTER TABLE actors
+    ADD UNIQUE (id);
+
+ALTER TABLE films
+    DROP COLUMN id;
+ALTER TABLE films
+    RENAME COLUMN serial_id TO id;
+ALTER SEQUENCE films_serial_id_seq RENAME TO films_id_seq;
+ALTER TABLE films
+    ADD UNIQUE (id);
+
+ALTER TABLE users
+    DROP COLUMN id;
+ALTER TABLE users
+    RENAME COLUMN serial_id TO id;
+ALTER SEQUENCE users_serial_id_seq RENAME TO users_id_seq;
+ALTER TABLE users
+    ADD UNIQUE (id);
+
+ALTER TABLE actors_films
+    ADD COLUMN id serial not null unique,
+    ALTER COLUMN actor_id SET NOT NULL,
+    ALTER COLUMN film_id SET NOT NULL,
+    ADD CONSTRAINT actors_films_actor_id_fkey FOREIGN KEY (actor_id) REFERENCES actors (id) ON DELETE CASCADE,
+    ADD CONSTRAINT actors_films_film_id_fkey FOREIGN KEY (film_id) REFERENCES films (id) ON DELETE CASCADE;
+
+COMMIT;
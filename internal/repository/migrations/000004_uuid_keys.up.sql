@@ -0,0 +1,128 @@
+BEGIN;
+
+-- Usernames become unique and roles are checked at the end. Users sharing
+-- a username cannot be merged or renamed without asking them, so the
+-- migration stops before it changes anything and names the offenders.
+DO
+$$
+DECLARE
+    duplicates text;
+    roles      text;
+BEGIN
+    SELECT string_agg(quote_literal(username), ', ' ORDER BY username)
+    INTO duplicates
+    FROM (SELECT username FROM users GROUP BY username HAVING count(*) > 1) shared;
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'usernames % are taken by more than one user, rename all but one of each and migrate again', duplicates;
+    END IF;
+
+    SELECT string_agg(DISTINCT quote_literal(role), ', ')
+    INTO roles
+    FROM users
+    WHERE role NOT IN ('admin', 'user');
+    IF roles IS NOT NULL THEN
+        RAISE EXCEPTION 'users have the roles % besides admin and user, fix them and migrate again', roles;
+    END IF;
+END;
+$$;
+
+-- Every row gets a fresh uuid, links are carried over through the old
+-- serial keys before those are dropped.
+ALTER TABLE actors
+    ADD COLUMN uid uuid not null default gen_random_uuid();
+
+ALTER TABLE films
+    ADD COLUMN uid uuid not null default gen_random_uuid();
+
+ALTER TABLE users
+    ADD COLUMN uid uuid not null default gen_random_uuid();
+
+ALTER TABLE actors_films
+    ADD COLUMN actor_uid uuid,
+    ADD COLUMN film_uid  uuid;
+
+UPDATE actors_films af
+SET actor_uid = a.uid
+FROM actors a
+WHERE a.id = af.actor_id;
+
+UPDATE actors_films af
+SET film_uid = f.uid
+FROM films f
+WHERE f.id = af.film_id;
+
+-- Dropping the old columns also drops their foreign keys and sequences.
+ALTER TABLE actors_films
+    DROP COLUMN id,
+    DROP COLUMN actor_id,
+    DROP COLUMN film_id;
+
+ALTER TABLE actors_films
+    RENAME COLUMN actor_uid TO actor_id;
+
+ALTER TABLE actors_films
+    RENAME COLUMN film_uid TO film_id;
+
+DELETE
+FROM actors_films a
+    USING actors_films b
+WHERE a.ctid < b.ctid
+  AND a.actor_id = b.actor_id
+  AND a.film_id = b.film_id;
+
+ALTER TABLE actors
+    DROP COLUMN id;
+ALTER TABLE actors
+    RENAME COLUMN uid TO id;
+ALTER TABLE actors
+    ALTER COLUMN id DROP DEFAULT,
+    ADD PRIMARY KEY (id);
+
+ALTER TABLE films
+    DROP COLUMN id;
+ALTER TABLE films
+    RENAME COLUMN uid TO id;
+ALTER TABLE films
+    ALTER COLUMN id DROP DEFAULT,
+    ADD PRIMARY KEY (id);
+
+ALTER TABLE users
+    DROP COLUMN id;
+ALTER TABLE users
+    RENAME COLUMN uid TO id;
+ALTER TABLE users
+    ALTER COLUMN id DROP DEFAULT,
+    ADD PRIMARY KEY (id);
+
+ALTER TABLE actors_films
+    ALTER COLUMN actor_id SET NOT NULL,
+    ALTER COLUMN film_id SET NOT NULL,
+    ADD PRIMARY KEY (actor_id, film_id),
+    ADD CONSTRAINT actors_films_actor_id_fkey FOREIGN KEY (actor_id) REFERENCES actors (id) ON DELETE CASCADE,
+    ADD CONSTRAINT actors_films_film_id_fkey FOREIGN KEY (film_id) REFERENCES films (id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS actors_films_film_id_idx ON actors_films (film_id);
+
+UPDATE actors
+SET name   = coalesce(name, ''),
+    gender = coalesce(gender, '');
+
+ALTER TABLE actors
+    ALTER COLUMN name SET NOT NULL,
+    ALTER COLUMN gender SET NOT NULL,
+    ALTER COLUMN gender SET DEFAULT '';
+
+UPDATE films
+SET title       = coalesce(title, ''),
+    description = coalesce(description, '');
+
+ALTER TABLE films
+    ALTER COLUMN title SET NOT NULL,
+    ALTER COLUMN description SET NOT NULL,
+    ALTER COLUMN description SET DEFAULT '';
+
+ALTER TABLE users
+    ADD CONSTRAINT users_username_key UNIQUE (username),
+    ADD CONSTRAINT users_role_check CHECK (role IN ('admin', 'user'));
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS film_ratings_aggregate ON film_ratings;
+DROP FUNCTION IF EXISTS film_ratings_aggregate();
+
+ALTER TABLE films
+    DROP COLUMN IF EXISTS rating_sum,
+    DROP COLUMN IF EXISTS rating_count;
+
+DROP TABLE IF EXISTS film_rating_history;
+DROP TABLE IF EXISTS film_ratings;
+
+COMMIT;
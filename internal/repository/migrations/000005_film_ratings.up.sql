@@ -0,0 +1,51 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS film_ratings
+(
+    user_id  uuid          not null references users (id) on delete cascade,
+    film_id  uuid          not null references films (id) on delete cascade,
+    rating   decimal(4, 2) not null check ( rating >= 0 and rating <= 10 ),
+    rated_at timestamptz   not null default now(),
+    primary key (user_id, film_id)
+);
+
+CREATE INDEX IF NOT EXISTS film_ratings_film_id_idx ON film_ratings (film_id);
+
+-- Every rating a user has given, film_ratings keeps only the latest one.
+CREATE TABLE IF NOT EXISTS film_rating_history
+(
+    id       bigserial primary key,
+    user_id  uuid          not null references users (id) on delete cascade,
+    film_id  uuid          not null references films (id) on delete cascade,
+    rating   decimal(4, 2) not null,
+    rated_at timestamptz   not null
+);
+
+CREATE INDEX IF NOT EXISTS film_rating_history_user_id_idx ON film_rating_history (user_id, rated_at);
+
+-- The aggregate of the user ratings is kept on the film, the trigger
+-- follows every change of film_ratings including cascaded deletes.
+ALTER TABLE films
+    ADD COLUMN IF NOT EXISTS rating_sum   decimal(12, 2) not null default 0,
+    ADD COLUMN IF NOT EXISTS rating_count integer        not null default 0;
+
+CREATE OR REPLACE FUNCTION film_ratings_aggregate() RETURNS trigger AS
+$$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE films SET rating_sum = rating_sum - OLD.rating, rating_count = rating_count - 1 WHERE id = OLD.film_id;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE films SET rating_sum = rating_sum + NEW.rating, rating_count = rating_count + 1 WHERE id = NEW.film_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER film_ratings_aggregate
+    AFTER INSERT OR UPDATE OR DELETE
+    ON film_ratings
+    FOR EACH ROW
+EXECUTE FUNCTION film_ratings_aggregate();
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS watch_history;
+DROP TABLE IF EXISTS watchlist;
+DROP FUNCTION IF EXISTS watchlist_close_gap();
+
+COMMIT;
@@ -0,0 +1,44 @@
+BEGIN;
+
+-- The films a user plans to watch, in the order the user keeps them.
+CREATE TABLE IF NOT EXISTS watchlist
+(
+    user_id  uuid        not null references users (id) on delete cascade,
+    film_id  uuid        not null references films (id) on delete cascade,
+    position integer     not null check ( position > 0 ),
+    added_at timestamptz not null default now(),
+    primary key (user_id, film_id)
+);
+
+CREATE INDEX IF NOT EXISTS watchlist_film_id_idx ON watchlist (film_id);
+
+-- Positions have no gaps, the trigger closes the one a removed entry
+-- leaves, including the entries removed with their film.
+CREATE OR REPLACE FUNCTION watchlist_close_gap() RETURNS trigger AS
+$$
+BEGIN
+    UPDATE watchlist SET position = position - 1 WHERE user_id = OLD.user_id AND position > OLD.position;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER watchlist_close_gap
+    AFTER DELETE
+    ON watchlist
+    FOR EACH ROW
+EXECUTE FUNCTION watchlist_close_gap();
+
+-- Every viewing of a film, a film may be watched more than once.
+CREATE TABLE IF NOT EXISTS watch_history
+(
+    id         uuid primary key,
+    user_id    uuid          not null references users (id) on delete cascade,
+    film_id    uuid          not null references films (id) on delete cascade,
+    watched_at date          not null,
+    rating     decimal(4, 2) check ( rating >= 0 and rating <= 10 )
+);
+
+CREATE INDEX IF NOT EXISTS watch_history_user_id_idx ON watch_history (user_id, watched_at);
+CREATE INDEX IF NOT EXISTS watch_history_film_id_idx ON watch_history (film_id);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS film_tags;
+DROP TABLE IF EXISTS genres_films;
+DROP TABLE IF EXISTS genres;
+
+COMMIT;
@@ -0,0 +1,24 @@
+BEGIN;
+
+-- Only the actor credits survive, without character and billing.
+ALTER TABLE people
+    RENAME TO actors;
+ALTER INDEX IF EXISTS people_search_idx RENAME TO actors_search_idx;
+
+CREATE TABLE IF NOT EXISTS actors_films
+(
+    actor_id uuid not null references actors (id) on delete cascade,
+    film_id  uuid not null references films (id) on delete cascade,
+    primary key (actor_id, film_id)
+);
+
+CREATE INDEX IF NOT EXISTS actors_films_film_id_idx ON actors_films (film_id);
+
+INSERT INTO actors_films (actor_id, film_id)
+SELECT person_id, film_id
+FROM credits
+WHERE role = 'actor';
+
+DROP TABLE credits;
+
+COMMIT;
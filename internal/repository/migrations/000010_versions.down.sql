@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS version;
+ALTER TABLE people
+    DROP COLUMN IF EXISTS version;
+ALTER TABLE films
+    DROP COLUMN IF EXISTS version;
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+-- The version counts the edits of a record, conditional writes compare it
+-- to the one the client has seen.
+ALTER TABLE films
+    ADD COLUMN IF NOT EXISTS version integer not null default 1;
+ALTER TABLE people
+    ADD COLUMN IF NOT EXISTS version integer not null default 1;
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS version integer not null default 1;
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+CREATE OR REPLACE FUNCTION film_ratings_aggregate() RETURNS trigger AS
+$$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE films SET rating_sum = rating_sum - OLD.rating, rating_count = rating_count - 1 WHERE id = OLD.film_id;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE films SET rating_sum = rating_sum + NEW.rating, rating_count = rating_count + 1 WHERE id = NEW.film_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS rating_totals;
+
+COMMIT;
@@ -0,0 +1,33 @@
+BEGIN;
+
+-- The totals of all user ratings weight the rating of every film that is
+-- read, the trigger keeps them in a single row next to the aggregate on
+-- the film instead of adding up the films on each read.
+CREATE TABLE IF NOT EXISTS rating_totals
+(
+    id           integer primary key default 1 check ( id = 1 ),
+    rating_sum   decimal(14, 2) not null default 0,
+    rating_count bigint         not null default 0
+);
+
+INSERT INTO rating_totals (id, rating_sum, rating_count)
+SELECT 1, COALESCE(SUM(rating), 0), COUNT(*)
+FROM film_ratings
+ON CONFLICT (id) DO NOTHING;
+
+CREATE OR REPLACE FUNCTION film_ratings_aggregate() RETURNS trigger AS
+$$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE films SET rating_sum = rating_sum - OLD.rating, rating_count = rating_count - 1 WHERE id = OLD.film_id;
+        UPDATE rating_totals SET rating_sum = rating_sum - OLD.rating, rating_count = rating_count - 1;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE films SET rating_sum = rating_sum + NEW.rating, rating_count = rating_count + 1 WHERE id = NEW.film_id;
+        UPDATE rating_totals SET rating_sum = rating_sum + NEW.rating, rating_count = rating_count + 1;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
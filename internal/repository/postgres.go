@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"filmography/config"
+	"filmography/internal/entities"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratepostgres "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Postgres error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation           = "23505"
+	pgForeignKeyViolation       = "23503"
+	pgInvalidTextRepresentation = "22P02"
+)
+
+// Postgres is the repository on a Postgres database, on top of Repo it
+// searches through the tsvector columns.
+type Postgres struct {
+	Repo
+	cfg config.Config
+}
+
+func New(cfg config.Config) (Postgres, error) {
+	return open(cfg.GetPostgresUrl(), cfg)
+}
+
+// open connects to the database at dsn and, unless disabled in cfg,
+// migrates it up.
+func open(dsn string, cfg config.Config) (Postgres, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return Postgres{}, fmt.Errorf("open failed: %w", err)
+	}
+
+	err = db.Ping()
+	if err != nil {
+		return Postgres{}, fmt.Errorf("ping failed: %w", err)
+	}
+
+	repo := Postgres{
+		Repo: NewRepo(db, postgres{}),
+		cfg:  cfg,
+	}
+	if !cfg.AutoMigrate {
+		return repo, nil
+	}
+
+	m, err := repo.Migrate()
+	if err != nil {
+		return Postgres{}, fmt.Errorf("migrate failed: %w", err)
+	}
+
+	err = m.Up()
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return Postgres{}, fmt.Errorf("migrate up failed: %w", err)
+	}
+
+	return repo, nil
+}
+
+// Migrate returns the schema migrations of the database. Closing them
+// closes the repository as well.
+func (r Postgres) Migrate() (*migrate.Migrate, error) {
+	driver, err := migratepostgres.WithInstance(r.db.DB, &migratepostgres.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("with instance failed: %w", err)
+	}
+
+	m, err := migrate.NewWithDatabaseInstance(r.cfg.MigratePath, "postgres", driver)
+	if err != nil {
+		return nil, fmt.Errorf("new with database instance failed: %w", err)
+	}
+	return m, nil
+}
+
+// postgres is the dialect the queries are written in.
+type postgres struct{}
+
+func (postgres) Rebind(query string) string {
+	return query
+}
+
+func (postgres) RowLock() string {
+	return "FOR NO KEY UPDATE"
+}
+
+func (postgres) Date(expr string) string {
+	return expr + "::date"
+}
+
+func (postgres) Error(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		return fmt.Errorf("%w: %w", entities.ErrConflict, err)
+	case pgForeignKeyViolation:
+		return fmt.Errorf("%w: %w", entities.ErrInvalidReference, err)
+	case pgInvalidTextRepresentation:
+		// A malformed key can never match a row.
+		return fmt.Errorf("%w: %w", entities.ErrNotFound, err)
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"filmography/internal/entities"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// listBuilder collects the filters of a list query together with their
+// positional arguments.
+type listBuilder struct {
+	where []string
+	args  []any
+}
+
+// add appends a condition, the ? in cond is replaced with the placeholder
+// of arg.
+func (b *listBuilder) add(cond string, arg any) {
+	b.where = append(b.where, strings.Replace(cond, "?", b.arg(arg), 1))
+}
+
+// arg appends a positional argument and returns its placeholder.
+func (b *listBuilder) arg(v any) string {
+	b.args = append(b.args, v)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// addList appends a condition on a list of values, the ? in cond is
+// replaced with the placeholders of values.
+func (b *listBuilder) addList(cond string, values []string) {
+	marks := make([]string, 0, len(values))
+	for _, v := range values {
+		b.args = append(b.args, v)
+		marks = append(marks, "$"+strconv.Itoa(len(b.args)))
+	}
+	b.where = append(b.where, strings.Replace(cond, "?", strings.Join(marks, ", "), 1))
+}
+
+func (b *listBuilder) whereClause() string {
+	if len(b.where) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.where, " AND ")
+}
+
+// pageClause appends the limit and offset of q to the arguments. A page
+// behind a cursor is selected by seek and needs no offset.
+func (b *listBuilder) pageClause(q entities.ListQuery) string {
+	if q.After != nil {
+		return " LIMIT " + b.arg(q.Limit)
+	}
+	return " LIMIT " + b.arg(q.Limit) + " OFFSET " + b.arg(q.Offset)
+}
+
+// seek appends the condition of the rows behind the cursor of q in the
+// order of orderClause: a row is behind when it equals the keys on the
+// first fields and lies beyond them on the next one, the ID breaking the
+// last tie. It must be added after the rows are counted.
+func (b *listBuilder) seek(alias string, q entities.ListQuery, allowed []string) {
+	if len(q.After) != len(q.Sort)+1 {
+		return
+	}
+
+	exprs := make([]string, 0, len(q.After))
+	ops := make([]string, 0, len(q.After))
+	keys := make([]any, 0, len(q.After))
+	for i, s := range q.Sort {
+		if !slices.Contains(allowed, s.Field) {
+			continue
+		}
+		op := " > "
+		if s.Desc {
+			op = " < "
+		}
+		exprs = append(exprs, sortKey(alias, s.Field))
+		ops = append(ops, op)
+		keys = append(keys, q.After[i])
+	}
+	exprs = append(exprs, alias+"id")
+	ops = append(ops, " > ")
+	keys = append(keys, q.After[len(q.Sort)])
+
+	behind := make([]string, 0, len(exprs))
+	for i := range exprs {
+		conds := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			conds = append(conds, exprs[j]+" = "+b.arg(keys[j]))
+		}
+		conds = append(conds, exprs[i]+ops[i]+b.arg(keys[i]))
+		behind = append(behind, "("+strings.Join(conds, " AND ")+")")
+	}
+	b.where = append(b.where, "("+strings.Join(behind, " OR ")+")")
+}
+
+// sortKey is the expression a list is ordered by for a sort field. A
+// missing rating counts as entities.Unrated, so that every store orders it
+// first and a cursor can seek past it.
+func sortKey(alias string, field string) string {
+	if field == "rating" {
+		return "coalesce(" + alias + field + ", " + strconv.FormatFloat(entities.Unrated, 'f', -1, 64) + ")"
+	}
+	return alias + field
+}
+
+// orderClause renders ORDER BY for the allowed sort fields of q with the
+// ID as the final tie-breaker. Unknown fields are skipped.
+func orderClause(alias string, sort []entities.SortField, allowed []string) string {
+	parts := make([]string, 0, len(sort)+1)
+	for _, s := range sort {
+		if !slices.Contains(allowed, s.Field) {
+			continue
+		}
+		part := sortKey(alias, s.Field)
+		if s.Desc {
+			part += " DESC"
+		}
+		parts = append(parts, part)
+	}
+	parts = append(parts, alias+"id")
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"fmt"
+	"time"
+)
+
+// RateFilm replaces the rating the user has given the film and appends it
+// to the history. The aggregate on the film and the totals of all films
+// are kept up to date by the film_ratings trigger.
+func (r Repo) RateFilm(ctx context.Context, rating entities.FilmRating) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := r.exists(queryCtx, "films", rating.FilmID)
+	if err != nil {
+		return fmt.Errorf("film %q: %w", rating.FilmID, err)
+	}
+
+	tx, err := r.db.BeginTx(queryCtx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(queryCtx, `INSERT INTO film_ratings (user_id, film_id, rating, rated_at) VALUES($1, $2, $3, $4)
+ON CONFLICT (user_id, film_id) DO UPDATE SET rating = excluded.rating, rated_at = excluded.rated_at`,
+		rating.UserID, rating.FilmID, rating.Rating, rating.RatedAt)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
+	}
+
+	_, err = tx.ExecContext(queryCtx, "INSERT INTO film_rating_history (user_id, film_id, rating, rated_at) VALUES($1, $2, $3, $4)",
+		rating.UserID, rating.FilmID, rating.Rating, rating.RatedAt)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r Repo) GetFilmRating(ctx context.Context, filmID string, userID string) (entities.FilmRating, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rating := entities.FilmRating{}
+	err := r.db.QueryRowContext(queryCtx, "SELECT film_id, user_id, rating, rated_at FROM film_ratings WHERE film_id = $1 AND user_id = $2", filmID, userID).
+		Scan(&rating.FilmID, &rating.UserID, &rating.Rating, &rating.RatedAt)
+	if err != nil {
+		return entities.FilmRating{}, fmt.Errorf("scan failed: %w", r.dbError(err))
+	}
+
+	return rating, nil
+}
+
+// GetRatingHistory lists the ratings a user has given, newest first.
+func (r Repo) GetRatingHistory(ctx context.Context, query entities.RatingQuery) ([]entities.FilmRating, int, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	b := listBuilder{}
+	b.add("user_id = ?", query.UserID)
+	if query.FilmID != "" {
+		b.add("film_id = ?", query.FilmID)
+	}
+
+	var total int
+	err := r.db.QueryRowContext(queryCtx, "SELECT COUNT(*) FROM film_rating_history"+b.whereClause(), b.args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count failed: %w", r.dbError(err))
+	}
+
+	query.Sort = entities.RatingHistorySort
+	b.seek("", query.ListQuery, entities.RatingSortFields)
+	page := "SELECT id, film_id, user_id, rating, rated_at FROM film_rating_history" + b.whereClause() +
+		orderClause("", query.Sort, entities.RatingSortFields) + b.pageClause(query.ListQuery)
+	rows, err := r.db.QueryContext(queryCtx, page, b.args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	ratings := make([]entities.FilmRating, 0)
+
+	for rows.Next() {
+		rating := entities.FilmRating{}
+		err := rows.Scan(&rating.ID, &rating.FilmID, &rating.UserID, &rating.Rating, &rating.RatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan failed: %w", err)
+		}
+
+		ratings = append(ratings, rating)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return ratings, total, nil
+}
+
+// GetRatingTotals returns the sum and count of the user ratings of all
+// films. The film_ratings trigger keeps them in the single row of
+// rating_totals, so that weighting a film does not add up the whole table.
+func (r Repo) GetRatingTotals(ctx context.Context) (entities.RatingTotals, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	totals := entities.RatingTotals{}
+	err := r.db.QueryRowContext(queryCtx, "SELECT rating_sum, rating_count FROM rating_totals").Scan(&totals.Sum, &totals.Count)
+	if err != nil {
+		return entities.RatingTotals{}, fmt.Errorf("scan failed: %w", err)
+	}
+
+	return totals, nil
+}
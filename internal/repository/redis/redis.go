@@ -40,6 +40,46 @@ func (r Redis) GetToken(token string) bool {
 	return val == ""
 }
 
+// AddRefreshToken marks the refresh token with the given jti as unused.
+func (r Redis) AddRefreshToken(id string, family string, expired time.Duration) error {
+	err := r.client.Set(refreshTokenKey(id), family, expired).Err()
+	if err != nil {
+		return fmt.Errorf("client set failed: %w", err)
+	}
+	return nil
+}
+
+// UseRefreshToken consumes the refresh token and reports whether it was
+// still unused.
+func (r Redis) UseRefreshToken(id string) (bool, error) {
+	num, err := r.client.Del(refreshTokenKey(id)).Result()
+	if err != nil {
+		return false, fmt.Errorf("client del failed: %w", err)
+	}
+	return num == 1, nil
+}
+
+func (r Redis) RevokeFamily(family string, expired time.Duration) error {
+	err := r.client.Set(familyKey(family), true, expired).Err()
+	if err != nil {
+		return fmt.Errorf("client set failed: %w", err)
+	}
+	return nil
+}
+
+func (r Redis) IsFamilyRevoked(family string) bool {
+	val := r.client.Get(familyKey(family)).Val()
+	return val != ""
+}
+
+func refreshTokenKey(id string) string {
+	return "rt:" + id
+}
+
+func familyKey(family string) string {
+	return "rt-family:" + family
+}
+
 func (r Redis) Close() error {
 	return r.client.Close()
 }
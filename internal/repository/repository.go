@@ -1,52 +1,62 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
-	"errors"
-	"filmography/config"
+	"filmography/internal/entities"
 	"fmt"
-
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
+// Repo stores the catalogue in an SQL database. The queries are shared by
+// the databases, the dialect covers their differences.
 type Repo struct {
-	db  *sql.DB
-	cfg config.Config
+	db sqlDB
 }
 
-func New(cfg config.Config) (Repo, error) {
-	db, err := sql.Open("pgx", cfg.GetPostgresUrl())
-	if err != nil {
-		return Repo{}, fmt.Errorf("open failed: %w", err)
-	}
+// NewRepo returns the repository on db, which speaks dialect. The schema
+// is up to the caller.
+func NewRepo(db *sql.DB, dialect Dialect) Repo {
+	return Repo{db: sqlDB{DB: db, dialect: dialect}}
+}
 
-	err = db.Ping()
-	if err != nil {
-		return Repo{}, fmt.Errorf("ping failed: %w", err)
-	}
+func (r Repo) Close() error {
+	return r.db.Close()
+}
 
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
-		return Repo{}, fmt.Errorf("with instance failed: %w", err)
-	}
+func (r Repo) dbError(err error) error {
+	return r.db.dbError(err)
+}
+
+// querier runs the reads of a repository on the database or inside a
+// transaction.
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	dbError(err error) error
+}
 
-	m, err := migrate.NewWithDatabaseInstance(cfg.MigratePath, "postgres", driver)
+// exists reports entities.ErrNotFound unless table has a row with the id.
+func (r Repo) exists(ctx context.Context, table string, id string) error {
+	return rowExists(ctx, r.db, table, id)
+}
+
+func rowExists(ctx context.Context, q querier, table string, id string) error {
+	var found bool
+	err := q.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM "+table+" WHERE id = $1)", id).Scan(&found)
 	if err != nil {
-		return Repo{}, fmt.Errorf("new with database instance failed: %w", err)
+		return fmt.Errorf("exists failed: %w", q.dbError(err))
 	}
-
-	err = m.Up()
-	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return Repo{}, fmt.Errorf("migrate up failed: %w", err)
+	if !found {
+		return entities.ErrNotFound
 	}
-
-	return Repo{
-		db: db,
-	}, nil
+	return nil
 }
 
-func (r Repo) Close() error {
-	return r.db.Close()
+// versionError explains why a conditional write of the record in table
+// matched no rows: it does not exist or it is at another version.
+func versionError(ctx context.Context, q querier, table string, id string) error {
+	err := rowExists(ctx, q, table, id)
+	if err != nil {
+		return err
+	}
+	return entities.ErrVersionMismatch
 }
@@ -0,0 +1,96 @@
+package repotest
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"filmography/service"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func testActorRoundTrip(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	actor := createActor(t, repo, "Keanu Reeves", "male", date(1964, 9, 2))
+
+	got, err := repo.GetActor(ctx, actor.ID)
+	if err != nil {
+		t.Fatalf("GetActor() error = %v", err)
+	}
+	assertActor(t, got, actor)
+
+	actor.Name = "Keanu Charles Reeves"
+	actor.Birthday = date(1964, 9, 3)
+	if err := repo.UpdateActor(ctx, actor.ID, actor); err != nil {
+		t.Fatalf("UpdateActor() error = %v", err)
+	}
+	got, err = repo.GetActor(ctx, actor.ID)
+	if err != nil {
+		t.Fatalf("GetActor() after update error = %v", err)
+	}
+	assertActor(t, got, actor)
+
+	if err := repo.DeleteActor(ctx, actor.ID, entities.AnyVersion); err != nil {
+		t.Fatalf("DeleteActor() error = %v", err)
+	}
+	_, err = repo.GetActor(ctx, actor.ID)
+	wantErr(t, "GetActor() after delete", err, entities.ErrNotFound)
+}
+
+func testActorNotFound(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	id := uuid.NewString()
+
+	_, err := repo.GetActor(ctx, id)
+	wantErr(t, "GetActor()", err, entities.ErrNotFound)
+
+	err = repo.UpdateActor(ctx, id, entities.ActorEntity{Name: "Nobody"})
+	wantErr(t, "UpdateActor()", err, entities.ErrNotFound)
+
+	err = repo.DeleteActor(ctx, id, entities.AnyVersion)
+	wantErr(t, "DeleteActor()", err, entities.ErrNotFound)
+
+	_, err = repo.GetFilmsByActor(ctx, id)
+	wantErr(t, "GetFilmsByActor()", err, entities.ErrNotFound)
+}
+
+func testActorOrderAndFilters(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	keanu := createActor(t, repo, "Keanu Reeves", "male", date(1964, 9, 2))
+	carrie := createActor(t, repo, "Carrie-Anne Moss", "female", date(1967, 8, 21))
+	laurence := createActor(t, repo, "Laurence Fishburne", "male", date(1961, 7, 30))
+
+	actors, total, err := repo.GetActors(ctx, entities.ActorQuery{ListQuery: page(10, 0, asc("name"))})
+	if err != nil {
+		t.Fatalf("GetActors() error = %v", err)
+	}
+	if total != 3 {
+		t.Errorf("total = %d, want 3", total)
+	}
+	assertIDs(t, "actors by name", actorIDs(actors), []string{carrie.ID, keanu.ID, laurence.ID})
+
+	actors, _, err = repo.GetActors(ctx, entities.ActorQuery{ListQuery: page(10, 0, desc("birthday"))})
+	if err != nil {
+		t.Fatalf("GetActors() by birthday error = %v", err)
+	}
+	assertIDs(t, "actors by -birthday", actorIDs(actors), []string{carrie.ID, keanu.ID, laurence.ID})
+
+	actors, total, err = repo.GetActors(ctx, entities.ActorQuery{ListQuery: page(10, 0, asc("birthday")), Gender: "male"})
+	if err != nil {
+		t.Fatalf("GetActors() by gender error = %v", err)
+	}
+	if total != 2 {
+		t.Errorf("total for gender = %d, want 2", total)
+	}
+	assertIDs(t, "male actors", actorIDs(actors), []string{laurence.ID, keanu.ID})
+
+	bornBefore := date(1965, 1, 1)
+	actors, total, err = repo.GetActors(ctx, entities.ActorQuery{ListQuery: page(1, 1, asc("name")), BornBefore: &bornBefore})
+	if err != nil {
+		t.Fatalf("GetActors() born before error = %v", err)
+	}
+	if total != 2 {
+		t.Errorf("total born before = %d, want 2", total)
+	}
+	assertIDs(t, "second actor born before 1965", actorIDs(actors), []string{laurence.ID})
+}
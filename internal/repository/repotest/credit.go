@@ -0,0 +1,207 @@
+package repotest
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"filmography/service"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// createCreditedFilm creates a film like base with credits, base may list
+// actors the old way too.
+func createCreditedFilm(t *testing.T, repo service.Repo, title string, base entities.FilmEntity, credits ...entities.CreditEntity) entities.FilmEntity {
+	t.Helper()
+	film := base
+	film.ID = uuid.NewString()
+	film.Title = title
+	film.Credits = credits
+	if err := repo.CreateFilm(context.Background(), film); err != nil {
+		t.Fatalf("CreateFilm(%q) error = %v", title, err)
+	}
+	return film
+}
+
+func credit(person entities.ActorEntity, role entities.CreditRole) entities.CreditEntity {
+	return entities.CreditEntity{PersonID: person.ID, Role: role}
+}
+
+func billed(person entities.ActorEntity, character string, billing int) entities.CreditEntity {
+	return entities.CreditEntity{PersonID: person.ID, Role: entities.CreditActor, Character: character, Billing: billing}
+}
+
+// creditKeys describes credits as "name/role/character/billing", which
+// shows the order and every stored field at once.
+func creditKeys(credits []entities.CreditEntity) []string {
+	keys := make([]string, 0, len(credits))
+	for _, c := range credits {
+		keys = append(keys, c.Name+"/"+string(c.Role)+"/"+c.Character+"/"+strconv.Itoa(c.Billing))
+	}
+	return keys
+}
+
+func personCreditKeys(credits []entities.PersonCredit) []string {
+	keys := make([]string, 0, len(credits))
+	for _, c := range credits {
+		keys = append(keys, c.Title+"/"+string(c.Role))
+	}
+	return keys
+}
+
+func testFilmCredits(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	mann := createActor(t, repo, "Michael Mann", "male", date(1943, 2, 5))
+	pacino := createActor(t, repo, "Al Pacino", "male", date(1940, 4, 25))
+	deniro := createActor(t, repo, "Robert De Niro", "male", date(1943, 8, 17))
+	kilmer := createActor(t, repo, "Val Kilmer", "male", date(1959, 12, 31))
+
+	// Kilmer is listed as an actor only and becomes an unbilled credit,
+	// Pacino is credited already so his listing adds nothing.
+	heat := createCreditedFilm(t, repo, "Heat", entities.FilmEntity{ReleaseDate: date(1995, 12, 15), Actors: []entities.ActorEntity{kilmer, pacino}},
+		billed(deniro, "Neil McCauley", 2),
+		credit(mann, entities.CreditWriter),
+		billed(pacino, "Vincent Hanna", 1),
+		credit(mann, entities.CreditDirector),
+	)
+
+	got, err := repo.GetFilm(ctx, heat.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() error = %v", err)
+	}
+	assertIDs(t, "credits", creditKeys(got.Credits), []string{
+		"Michael Mann/director//0",
+		"Michael Mann/writer//0",
+		"Al Pacino/actor/Vincent Hanna/1",
+		"Robert De Niro/actor/Neil McCauley/2",
+		"Val Kilmer/actor//0",
+	})
+	assertIDs(t, "cast", actorIDs(got.Actors), []string{pacino.ID, deniro.ID, kilmer.ID})
+
+	cast, err := repo.GetActorsByFilm(ctx, heat.ID)
+	if err != nil {
+		t.Fatalf("GetActorsByFilm() error = %v", err)
+	}
+	assertIDs(t, "GetActorsByFilm()", actorIDs(cast), []string{pacino.ID, deniro.ID, kilmer.ID})
+
+	// Only acting credits make the filmography of an actor.
+	films, err := repo.GetFilmsByActor(ctx, mann.ID)
+	if err != nil {
+		t.Fatalf("GetFilmsByActor() error = %v", err)
+	}
+	assertIDs(t, "films of the director", filmIDs(films), []string{})
+
+	// An update replaces all the credits.
+	heat.Actors, heat.Credits = []entities.ActorEntity{deniro}, nil
+	if err := repo.UpdateFilm(ctx, heat.ID, heat); err != nil {
+		t.Fatalf("UpdateFilm() error = %v", err)
+	}
+	got, err = repo.GetFilm(ctx, heat.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() error = %v", err)
+	}
+	assertIDs(t, "credits after update", creditKeys(got.Credits), []string{"Robert De Niro/actor//0"})
+}
+
+func testCreditsByPerson(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	mann := createActor(t, repo, "Michael Mann", "male", date(1943, 2, 5))
+	caan := createActor(t, repo, "James Caan", "male", date(1940, 3, 26))
+	createCreditedFilm(t, repo, "Heat", entities.FilmEntity{ReleaseDate: date(1995, 12, 15)}, credit(mann, entities.CreditDirector))
+	createCreditedFilm(t, repo, "Thief", entities.FilmEntity{ReleaseDate: date(1981, 3, 27)},
+		credit(mann, entities.CreditWriter),
+		credit(mann, entities.CreditDirector),
+		billed(caan, "Frank", 1),
+	)
+
+	credits, err := repo.GetCreditsByPerson(ctx, mann.ID)
+	if err != nil {
+		t.Fatalf("GetCreditsByPerson() error = %v", err)
+	}
+	assertIDs(t, "credits of the director", personCreditKeys(credits), []string{"Thief/director", "Thief/writer", "Heat/director"})
+
+	credits, err = repo.GetCreditsByPerson(ctx, caan.ID)
+	if err != nil {
+		t.Fatalf("GetCreditsByPerson() error = %v", err)
+	}
+	if len(credits) != 1 || credits[0].Character != "Frank" || credits[0].Billing != 1 || !credits[0].ReleaseDate.Equal(date(1981, 3, 27)) {
+		t.Errorf("credits of the actor = %+v, want Frank billed first in Thief", credits)
+	}
+
+	_, err = repo.GetCreditsByPerson(ctx, uuid.NewString())
+	wantErr(t, "GetCreditsByPerson(unknown)", err, entities.ErrNotFound)
+}
+
+func testFilmUnknownPerson(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	mann := createActor(t, repo, "Michael Mann", "male", date(1943, 2, 5))
+	film := entities.FilmEntity{ID: uuid.NewString(), Title: "Heat", ReleaseDate: date(1995, 12, 15), Credits: []entities.CreditEntity{
+		credit(mann, entities.CreditDirector),
+		{PersonID: uuid.NewString(), Role: entities.CreditComposer},
+	}}
+
+	err := repo.CreateFilm(ctx, film)
+	wantErr(t, "CreateFilm(unknown person)", err, entities.ErrUnknownActor)
+	_, err = repo.GetFilm(ctx, film.ID)
+	wantErr(t, "GetFilm() of the failed film", err, entities.ErrNotFound)
+}
+
+func testImportKeepsCrew(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	mann := createActor(t, repo, "Michael Mann", "male", date(1943, 2, 5))
+	pacino := createActor(t, repo, "Al Pacino", "male", date(1940, 4, 25))
+	deniro := createActor(t, repo, "Robert De Niro", "male", date(1943, 8, 17))
+	heat := createCreditedFilm(t, repo, "Heat", entities.FilmEntity{ReleaseDate: date(1995, 12, 15)},
+		credit(mann, entities.CreditDirector),
+		billed(pacino, "Vincent Hanna", 1),
+		billed(deniro, "Neil McCauley", 2),
+	)
+
+	// The imported cast drops De Niro and adds Kilmer, Pacino is matched
+	// and keeps his part.
+	imported := importedFilm("Heat", importedActor("Al Pacino", "", date(1940, 4, 25)), importedActor("Val Kilmer", "male", date(1959, 12, 31)))
+	imported.ID = heat.ID
+	errs, err := repo.ImportFilms(ctx, []entities.CreditedFilm{imported}, false)
+	if err != nil || errs[0] != nil {
+		t.Fatalf("ImportFilms() = %v, %v", errs, err)
+	}
+
+	got, err := repo.GetFilm(ctx, heat.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() error = %v", err)
+	}
+	assertIDs(t, "credits after import", creditKeys(got.Credits), []string{
+		"Michael Mann/director//0",
+		"Al Pacino/actor/Vincent Hanna/1",
+		"Val Kilmer/actor//0",
+	})
+}
+
+func testCascadeDeleteCredits(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	mann := createActor(t, repo, "Michael Mann", "male", date(1943, 2, 5))
+	pacino := createActor(t, repo, "Al Pacino", "male", date(1940, 4, 25))
+	heat := createCreditedFilm(t, repo, "Heat", entities.FilmEntity{ReleaseDate: date(1995, 12, 15)},
+		credit(mann, entities.CreditDirector),
+		billed(pacino, "Vincent Hanna", 1),
+	)
+
+	if err := repo.DeleteActor(ctx, mann.ID, entities.AnyVersion); err != nil {
+		t.Fatalf("DeleteActor() error = %v", err)
+	}
+	got, err := repo.GetFilm(ctx, heat.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() error = %v", err)
+	}
+	assertIDs(t, "credits after deleting the director", creditKeys(got.Credits), []string{"Al Pacino/actor/Vincent Hanna/1"})
+
+	if err := repo.DeleteFilm(ctx, heat.ID, entities.AnyVersion); err != nil {
+		t.Fatalf("DeleteFilm() error = %v", err)
+	}
+	credits, err := repo.GetCreditsByPerson(ctx, pacino.ID)
+	if err != nil {
+		t.Fatalf("GetCreditsByPerson() error = %v", err)
+	}
+	assertIDs(t, "credits after deleting the film", personCreditKeys(credits), []string{})
+}
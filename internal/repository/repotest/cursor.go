@@ -0,0 +1,133 @@
+package repotest
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"filmography/service"
+	"fmt"
+	"testing"
+)
+
+// keyIDs returns the IDs of items as their sort keys print them.
+func keyIDs[T entities.Sortable](items []T) []string {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, fmt.Sprint(item.SortKey("id")))
+	}
+	return ids
+}
+
+// assertPages reads a list two items at a time, each page behind the last
+// item of the page before, and compares the items with the list read at
+// once.
+func assertPages[T entities.Sortable](t *testing.T, what string, sort []entities.SortField, get func(query entities.ListQuery) ([]T, int, error)) {
+	t.Helper()
+	all, total, err := get(page(100, 0, sort...))
+	if err != nil {
+		t.Fatalf("%s: list error = %v", what, err)
+	}
+
+	got := make([]string, 0, len(all))
+	query := page(2, 0, sort...)
+	for range all {
+		items, pageTotal, err := get(query)
+		if err != nil {
+			t.Fatalf("%s: page behind %v error = %v", what, query.After, err)
+		}
+		if pageTotal != total {
+			t.Errorf("%s: page total = %d, want %d", what, pageTotal, total)
+		}
+		got = append(got, keyIDs(items)...)
+		if len(items) < query.Limit {
+			break
+		}
+		query.After = entities.SortKeys(items[len(items)-1], sort)
+	}
+	assertIDs(t, what, got, keyIDs(all))
+}
+
+func testCursorPagination(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	keanu := createActor(t, repo, "Keanu Reeves", "male", date(1964, 9, 2))
+	createActor(t, repo, "Laurence Fishburne", "male", date(1961, 7, 30))
+	createActor(t, repo, "Carrie-Anne Moss", "female", date(1967, 8, 21))
+	createActor(t, repo, "Hugo Weaving", "male", date(1960, 4, 4))
+	createActor(t, repo, "Keanu Reeves", "male", date(1964, 9, 2))
+
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7)
+	reloaded := createFilm(t, repo, "The Matrix Reloaded", date(2003, 5, 15), 7.2)
+	revolutions := createFilm(t, repo, "The Matrix Revolutions", date(2003, 11, 5), 6.8)
+	wick := createFilm(t, repo, "John Wick", date(2014, 10, 24), 7.2)
+	speed := createFilm(t, repo, "Speed", date(1994, 6, 10), 7.2)
+	createFilm(t, repo, "Speed", date(1994, 6, 10), 7.2)
+
+	alice := createUser(t, repo, "alice", entities.User)
+	bob := createUser(t, repo, "bob", entities.User)
+	carol := createUser(t, repo, "carol", entities.Admin)
+	createUser(t, repo, "dave", entities.User)
+
+	for _, film := range []entities.FilmEntity{matrix, reloaded, revolutions, wick, speed} {
+		createReview(t, repo, film, alice, entities.ReviewPublished, date(2024, 1, 1))
+		createReview(t, repo, film, bob, entities.ReviewPending, date(2024, 1, 2))
+		createHistoryEntry(t, repo, alice, film, date(2024, 2, 1), nil)
+		createHistoryEntry(t, repo, alice, film, date(2024, 2, 2), ratingOf(7))
+		addToWatchlist(t, repo, alice, film, 0, date(2024, 1, 1))
+		rateFilm(t, repo, film, alice, 7, date(2024, 3, 1))
+		rateFilm(t, repo, film, carol, 8, date(2024, 3, 2))
+	}
+	rateFilm(t, repo, matrix, alice, 9, date(2024, 3, 1))
+
+	for _, sort := range [][]entities.SortField{nil, {asc("title")}, {desc("rating"), asc("release_date")}, {desc("release_date"), desc("title")}} {
+		assertPages(t, fmt.Sprintf("GetFilms(%v)", sort), sort, func(query entities.ListQuery) ([]entities.FilmEntity, int, error) {
+			return repo.GetFilms(ctx, entities.FilmQuery{ListQuery: query})
+		})
+	}
+	for _, sort := range [][]entities.SortField{{asc("name")}, {asc("gender"), desc("birthday")}} {
+		assertPages(t, fmt.Sprintf("GetActors(%v)", sort), sort, func(query entities.ListQuery) ([]entities.ActorEntity, int, error) {
+			return repo.GetActors(ctx, entities.ActorQuery{ListQuery: query, Gender: keanu.Gender})
+		})
+	}
+	for _, sort := range [][]entities.SortField{{desc("role")}, {asc("role"), desc("username")}} {
+		assertPages(t, fmt.Sprintf("GetUsers(%v)", sort), sort, func(query entities.ListQuery) ([]entities.UserEntity, int, error) {
+			return repo.GetUsers(ctx, entities.UserQuery{ListQuery: query})
+		})
+	}
+	for _, sort := range [][]entities.SortField{{asc("created_at")}, {desc("updated_at")}} {
+		assertPages(t, fmt.Sprintf("GetReviews(%v)", sort), sort, func(query entities.ListQuery) ([]entities.ReviewEntity, int, error) {
+			return repo.GetReviews(ctx, entities.ReviewQuery{ListQuery: query})
+		})
+	}
+	for _, sort := range [][]entities.SortField{{asc("position")}, {desc("added_at"), asc("title")}, {desc("release_date")}} {
+		assertPages(t, fmt.Sprintf("GetWatchlist(%v)", sort), sort, func(query entities.ListQuery) ([]entities.WatchlistEntry, int, error) {
+			return repo.GetWatchlist(ctx, entities.WatchQuery{ListQuery: query, UserID: alice.ID})
+		})
+	}
+	for _, sort := range [][]entities.SortField{{desc("watched_at")}, {asc("rating"), asc("title")}, {desc("rating")}} {
+		assertPages(t, fmt.Sprintf("GetHistory(%v)", sort), sort, func(query entities.ListQuery) ([]entities.HistoryEntry, int, error) {
+			return repo.GetHistory(ctx, entities.WatchQuery{ListQuery: query, UserID: alice.ID})
+		})
+	}
+	assertPages(t, "GetRatingHistory()", entities.RatingHistorySort, func(query entities.ListQuery) ([]entities.FilmRating, int, error) {
+		return repo.GetRatingHistory(ctx, entities.RatingQuery{ListQuery: query, UserID: alice.ID})
+	})
+
+	// A film added in front of the cursor does not move the next page, an
+	// offset would repeat the last film of the page before.
+	sort := []entities.SortField{asc("title")}
+	before, _, err := repo.GetFilms(ctx, entities.FilmQuery{ListQuery: page(4, 0, sort...)})
+	if err != nil {
+		t.Fatalf("GetFilms() error = %v", err)
+	}
+	first := before[:2]
+	createFilm(t, repo, "Constantine", date(2005, 2, 18), 7)
+	next := page(2, 2, sort...)
+	next.After = entities.SortKeys(first[len(first)-1], sort)
+	second, total, err := repo.GetFilms(ctx, entities.FilmQuery{ListQuery: next})
+	if err != nil {
+		t.Fatalf("GetFilms(behind %v) error = %v", next.After, err)
+	}
+	assertIDs(t, "GetFilms(behind the cursor)", filmIDs(second), filmIDs(before[2:]))
+	if total != 7 {
+		t.Errorf("GetFilms(behind the cursor) total = %d, want 7", total)
+	}
+}
@@ -0,0 +1,205 @@
+package repotest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"filmography/internal/entities"
+	"filmography/service"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testExport(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	keanu := createActor(t, repo, "Keanu Reeves", "male", date(1964, 9, 2))
+	carrie := createActor(t, repo, "Carrie-Anne Moss", "female", date(1967, 8, 21))
+	loner := createActor(t, repo, "Laurence Fishburne", "male", date(1961, 7, 30))
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7, keanu, carrie)
+	wick := createFilm(t, repo, "John Wick", date(2014, 10, 24), 7.4, keanu)
+	empty := createFilm(t, repo, "Empty", date(2000, 1, 1), 1)
+
+	films := make([]entities.FilmEntity, 0)
+	err := repo.ExportFilms(ctx, func(film entities.CreditedFilm) error {
+		films = append(films, film.FilmEntity)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportFilms() error = %v", err)
+	}
+	assertIDs(t, "ExportFilms() ordered by ID", filmIDs(films), byID(matrix.ID, wick.ID, empty.ID))
+	for _, film := range films {
+		switch film.ID {
+		case matrix.ID:
+			assertFilm(t, film, matrix)
+			assertIDs(t, "cast of The Matrix", actorIDs(film.Actors), []string{carrie.ID, keanu.ID})
+		case wick.ID:
+			assertIDs(t, "cast of John Wick", actorIDs(film.Actors), []string{keanu.ID})
+		case empty.ID:
+			if film.Actors == nil || len(film.Actors) != 0 {
+				t.Errorf("cast of a film without actors = %v, want empty", film.Actors)
+			}
+		}
+	}
+
+	actors := make([]entities.ActorEntity, 0)
+	err = repo.ExportActors(ctx, func(actor entities.ActorEntity) error {
+		actors = append(actors, actor)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportActors() error = %v", err)
+	}
+	assertIDs(t, "ExportActors() ordered by ID", actorIDs(actors), byID(keanu.ID, carrie.ID, loner.ID))
+
+	// The exports hold no connection while the callback writes, so that a
+	// slow client does not block the other requests of a store with a
+	// single connection.
+	readDuring := func() error {
+		readCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		_, err := repo.GetActor(readCtx, keanu.ID)
+		return err
+	}
+	err = repo.ExportActors(ctx, func(entities.ActorEntity) error {
+		return readDuring()
+	})
+	if err != nil {
+		t.Errorf("ExportActors() reading in the callback error = %v", err)
+	}
+	err = repo.ExportFilms(ctx, func(entities.CreditedFilm) error {
+		return readDuring()
+	})
+	if err != nil {
+		t.Errorf("ExportFilms() reading in the callback error = %v", err)
+	}
+
+	// An error of the callback stops the export and is returned as is.
+	stop := errors.New("stop")
+	calls := 0
+	err = repo.ExportFilms(ctx, func(film entities.CreditedFilm) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("ExportFilms() with failing callback = %v after %d calls, want stop after 1", err, calls)
+	}
+}
+
+// catalogue describes every film and person of repo by value, so that
+// stores holding the same catalogue under other IDs of people and genres
+// compare equal.
+func catalogue(t *testing.T, repo service.Repo) []string {
+	t.Helper()
+	ctx := context.Background()
+	keys := make([]string, 0)
+
+	films, _, err := repo.GetFilms(ctx, entities.FilmQuery{ListQuery: page(100, 0, asc("title"))})
+	if err != nil {
+		t.Fatalf("GetFilms() error = %v", err)
+	}
+	for _, film := range films {
+		cast := make([]string, 0, len(film.Actors))
+		for _, actor := range film.Actors {
+			cast = append(cast, actor.Name)
+		}
+		genres := make([]string, 0, len(film.Genres))
+		for _, genre := range film.Genres {
+			genres = append(genres, genre.Name)
+		}
+		keys = append(keys, fmt.Sprintf("film %s %q %q %s %v cast=%v credits=%v genres=%v tags=%v",
+			film.ID, film.Title, film.Description, film.ReleaseDate.UTC().Format(time.DateOnly), film.Rating,
+			cast, creditKeys(film.Credits), genres, film.Tags))
+	}
+
+	people, _, err := repo.GetActors(ctx, entities.ActorQuery{ListQuery: page(100, 0, asc("name"))})
+	if err != nil {
+		t.Fatalf("GetActors() error = %v", err)
+	}
+	for _, person := range people {
+		keys = append(keys, fmt.Sprintf("person %q %q %s", person.Name, person.Gender, person.Birthday.UTC().Format(time.DateOnly)))
+	}
+	return keys
+}
+
+// clearCatalogue deletes every film, person and genre of repo.
+func clearCatalogue(t *testing.T, repo service.Repo) {
+	t.Helper()
+	ctx := context.Background()
+
+	films, _, err := repo.GetFilms(ctx, entities.FilmQuery{ListQuery: page(100, 0)})
+	if err != nil {
+		t.Fatalf("GetFilms() error = %v", err)
+	}
+	for _, film := range films {
+		if err := repo.DeleteFilm(ctx, film.ID, entities.AnyVersion); err != nil {
+			t.Fatalf("DeleteFilm() error = %v", err)
+		}
+	}
+	people, _, err := repo.GetActors(ctx, entities.ActorQuery{ListQuery: page(100, 0)})
+	if err != nil {
+		t.Fatalf("GetActors() error = %v", err)
+	}
+	for _, person := range people {
+		if err := repo.DeleteActor(ctx, person.ID, entities.AnyVersion); err != nil {
+			t.Fatalf("DeleteActor() error = %v", err)
+		}
+	}
+	genres, err := repo.GetGenreCounts(ctx, entities.FilmQuery{})
+	if err != nil {
+		t.Fatalf("GetGenreCounts() error = %v", err)
+	}
+	for _, genre := range genres {
+		if err := repo.DeleteGenre(ctx, genre.ID); err != nil {
+			t.Fatalf("DeleteGenre() error = %v", err)
+		}
+	}
+}
+
+// testExportImport exports the films in every format, imports the file
+// into the emptied store and expects the catalogue it started with.
+func testExportImport(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	mann := createActor(t, repo, "Michael Mann", "male", date(1943, 2, 5))
+	pacino := createActor(t, repo, "Al Pacino", "male", date(1940, 4, 25))
+	deniro := createActor(t, repo, "Robert De Niro", "male", date(1943, 8, 17))
+	crime := createGenre(t, repo, "Crime")
+	drama := createGenre(t, repo, "Drama")
+	createCreditedFilm(t, repo, "Heat", entities.FilmEntity{
+		Description: `A thief, a cop and "the job", in L.A.`,
+		ReleaseDate: date(1995, 12, 15),
+		Rating:      8.3,
+		Genres:      []entities.GenreEntity{crime, drama},
+		Tags:        []string{"heist", "los angeles"},
+	},
+		credit(mann, entities.CreditDirector),
+		credit(mann, entities.CreditWriter),
+		billed(pacino, "Vincent Hanna", 1),
+		billed(deniro, "Neil McCauley", 2),
+	)
+	createFilm(t, repo, "Empty", date(2000, 1, 1), 1)
+	want := catalogue(t, repo)
+
+	for _, format := range []service.FileFormat{service.FormatJSON, service.FormatJSONLines, service.FormatCSV} {
+		file := bytes.Buffer{}
+		err := service.NewExportService(repo).Export(ctx, &file, service.ExportOptions{Format: format, Entity: service.ExportFilms})
+		if err != nil {
+			t.Fatalf("Export(%s) error = %v", format, err)
+		}
+		clearCatalogue(t, repo)
+
+		report, err := service.NewImportService(repo).Import(ctx, &file, service.ImportOptions{Format: format})
+		if err != nil {
+			t.Fatalf("Import(%s) error = %v", format, err)
+		}
+		if report.Imported != 2 || report.Failed != 0 {
+			t.Fatalf("Import(%s) report = %+v, want 2 films imported", format, report)
+		}
+		if got := catalogue(t, repo); !slices.Equal(got, want) {
+			t.Errorf("catalogue after the %s round trip =\n%s\nwant\n%s", format, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package repotest
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"filmography/service"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func testFilmRoundTrip(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	keanu := createActor(t, repo, "Keanu Reeves", "male", date(1964, 9, 2))
+	carrie := createActor(t, repo, "Carrie-Anne Moss", "female", date(1967, 8, 21))
+	laurence := createActor(t, repo, "Laurence Fishburne", "male", date(1961, 7, 30))
+
+	// The same actor twice is linked once.
+	film := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7, keanu, carrie, keanu)
+
+	got, err := repo.GetFilm(ctx, film.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() error = %v", err)
+	}
+	assertFilm(t, got, film)
+	assertIDs(t, "cast ordered by name", actorIDs(got.Actors), []string{carrie.ID, keanu.ID})
+	assertActor(t, got.Actors[1], keanu)
+
+	cast, err := repo.GetActorsByFilm(ctx, film.ID)
+	if err != nil {
+		t.Fatalf("GetActorsByFilm() error = %v", err)
+	}
+	assertIDs(t, "GetActorsByFilm()", actorIDs(cast), []string{carrie.ID, keanu.ID})
+
+	film.Title = "The Matrix Reloaded"
+	film.Description = "Sequel"
+	film.ReleaseDate = date(2003, 5, 15)
+	film.Rating = 7.2
+	film.Actors = []entities.ActorEntity{laurence, keanu}
+	if err := repo.UpdateFilm(ctx, film.ID, film); err != nil {
+		t.Fatalf("UpdateFilm() error = %v", err)
+	}
+	got, err = repo.GetFilm(ctx, film.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() after update error = %v", err)
+	}
+	assertFilm(t, got, film)
+	assertIDs(t, "cast after update", actorIDs(got.Actors), []string{keanu.ID, laurence.ID})
+
+	film.Actors = nil
+	if err := repo.UpdateFilm(ctx, film.ID, film); err != nil {
+		t.Fatalf("UpdateFilm() without cast error = %v", err)
+	}
+	got, err = repo.GetFilm(ctx, film.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() without cast error = %v", err)
+	}
+	if len(got.Actors) != 0 {
+		t.Errorf("cast after clearing = %v, want none", actorIDs(got.Actors))
+	}
+
+	if err := repo.DeleteFilm(ctx, film.ID, entities.AnyVersion); err != nil {
+		t.Fatalf("DeleteFilm() error = %v", err)
+	}
+	_, err = repo.GetFilm(ctx, film.ID)
+	wantErr(t, "GetFilm() after delete", err, entities.ErrNotFound)
+}
+
+func testFilmNotFound(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	id := uuid.NewString()
+
+	_, err := repo.GetFilm(ctx, id)
+	wantErr(t, "GetFilm()", err, entities.ErrNotFound)
+
+	err = repo.UpdateFilm(ctx, id, entities.FilmEntity{Title: "Nothing"})
+	wantErr(t, "UpdateFilm()", err, entities.ErrNotFound)
+
+	err = repo.DeleteFilm(ctx, id, entities.AnyVersion)
+	wantErr(t, "DeleteFilm()", err, entities.ErrNotFound)
+
+	_, err = repo.GetActorsByFilm(ctx, id)
+	wantErr(t, "GetActorsByFilm()", err, entities.ErrNotFound)
+}
+
+func testFilmUnknownActor(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	keanu := createActor(t, repo, "Keanu Reeves", "male", date(1964, 9, 2))
+	ghost := entities.ActorEntity{ID: uuid.NewString()}
+
+	film := entities.FilmEntity{ID: uuid.NewString(), Title: "Ghost", ReleaseDate: date(2000, 1, 1), Actors: []entities.ActorEntity{keanu, ghost}}
+	err := repo.CreateFilm(ctx, film)
+	wantErr(t, "CreateFilm()", err, entities.ErrUnknownActor)
+	wantErr(t, "CreateFilm()", err, entities.ErrInvalidReference)
+
+	// The failed create must not leave the film behind.
+	_, err = repo.GetFilm(ctx, film.ID)
+	wantErr(t, "GetFilm() after failed create", err, entities.ErrNotFound)
+
+	stored := createFilm(t, repo, "Speed", date(1994, 6, 10), 7.3, keanu)
+	changed := stored
+	changed.Title = "Speed 2"
+	changed.Actors = []entities.ActorEntity{ghost}
+	err = repo.UpdateFilm(ctx, stored.ID, changed)
+	wantErr(t, "UpdateFilm()", err, entities.ErrUnknownActor)
+
+	// The failed update must leave the film and its cast as they were.
+	got, err := repo.GetFilm(ctx, stored.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() after failed update error = %v", err)
+	}
+	assertFilm(t, got, stored)
+	assertIDs(t, "cast after failed update", actorIDs(got.Actors), []string{keanu.ID})
+}
+
+func testFilmography(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	keanu := createActor(t, repo, "Keanu Reeves", "male", date(1964, 9, 2))
+	carrie := createActor(t, repo, "Carrie-Anne Moss", "female", date(1967, 8, 21))
+
+	wick := createFilm(t, repo, "John Wick", date(2014, 10, 24), 7.4, keanu)
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7, keanu, carrie)
+	speed := createFilm(t, repo, "Speed", date(1994, 6, 10), 7.3, keanu)
+	createFilm(t, repo, "Memento", date(2000, 9, 5), 8.4, carrie)
+
+	films, err := repo.GetFilmsByActor(ctx, keanu.ID)
+	if err != nil {
+		t.Fatalf("GetFilmsByActor() error = %v", err)
+	}
+	assertIDs(t, "filmography by release date", filmIDs(films), []string{speed.ID, matrix.ID, wick.ID})
+	assertFilm(t, films[1], matrix)
+	assertIDs(t, "full cast in filmography", actorIDs(films[1].Actors), []string{carrie.ID, keanu.ID})
+
+	nobody := createActor(t, repo, "Nobody", "", date(1990, 1, 1))
+	films, err = repo.GetFilmsByActor(ctx, nobody.ID)
+	if err != nil {
+		t.Fatalf("GetFilmsByActor() without films error = %v", err)
+	}
+	if len(films) != 0 {
+		t.Errorf("filmography of an actor without films = %v, want none", filmIDs(films))
+	}
+}
+
+func testCascadeDeleteActor(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	keanu := createActor(t, repo, "Keanu Reeves", "male", date(1964, 9, 2))
+	carrie := createActor(t, repo, "Carrie-Anne Moss", "female", date(1967, 8, 21))
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7, keanu, carrie)
+
+	if err := repo.DeleteActor(ctx, carrie.ID, entities.AnyVersion); err != nil {
+		t.Fatalf("DeleteActor() error = %v", err)
+	}
+
+	got, err := repo.GetFilm(ctx, matrix.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() error = %v", err)
+	}
+	assertIDs(t, "cast after deleting an actor", actorIDs(got.Actors), []string{keanu.ID})
+}
+
+func testCascadeDeleteFilm(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	keanu := createActor(t, repo, "Keanu Reeves", "male", date(1964, 9, 2))
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7, keanu)
+	wick := createFilm(t, repo, "John Wick", date(2014, 10, 24), 7.4, keanu)
+
+	if err := repo.DeleteFilm(ctx, matrix.ID, entities.AnyVersion); err != nil {
+		t.Fatalf("DeleteFilm() error = %v", err)
+	}
+
+	films, err := repo.GetFilmsByActor(ctx, keanu.ID)
+	if err != nil {
+		t.Fatalf("GetFilmsByActor() error = %v", err)
+	}
+	assertIDs(t, "filmography after deleting a film", filmIDs(films), []string{wick.ID})
+
+	// A film with the deleted ID can be created again.
+	matrix.Actors = []entities.ActorEntity{keanu}
+	if err := repo.CreateFilm(ctx, matrix); err != nil {
+		t.Fatalf("CreateFilm() with a reused ID error = %v", err)
+	}
+}
+
+func testFilmPagination(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	// Two films share every sort key, the ID has to break the tie.
+	a := createFilm(t, repo, "Alien", date(1979, 5, 25), 8.5)
+	b := createFilm(t, repo, "Brazil", date(1985, 2, 20), 7.9)
+	c := createFilm(t, repo, "Casablanca", date(1942, 11, 26), 8.5)
+	twin1 := createFilm(t, repo, "Duel", date(1971, 11, 13), 7.6)
+	twin2 := createFilm(t, repo, "Duel", date(1971, 11, 13), 7.6)
+	twins := byID(twin1.ID, twin2.ID)
+
+	tests := []struct {
+		name  string
+		query entities.ListQuery
+		want  []string
+	}{
+		{"default order is by ID", page(10, 0), byID(a.ID, b.ID, c.ID, twin1.ID, twin2.ID)},
+		{"title", page(10, 0, asc("title")), []string{a.ID, b.ID, c.ID, twins[0], twins[1]}},
+		{"descending rating then release date", page(10, 0, desc("rating"), asc("release_date")), []string{c.ID, a.ID, b.ID, twins[0], twins[1]}},
+		{"first page", page(2, 0, asc("release_date")), []string{c.ID, twins[0]}},
+		{"second page", page(2, 2, asc("release_date")), []string{twins[1], a.ID}},
+		{"last page", page(2, 4, asc("release_date")), []string{b.ID}},
+		{"past the end", page(2, 10, asc("release_date")), []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			films, total, err := repo.GetFilms(ctx, entities.FilmQuery{ListQuery: tt.query})
+			if err != nil {
+				t.Fatalf("GetFilms() error = %v", err)
+			}
+			if total != 5 {
+				t.Errorf("total = %d, want 5", total)
+			}
+			assertIDs(t, "films", filmIDs(films), tt.want)
+		})
+	}
+}
+
+func testFilmFilters(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	keanu := createActor(t, repo, "Keanu Reeves", "male", date(1964, 9, 2))
+	carrie := createActor(t, repo, "Carrie-Anne Moss", "female", date(1967, 8, 21))
+	speed := createFilm(t, repo, "Speed", date(1994, 6, 10), 7.3, keanu)
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7, keanu, carrie)
+	wick := createFilm(t, repo, "John Wick", date(2014, 10, 24), 7.4, keanu)
+
+	minRating := 7.4
+	releasedAfter := date(1999, 3, 31)
+
+	tests := []struct {
+		name      string
+		query     entities.FilmQuery
+		wantIDs   []string
+		wantTotal int
+	}{
+		{"min rating is inclusive", entities.FilmQuery{ListQuery: page(10, 0, asc("release_date")), MinRating: &minRating}, []string{matrix.ID, wick.ID}, 2},
+		{"released after is exclusive", entities.FilmQuery{ListQuery: page(10, 0, asc("release_date")), ReleasedAfter: &releasedAfter}, []string{wick.ID}, 1},
+		{"filters are combined", entities.FilmQuery{ListQuery: page(10, 0), MinRating: &minRating, ReleasedAfter: &releasedAfter}, []string{wick.ID}, 1},
+		{"total ignores the page", entities.FilmQuery{ListQuery: page(1, 0, asc("rating"))}, []string{speed.ID}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			films, total, err := repo.GetFilms(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("GetFilms() error = %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", total, tt.wantTotal)
+			}
+			assertIDs(t, "films", filmIDs(films), tt.wantIDs)
+		})
+	}
+
+	films, _, err := repo.GetFilms(ctx, entities.FilmQuery{ListQuery: page(10, 0, desc("rating"))})
+	if err != nil {
+		t.Fatalf("GetFilms() error = %v", err)
+	}
+	assertIDs(t, "cast in list", actorIDs(films[0].Actors), []string{carrie.ID, keanu.ID})
+}
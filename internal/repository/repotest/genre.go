@@ -0,0 +1,265 @@
+package repotest
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"filmography/service"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func createGenre(t *testing.T, repo service.Repo, name string) entities.GenreEntity {
+	t.Helper()
+	genre := entities.GenreEntity{ID: uuid.NewString(), Name: name}
+	if err := repo.CreateGenre(context.Background(), genre); err != nil {
+		t.Fatalf("CreateGenre(%q) error = %v", name, err)
+	}
+	return genre
+}
+
+// createTaggedFilm creates a film in genres with tags.
+func createTaggedFilm(t *testing.T, repo service.Repo, title string, genres []entities.GenreEntity, tags ...string) entities.FilmEntity {
+	t.Helper()
+	film := entities.FilmEntity{ID: uuid.NewString(), Title: title, ReleaseDate: date(2000, 1, 1), Genres: genres, Tags: tags}
+	if err := repo.CreateFilm(context.Background(), film); err != nil {
+		t.Fatalf("CreateFilm(%q) error = %v", title, err)
+	}
+	return film
+}
+
+func genreIDs(genres []entities.GenreEntity) []string {
+	ids := make([]string, 0, len(genres))
+	for _, genre := range genres {
+		ids = append(ids, genre.ID)
+	}
+	return ids
+}
+
+func genreCounts(t *testing.T, repo service.Repo, query entities.FilmQuery) map[string]int {
+	t.Helper()
+	counts, err := repo.GetGenreCounts(context.Background(), query)
+	if err != nil {
+		t.Fatalf("GetGenreCounts() error = %v", err)
+	}
+	films := make(map[string]int, len(counts))
+	for _, count := range counts {
+		films[count.Name] = count.Films
+	}
+	return films
+}
+
+func testGenreRoundTrip(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	drama := createGenre(t, repo, "Drama")
+	crime := createGenre(t, repo, "Crime")
+	heat := createTaggedFilm(t, repo, "Heat", []entities.GenreEntity{drama, crime})
+
+	got, err := repo.GetGenre(ctx, drama.ID)
+	if err != nil {
+		t.Fatalf("GetGenre() error = %v", err)
+	}
+	if got != drama {
+		t.Errorf("GetGenre() = %+v, want %+v", got, drama)
+	}
+
+	// Names are unique regardless of case.
+	err = repo.CreateGenre(ctx, entities.GenreEntity{ID: uuid.NewString(), Name: "DRAMA"})
+	wantErr(t, "CreateGenre(taken name)", err, entities.ErrConflict)
+	err = repo.UpdateGenre(ctx, crime.ID, entities.GenreEntity{Name: "drama"})
+	wantErr(t, "UpdateGenre(taken name)", err, entities.ErrConflict)
+
+	drama.Name = "Melodrama"
+	if err := repo.UpdateGenre(ctx, drama.ID, drama); err != nil {
+		t.Fatalf("UpdateGenre() error = %v", err)
+	}
+	film, err := repo.GetFilm(ctx, heat.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() error = %v", err)
+	}
+	if !slices.Equal(film.Genres, []entities.GenreEntity{crime, drama}) {
+		t.Errorf("genres after rename = %+v, want crime and the renamed drama", film.Genres)
+	}
+
+	if err := repo.DeleteGenre(ctx, crime.ID); err != nil {
+		t.Fatalf("DeleteGenre() error = %v", err)
+	}
+	film, err = repo.GetFilm(ctx, heat.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() after deleting a genre error = %v", err)
+	}
+	assertIDs(t, "genres after deleting one", genreIDs(film.Genres), []string{drama.ID})
+
+	missing := uuid.NewString()
+	_, err = repo.GetGenre(ctx, missing)
+	wantErr(t, "GetGenre(missing)", err, entities.ErrNotFound)
+	err = repo.UpdateGenre(ctx, missing, entities.GenreEntity{Name: "Noir"})
+	wantErr(t, "UpdateGenre(missing)", err, entities.ErrNotFound)
+	err = repo.DeleteGenre(ctx, crime.ID)
+	wantErr(t, "DeleteGenre(deleted)", err, entities.ErrNotFound)
+}
+
+func testFilmTaxonomy(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	drama := createGenre(t, repo, "Drama")
+	crime := createGenre(t, repo, "Crime")
+	keanu := createActor(t, repo, "Keanu Reeves", "male", date(1964, 9, 2))
+
+	// The same genre or tag twice is stored once.
+	film := entities.FilmEntity{ID: uuid.NewString(), Title: "John Wick", ReleaseDate: date(2014, 10, 24), Actors: []entities.ActorEntity{keanu},
+		Genres: []entities.GenreEntity{drama, crime, drama}, Tags: []string{"revenge", "dog", "revenge"}}
+	if err := repo.CreateFilm(ctx, film); err != nil {
+		t.Fatalf("CreateFilm() error = %v", err)
+	}
+	got, err := repo.GetFilm(ctx, film.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() error = %v", err)
+	}
+	assertIDs(t, "genres ordered by name", genreIDs(got.Genres), []string{crime.ID, drama.ID})
+	assertIDs(t, "tags ordered", got.Tags, []string{"dog", "revenge"})
+
+	films, err := repo.GetFilmsByActor(ctx, keanu.ID)
+	if err != nil {
+		t.Fatalf("GetFilmsByActor() error = %v", err)
+	}
+	assertIDs(t, "genres in filmography", genreIDs(films[0].Genres), []string{crime.ID, drama.ID})
+
+	film.Genres = []entities.GenreEntity{drama}
+	film.Tags = []string{"hitman"}
+	if err := repo.UpdateFilm(ctx, film.ID, film); err != nil {
+		t.Fatalf("UpdateFilm() error = %v", err)
+	}
+	got, err = repo.GetFilm(ctx, film.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() after update error = %v", err)
+	}
+	assertIDs(t, "genres after update", genreIDs(got.Genres), []string{drama.ID})
+	assertIDs(t, "tags after update", got.Tags, []string{"hitman"})
+
+	// An unknown genre rejects the whole change.
+	unknown := entities.GenreEntity{ID: uuid.NewString()}
+	changed := film
+	changed.Title = "John Wick 2"
+	changed.Genres = []entities.GenreEntity{crime, unknown}
+	err = repo.UpdateFilm(ctx, film.ID, changed)
+	wantErr(t, "UpdateFilm(unknown genre)", err, entities.ErrUnknownGenre)
+	got, err = repo.GetFilm(ctx, film.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() after failed update error = %v", err)
+	}
+	assertFilm(t, got, film)
+	assertIDs(t, "genres after failed update", genreIDs(got.Genres), []string{drama.ID})
+
+	err = repo.CreateFilm(ctx, entities.FilmEntity{ID: uuid.NewString(), Title: "Heat", Genres: []entities.GenreEntity{unknown}})
+	wantErr(t, "CreateFilm(unknown genre)", err, entities.ErrUnknownGenre)
+	_, total, err := repo.GetFilms(ctx, entities.FilmQuery{ListQuery: page(10, 0)})
+	if err != nil {
+		t.Fatalf("GetFilms() error = %v", err)
+	}
+	if total != 1 {
+		t.Errorf("films after failed create = %d, want 1", total)
+	}
+}
+
+func testFilmTaxonomyFilters(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	drama := createGenre(t, repo, "Drama")
+	crime := createGenre(t, repo, "Crime")
+	comedy := createGenre(t, repo, "Comedy")
+	heat := createTaggedFilm(t, repo, "Heat", []entities.GenreEntity{drama, crime}, "heist", "la")
+	fargo := createTaggedFilm(t, repo, "Fargo", []entities.GenreEntity{crime, comedy}, "snow")
+	drive := createTaggedFilm(t, repo, "Drive", []entities.GenreEntity{drama}, "la", "cars")
+	createTaggedFilm(t, repo, "Untitled", nil)
+
+	tests := []struct {
+		name    string
+		query   entities.FilmQuery
+		wantIDs []string
+	}{
+		{"any genre", entities.FilmQuery{Genres: []string{drama.ID, comedy.ID}}, []string{drive.ID, fargo.ID, heat.ID}},
+		{"all genres", entities.FilmQuery{Genres: []string{drama.ID, crime.ID}, MatchAll: true}, []string{heat.ID}},
+		{"any tag", entities.FilmQuery{Tags: []string{"snow", "cars"}}, []string{drive.ID, fargo.ID}},
+		{"all tags", entities.FilmQuery{Tags: []string{"la", "heist"}, MatchAll: true}, []string{heat.ID}},
+		{"genres and tags are combined", entities.FilmQuery{Genres: []string{crime.ID}, Tags: []string{"la"}}, []string{heat.ID}},
+		{"no film has all", entities.FilmQuery{Genres: []string{drama.ID, comedy.ID}, MatchAll: true}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.ListQuery = page(10, 0, asc("title"))
+			films, total, err := repo.GetFilms(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("GetFilms() error = %v", err)
+			}
+			if total != len(tt.wantIDs) {
+				t.Errorf("total = %d, want %d", total, len(tt.wantIDs))
+			}
+			assertIDs(t, "films", filmIDs(films), tt.wantIDs)
+		})
+	}
+
+	counts, err := repo.GetGenreCounts(ctx, entities.FilmQuery{})
+	if err != nil {
+		t.Fatalf("GetGenreCounts() error = %v", err)
+	}
+	want := []entities.GenreCount{{GenreEntity: comedy, Films: 1}, {GenreEntity: crime, Films: 2}, {GenreEntity: drama, Films: 2}}
+	if !slices.Equal(counts, want) {
+		t.Errorf("GetGenreCounts() = %+v, want %+v", counts, want)
+	}
+
+	// Genres without a matching film are counted as zero.
+	got := genreCounts(t, repo, entities.FilmQuery{Tags: []string{"la"}})
+	if got["Drama"] != 2 || got["Crime"] != 1 || got["Comedy"] != 0 || len(got) != 3 {
+		t.Errorf("GetGenreCounts(tag la) = %v, want Drama 2, Crime 1, Comedy 0", got)
+	}
+
+	tags, err := repo.GetTagCounts(ctx, entities.FilmQuery{}, 2)
+	if err != nil {
+		t.Fatalf("GetTagCounts() error = %v", err)
+	}
+	wantTags := []entities.TagCount{{Tag: "la", Films: 2}, {Tag: "cars", Films: 1}}
+	if !slices.Equal(tags, wantTags) {
+		t.Errorf("GetTagCounts(limit 2) = %+v, want %+v", tags, wantTags)
+	}
+	tags, err = repo.GetTagCounts(ctx, entities.FilmQuery{Genres: []string{comedy.ID}}, 10)
+	if err != nil {
+		t.Fatalf("GetTagCounts() error = %v", err)
+	}
+	wantTags = []entities.TagCount{{Tag: "snow", Films: 1}}
+	if !slices.Equal(tags, wantTags) {
+		t.Errorf("GetTagCounts(genre comedy) = %+v, want %+v", tags, wantTags)
+	}
+}
+
+func testCascadeDeleteTaxonomy(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	drama := createGenre(t, repo, "Drama")
+	heat := createTaggedFilm(t, repo, "Heat", []entities.GenreEntity{drama}, "heist")
+	drive := createTaggedFilm(t, repo, "Drive", []entities.GenreEntity{drama}, "heist")
+
+	if err := repo.DeleteFilm(ctx, heat.ID, entities.AnyVersion); err != nil {
+		t.Fatalf("DeleteFilm() error = %v", err)
+	}
+	if got := genreCounts(t, repo, entities.FilmQuery{}); got["Drama"] != 1 {
+		t.Errorf("films of the genre after deleting a film = %d, want 1", got["Drama"])
+	}
+	tags, err := repo.GetTagCounts(ctx, entities.FilmQuery{}, 10)
+	if err != nil {
+		t.Fatalf("GetTagCounts() error = %v", err)
+	}
+	if !slices.Equal(tags, []entities.TagCount{{Tag: "heist", Films: 1}}) {
+		t.Errorf("tags after deleting a film = %+v, want heist once", tags)
+	}
+
+	if err := repo.DeleteGenre(ctx, drama.ID); err != nil {
+		t.Fatalf("DeleteGenre() error = %v", err)
+	}
+	film, err := repo.GetFilm(ctx, drive.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() after deleting its genre error = %v", err)
+	}
+	if len(film.Genres) != 0 || !slices.Equal(film.Tags, []string{"heist"}) {
+		t.Errorf("film after deleting its genre = %+v, want no genres and its tag", film)
+	}
+}
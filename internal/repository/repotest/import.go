@@ -0,0 +1,144 @@
+package repotest
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"filmography/service"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// importedFilm is a film of a file without credits, genres and tags.
+func importedFilm(title string, cast ...entities.ActorEntity) entities.CreditedFilm {
+	return entities.CreditedFilm{FilmEntity: entities.FilmEntity{ID: uuid.NewString(), Title: title, ReleaseDate: date(1999, 3, 31), Rating: 8, Actors: cast}}
+}
+
+func importedActor(name string, gender string, birthday time.Time) entities.ActorEntity {
+	return entities.ActorEntity{ID: uuid.NewString(), Name: name, Gender: gender, Birthday: birthday}
+}
+
+func testImportFilms(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	keanu := createActor(t, repo, "Keanu Reeves", "", date(1964, 9, 2))
+	stored := createFilm(t, repo, "Matrix", date(1999, 1, 1), 5)
+
+	// Keanu is matched by name and birthday and gets a gender, the Keanu
+	// born on another day is someone else. Carrie is created once although
+	// two films list her.
+	carrie := importedActor("Carrie-Anne Moss", "female", date(1967, 8, 21))
+	otherKeanu := importedActor("Keanu Reeves", "male", date(1990, 1, 1))
+	replaced := importedFilm("The Matrix", importedActor("Keanu Reeves", "male", date(1964, 9, 2)), carrie)
+	replaced.ID = stored.ID
+	sequel := importedFilm("The Matrix Reloaded", importedActor("Carrie-Anne Moss", "", date(1967, 8, 21)), otherKeanu)
+	// The ID of Keanu cannot be reused for a different actor.
+	broken := importedFilm("Broken", entities.ActorEntity{ID: keanu.ID, Name: "Impostor"})
+
+	errs, err := repo.ImportFilms(ctx, []entities.CreditedFilm{replaced, broken, sequel}, false)
+	if err != nil {
+		t.Fatalf("ImportFilms() error = %v", err)
+	}
+	if len(errs) != 3 || errs[0] != nil || errs[2] != nil {
+		t.Fatalf("ImportFilms() errs = %v, want only the second film to fail", errs)
+	}
+	wantErr(t, "ImportFilms() reused actor ID", errs[1], entities.ErrConflict)
+
+	_, err = repo.GetFilm(ctx, broken.ID)
+	wantErr(t, "GetFilm() of the failed film", err, entities.ErrNotFound)
+
+	got, err := repo.GetFilm(ctx, stored.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() error = %v", err)
+	}
+	assertFilm(t, got, replaced.FilmEntity)
+	assertIDs(t, "cast of the replaced film", actorIDs(got.Actors), []string{carrie.ID, keanu.ID})
+	keanu.Gender = "male"
+	assertActor(t, got.Actors[1], keanu)
+
+	got, err = repo.GetFilm(ctx, sequel.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() of the new film error = %v", err)
+	}
+	assertIDs(t, "cast of the new film", actorIDs(got.Actors), []string{carrie.ID, otherKeanu.ID})
+	// An actor without gender keeps the stored one.
+	assertActor(t, got.Actors[0], carrie)
+
+	_, total, err := repo.GetActors(ctx, entities.ActorQuery{ListQuery: page(10, 0)})
+	if err != nil {
+		t.Fatalf("GetActors() error = %v", err)
+	}
+	if total != 3 {
+		t.Errorf("actors after import = %d, want 3", total)
+	}
+}
+
+func testImportFilmsDryRun(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	keanu := createActor(t, repo, "Keanu Reeves", "", date(1964, 9, 2))
+
+	film := importedFilm("The Matrix", importedActor("Keanu Reeves", "male", date(1964, 9, 2)), importedActor("Carrie-Anne Moss", "female", date(1967, 8, 21)))
+	broken := importedFilm("Broken", entities.ActorEntity{ID: keanu.ID, Name: "Impostor"})
+	errs, err := repo.ImportFilms(ctx, []entities.CreditedFilm{film, broken}, true)
+	if err != nil {
+		t.Fatalf("ImportFilms() error = %v", err)
+	}
+	if len(errs) != 2 || errs[0] != nil {
+		t.Fatalf("ImportFilms() errs = %v, want only the second film to fail", errs)
+	}
+	wantErr(t, "ImportFilms() reused actor ID", errs[1], entities.ErrConflict)
+
+	_, err = repo.GetFilm(ctx, film.ID)
+	wantErr(t, "GetFilm() after a dry run", err, entities.ErrNotFound)
+	actors, total, err := repo.GetActors(ctx, entities.ActorQuery{ListQuery: page(10, 0)})
+	if err != nil {
+		t.Fatalf("GetActors() error = %v", err)
+	}
+	if total != 1 {
+		t.Errorf("actors after a dry run = %d, want 1", total)
+	}
+	assertActor(t, actors[0], keanu)
+}
+
+func testImportActors(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	keanu := createActor(t, repo, "Keanu Reeves", "", date(1964, 9, 2))
+
+	carrie := importedActor("Carrie-Anne Moss", "female", date(1967, 8, 21))
+	actors := []entities.ActorEntity{
+		importedActor("Keanu Reeves", "male", date(1964, 9, 2)),
+		{ID: keanu.ID, Name: "Impostor"},
+		carrie,
+	}
+	errs, err := repo.ImportActors(ctx, actors, false)
+	if err != nil {
+		t.Fatalf("ImportActors() error = %v", err)
+	}
+	if len(errs) != 3 || errs[0] != nil || errs[2] != nil {
+		t.Fatalf("ImportActors() errs = %v, want only the second actor to fail", errs)
+	}
+	wantErr(t, "ImportActors() reused actor ID", errs[1], entities.ErrConflict)
+
+	got, total, err := repo.GetActors(ctx, entities.ActorQuery{ListQuery: page(10, 0, asc("name"))})
+	if err != nil {
+		t.Fatalf("GetActors() error = %v", err)
+	}
+	if total != 2 {
+		t.Fatalf("actors after import = %d, want 2", total)
+	}
+	keanu.Gender = "male"
+	assertActor(t, got[0], carrie)
+	assertActor(t, got[1], keanu)
+
+	_, err = repo.ImportActors(ctx, []entities.ActorEntity{importedActor("Laurence Fishburne", "male", date(1961, 7, 30))}, true)
+	if err != nil {
+		t.Fatalf("ImportActors() dry run error = %v", err)
+	}
+	_, total, err = repo.GetActors(ctx, entities.ActorQuery{ListQuery: page(10, 0)})
+	if err != nil {
+		t.Fatalf("GetActors() error = %v", err)
+	}
+	if total != 2 {
+		t.Errorf("actors after a dry run = %d, want 2", total)
+	}
+}
@@ -0,0 +1,149 @@
+package repotest
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"filmography/service"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func rateFilm(t *testing.T, repo service.Repo, film entities.FilmEntity, user entities.UserEntity, rating float64, ratedAt time.Time) {
+	t.Helper()
+	err := repo.RateFilm(context.Background(), entities.FilmRating{FilmID: film.ID, UserID: user.ID, Rating: rating, RatedAt: ratedAt})
+	if err != nil {
+		t.Fatalf("RateFilm(%q, %q) error = %v", film.Title, user.Username, err)
+	}
+}
+
+func assertUserRating(t *testing.T, repo service.Repo, film entities.FilmEntity, want entities.RatingSummary) {
+	t.Helper()
+	got, err := repo.GetFilm(context.Background(), film.ID)
+	if err != nil {
+		t.Fatalf("GetFilm(%q) error = %v", film.Title, err)
+	}
+	if got.UserRating.Mean != want.Mean || got.UserRating.Count != want.Count {
+		t.Errorf("user rating of %q = %+v, want %+v", film.Title, got.UserRating, want)
+	}
+}
+
+func testRatings(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7)
+	wick := createFilm(t, repo, "John Wick", date(2014, 10, 24), 7.4)
+	alice := createUser(t, repo, "alice", entities.User)
+	bob := createUser(t, repo, "bob", entities.User)
+
+	assertUserRating(t, repo, matrix, entities.RatingSummary{})
+
+	rateFilm(t, repo, matrix, alice, 8, date(2024, 1, 1))
+	rateFilm(t, repo, matrix, bob, 7, date(2024, 1, 2))
+	rateFilm(t, repo, wick, alice, 6.5, date(2024, 1, 3))
+	assertUserRating(t, repo, matrix, entities.RatingSummary{Mean: 7.5, Count: 2})
+
+	// Rating again replaces the rating instead of adding one.
+	rateFilm(t, repo, matrix, alice, 10, date(2024, 1, 4))
+	assertUserRating(t, repo, matrix, entities.RatingSummary{Mean: 8.5, Count: 2})
+	assertUserRating(t, repo, wick, entities.RatingSummary{Mean: 6.5, Count: 1})
+
+	got, err := repo.GetFilmRating(ctx, matrix.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("GetFilmRating() error = %v", err)
+	}
+	if got.Rating != 10 || !got.RatedAt.Equal(date(2024, 1, 4)) {
+		t.Errorf("GetFilmRating() = %+v, want the latest rating", got)
+	}
+
+	totals, err := repo.GetRatingTotals(ctx)
+	if err != nil {
+		t.Fatalf("GetRatingTotals() error = %v", err)
+	}
+	if totals.Sum != 23.5 || totals.Count != 3 {
+		t.Errorf("GetRatingTotals() = %+v, want {Sum:23.5 Count:3}", totals)
+	}
+}
+
+func testRatingHistory(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7)
+	wick := createFilm(t, repo, "John Wick", date(2014, 10, 24), 7.4)
+	alice := createUser(t, repo, "alice", entities.User)
+	bob := createUser(t, repo, "bob", entities.User)
+
+	rateFilm(t, repo, matrix, alice, 8, date(2024, 1, 1))
+	rateFilm(t, repo, wick, alice, 6, date(2024, 1, 2))
+	rateFilm(t, repo, matrix, bob, 5, date(2024, 1, 3))
+	rateFilm(t, repo, matrix, alice, 9, date(2024, 1, 4))
+
+	history, total, err := repo.GetRatingHistory(ctx, entities.RatingQuery{ListQuery: page(2, 0), UserID: alice.ID})
+	if err != nil {
+		t.Fatalf("GetRatingHistory() error = %v", err)
+	}
+	if total != 3 || len(history) != 2 {
+		t.Fatalf("GetRatingHistory() = %d ratings of %d, want 2 of 3", len(history), total)
+	}
+	if history[0].Rating != 9 || history[1].FilmID != wick.ID {
+		t.Errorf("GetRatingHistory() = %+v, want newest first", history)
+	}
+
+	history, total, err = repo.GetRatingHistory(ctx, entities.RatingQuery{ListQuery: page(10, 0), UserID: alice.ID, FilmID: matrix.ID})
+	if err != nil {
+		t.Fatalf("GetRatingHistory(film) error = %v", err)
+	}
+	if total != 2 || len(history) != 2 || history[0].Rating != 9 || history[1].Rating != 8 {
+		t.Errorf("GetRatingHistory(film) = %+v, want both ratings of The Matrix", history)
+	}
+}
+
+func testRatingNotFound(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7)
+	alice := createUser(t, repo, "alice", entities.User)
+
+	_, err := repo.GetFilmRating(ctx, matrix.ID, alice.ID)
+	wantErr(t, "GetFilmRating(not rated)", err, entities.ErrNotFound)
+
+	err = repo.RateFilm(ctx, entities.FilmRating{FilmID: uuid.NewString(), UserID: alice.ID, Rating: 5, RatedAt: date(2024, 1, 1)})
+	wantErr(t, "RateFilm(unknown film)", err, entities.ErrNotFound)
+
+	err = repo.RateFilm(ctx, entities.FilmRating{FilmID: matrix.ID, UserID: uuid.NewString(), Rating: 5, RatedAt: date(2024, 1, 1)})
+	wantErr(t, "RateFilm(unknown user)", err, entities.ErrInvalidReference)
+}
+
+func testCascadeDeleteRatings(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7)
+	wick := createFilm(t, repo, "John Wick", date(2014, 10, 24), 7.4)
+	alice := createUser(t, repo, "alice", entities.User)
+	bob := createUser(t, repo, "bob", entities.User)
+
+	rateFilm(t, repo, matrix, alice, 8, date(2024, 1, 1))
+	rateFilm(t, repo, matrix, bob, 6, date(2024, 1, 2))
+	rateFilm(t, repo, wick, alice, 7, date(2024, 1, 3))
+
+	// Deleting a user takes their ratings out of the aggregates.
+	if err := repo.DeleteUser(ctx, bob.ID, entities.AnyVersion); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	assertUserRating(t, repo, matrix, entities.RatingSummary{Mean: 8, Count: 1})
+
+	if err := repo.DeleteFilm(ctx, wick.ID, entities.AnyVersion); err != nil {
+		t.Fatalf("DeleteFilm() error = %v", err)
+	}
+	_, total, err := repo.GetRatingHistory(ctx, entities.RatingQuery{ListQuery: page(10, 0), UserID: alice.ID})
+	if err != nil {
+		t.Fatalf("GetRatingHistory() error = %v", err)
+	}
+	if total != 1 {
+		t.Errorf("history after deleting a film has %d ratings, want 1", total)
+	}
+	totals, err := repo.GetRatingTotals(ctx)
+	if err != nil {
+		t.Fatalf("GetRatingTotals() error = %v", err)
+	}
+	if totals.Sum != 8 || totals.Count != 1 {
+		t.Errorf("GetRatingTotals() = %+v, want {Sum:8 Count:1}", totals)
+	}
+}
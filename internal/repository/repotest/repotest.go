@@ -0,0 +1,184 @@
+// Package repotest is the contract every implementation of service.Repo
+// has to satisfy. Backends run it from their own tests:
+//
+//	func TestRepo(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) service.Repo { return newEmptyRepo(t) })
+//	}
+package repotest
+
+import (
+	"context"
+	"errors"
+	"filmography/internal/entities"
+	"filmography/service"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Factory returns an empty repository. It is called once per subtest.
+type Factory func(t *testing.T) service.Repo
+
+// Run runs the whole contract against the repositories made by newRepo.
+func Run(t *testing.T, newRepo Factory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo service.Repo)
+	}{
+		{"ActorRoundTrip", testActorRoundTrip},
+		{"ActorNotFound", testActorNotFound},
+		{"FilmRoundTrip", testFilmRoundTrip},
+		{"FilmNotFound", testFilmNotFound},
+		{"FilmUnknownActor", testFilmUnknownActor},
+		{"Filmography", testFilmography},
+		{"CascadeDeleteActor", testCascadeDeleteActor},
+		{"CascadeDeleteFilm", testCascadeDeleteFilm},
+		{"UserRoundTrip", testUserRoundTrip},
+		{"UserNotFound", testUserNotFound},
+		{"UserConflict", testUserConflict},
+		{"FilmPagination", testFilmPagination},
+		{"FilmFilters", testFilmFilters},
+		{"ActorOrderAndFilters", testActorOrderAndFilters},
+		{"UserOrderAndFilters", testUserOrderAndFilters},
+		{"CursorPagination", testCursorPagination},
+		{"ImportFilms", testImportFilms},
+		{"ImportFilmsDryRun", testImportFilmsDryRun},
+		{"ImportActors", testImportActors},
+		{"Export", testExport},
+		{"ExportImport", testExportImport},
+		{"Ratings", testRatings},
+		{"RatingHistory", testRatingHistory},
+		{"RatingNotFound", testRatingNotFound},
+		{"CascadeDeleteRatings", testCascadeDeleteRatings},
+		{"ReviewRoundTrip", testReviewRoundTrip},
+		{"ReviewNotFound", testReviewNotFound},
+		{"ReviewConflict", testReviewConflict},
+		{"ReviewFilters", testReviewFilters},
+		{"CascadeDeleteReviews", testCascadeDeleteReviews},
+		{"WatchlistOrder", testWatchlistOrder},
+		{"History", testHistory},
+		{"CascadeDeleteWatch", testCascadeDeleteWatch},
+		{"GenreRoundTrip", testGenreRoundTrip},
+		{"FilmTaxonomy", testFilmTaxonomy},
+		{"FilmTaxonomyFilters", testFilmTaxonomyFilters},
+		{"CascadeDeleteTaxonomy", testCascadeDeleteTaxonomy},
+		{"FilmCredits", testFilmCredits},
+		{"CreditsByPerson", testCreditsByPerson},
+		{"FilmUnknownPerson", testFilmUnknownPerson},
+		{"ImportKeepsCrew", testImportKeepsCrew},
+		{"CascadeDeleteCredits", testCascadeDeleteCredits},
+		{"FilmVersions", testFilmVersions},
+		{"ActorVersions", testActorVersions},
+		{"UserVersions", testUserVersions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepo(t))
+		})
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func page(limit, offset int, sort ...entities.SortField) entities.ListQuery {
+	return entities.ListQuery{Limit: limit, Offset: offset, Sort: sort}
+}
+
+func asc(field string) entities.SortField {
+	return entities.SortField{Field: field}
+}
+
+func desc(field string) entities.SortField {
+	return entities.SortField{Field: field, Desc: true}
+}
+
+func createActor(t *testing.T, repo service.Repo, name string, gender string, birthday time.Time) entities.ActorEntity {
+	t.Helper()
+	actor := entities.ActorEntity{ID: uuid.NewString(), Name: name, Gender: gender, Birthday: birthday}
+	if err := repo.CreateActor(context.Background(), actor); err != nil {
+		t.Fatalf("CreateActor(%q) error = %v", name, err)
+	}
+	return actor
+}
+
+func createFilm(t *testing.T, repo service.Repo, title string, released time.Time, rating float64, cast ...entities.ActorEntity) entities.FilmEntity {
+	t.Helper()
+	film := entities.FilmEntity{ID: uuid.NewString(), Title: title, Description: title + " description", ReleaseDate: released, Rating: rating, Actors: cast}
+	if err := repo.CreateFilm(context.Background(), film); err != nil {
+		t.Fatalf("CreateFilm(%q) error = %v", title, err)
+	}
+	return film
+}
+
+func createUser(t *testing.T, repo service.Repo, username string, role entities.Role) entities.UserEntity {
+	t.Helper()
+	user := entities.UserEntity{ID: uuid.NewString(), Username: username, Role: role, PasswordHash: "hash-" + username}
+	if err := repo.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("CreateUser(%q) error = %v", username, err)
+	}
+	return user
+}
+
+func wantErr(t *testing.T, op string, err error, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("%s error = %v, want %v", op, err, target)
+	}
+}
+
+func assertActor(t *testing.T, got, want entities.ActorEntity) {
+	t.Helper()
+	if got.ID != want.ID || got.Name != want.Name || got.Gender != want.Gender || !got.Birthday.Equal(want.Birthday) {
+		t.Errorf("actor = %+v, want %+v", got, want)
+	}
+}
+
+func assertFilm(t *testing.T, got, want entities.FilmEntity) {
+	t.Helper()
+	if got.ID != want.ID || got.Title != want.Title || got.Description != want.Description ||
+		!got.ReleaseDate.Equal(want.ReleaseDate) || got.Rating != want.Rating {
+		t.Errorf("film = %+v, want %+v", got, want)
+	}
+}
+
+func actorIDs(actors []entities.ActorEntity) []string {
+	ids := make([]string, 0, len(actors))
+	for _, actor := range actors {
+		ids = append(ids, actor.ID)
+	}
+	return ids
+}
+
+func filmIDs(films []entities.FilmEntity) []string {
+	ids := make([]string, 0, len(films))
+	for _, film := range films {
+		ids = append(ids, film.ID)
+	}
+	return ids
+}
+
+func userIDs(users []entities.UserEntity) []string {
+	ids := make([]string, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids
+}
+
+func assertIDs(t *testing.T, what string, got, want []string) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Errorf("%s = %v, want %v", what, got, want)
+	}
+}
+
+// byID returns the IDs sorted, the order lists fall back to on ties.
+func byID(ids ...string) []string {
+	slices.Sort(ids)
+	return ids
+}
@@ -0,0 +1,210 @@
+package repotest
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"filmography/service"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func createReview(t *testing.T, repo service.Repo, film entities.FilmEntity, user entities.UserEntity, status entities.ReviewStatus, created time.Time) entities.ReviewEntity {
+	t.Helper()
+	review := entities.ReviewEntity{
+		ID:        uuid.NewString(),
+		FilmID:    film.ID,
+		UserID:    user.ID,
+		Text:      user.Username + " on " + film.Title,
+		Status:    entities.ReviewPending,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+	if err := repo.CreateReview(context.Background(), review); err != nil {
+		t.Fatalf("CreateReview(%q, %q) error = %v", film.Title, user.Username, err)
+	}
+	if status != entities.ReviewPending {
+		review.Status = status
+		if err := repo.UpdateReview(context.Background(), review); err != nil {
+			t.Fatalf("UpdateReview(%q) error = %v", review.ID, err)
+		}
+	}
+	review.Author = user.Username
+	return review
+}
+
+func reviewIDs(reviews []entities.ReviewEntity) []string {
+	ids := make([]string, 0, len(reviews))
+	for _, review := range reviews {
+		ids = append(ids, review.ID)
+	}
+	return ids
+}
+
+func assertReview(t *testing.T, got, want entities.ReviewEntity) {
+	t.Helper()
+	if got.ID != want.ID || got.FilmID != want.FilmID || got.UserID != want.UserID || got.Author != want.Author ||
+		got.Text != want.Text || got.Status != want.Status || !got.CreatedAt.Equal(want.CreatedAt) ||
+		!got.UpdatedAt.Equal(want.UpdatedAt) || got.ModeratorID != want.ModeratorID ||
+		(got.ModeratedAt == nil) != (want.ModeratedAt == nil) ||
+		(got.ModeratedAt != nil && !got.ModeratedAt.Equal(*want.ModeratedAt)) {
+		t.Errorf("review = %+v, want %+v", got, want)
+	}
+}
+
+func testReviewRoundTrip(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7)
+	alice := createUser(t, repo, "alice", entities.User)
+	root := createUser(t, repo, "root", entities.Admin)
+
+	review := createReview(t, repo, matrix, alice, entities.ReviewPending, date(2024, 1, 1))
+	got, err := repo.GetReview(ctx, review.ID)
+	if err != nil {
+		t.Fatalf("GetReview() error = %v", err)
+	}
+	assertReview(t, got, review)
+
+	moderated := date(2024, 1, 2)
+	review.Status = entities.ReviewPublished
+	review.ModeratorID = root.ID
+	review.ModeratedAt = &moderated
+	if err := repo.UpdateReview(ctx, review); err != nil {
+		t.Fatalf("UpdateReview() error = %v", err)
+	}
+	got, err = repo.GetReview(ctx, review.ID)
+	if err != nil {
+		t.Fatalf("GetReview() error = %v", err)
+	}
+	assertReview(t, got, review)
+
+	// Deleting the moderator keeps the review.
+	if err := repo.DeleteUser(ctx, root.ID, entities.AnyVersion); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	got, err = repo.GetReview(ctx, review.ID)
+	if err != nil {
+		t.Fatalf("GetReview() after deleting the moderator error = %v", err)
+	}
+	if got.ModeratorID != "" {
+		t.Errorf("ModeratorID after deleting the moderator = %q, want empty", got.ModeratorID)
+	}
+
+	if err := repo.DeleteReview(ctx, review.ID); err != nil {
+		t.Fatalf("DeleteReview() error = %v", err)
+	}
+	_, err = repo.GetReview(ctx, review.ID)
+	wantErr(t, "GetReview(deleted)", err, entities.ErrNotFound)
+}
+
+func testReviewNotFound(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7)
+	alice := createUser(t, repo, "alice", entities.User)
+	missing := uuid.NewString()
+
+	_, err := repo.GetReview(ctx, missing)
+	wantErr(t, "GetReview(missing)", err, entities.ErrNotFound)
+	err = repo.UpdateReview(ctx, entities.ReviewEntity{ID: missing, Text: "text", Status: entities.ReviewPending, UpdatedAt: date(2024, 1, 1)})
+	wantErr(t, "UpdateReview(missing)", err, entities.ErrNotFound)
+	err = repo.DeleteReview(ctx, missing)
+	wantErr(t, "DeleteReview(missing)", err, entities.ErrNotFound)
+	_, _, err = repo.GetReviews(ctx, entities.ReviewQuery{ListQuery: page(10, 0), FilmID: missing})
+	wantErr(t, "GetReviews(missing film)", err, entities.ErrNotFound)
+
+	review := entities.ReviewEntity{ID: uuid.NewString(), FilmID: missing, UserID: alice.ID, Text: "text", Status: entities.ReviewPending, CreatedAt: date(2024, 1, 1), UpdatedAt: date(2024, 1, 1)}
+	err = repo.CreateReview(ctx, review)
+	wantErr(t, "CreateReview(missing film)", err, entities.ErrNotFound)
+
+	review.FilmID, review.UserID = matrix.ID, missing
+	err = repo.CreateReview(ctx, review)
+	wantErr(t, "CreateReview(missing user)", err, entities.ErrInvalidReference)
+}
+
+func testReviewConflict(t *testing.T, repo service.Repo) {
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7)
+	alice := createUser(t, repo, "alice", entities.User)
+	createReview(t, repo, matrix, alice, entities.ReviewPending, date(2024, 1, 1))
+
+	review := entities.ReviewEntity{ID: uuid.NewString(), FilmID: matrix.ID, UserID: alice.ID, Text: "again", Status: entities.ReviewPending, CreatedAt: date(2024, 1, 2), UpdatedAt: date(2024, 1, 2)}
+	err := repo.CreateReview(context.Background(), review)
+	wantErr(t, "CreateReview(second review of the film)", err, entities.ErrConflict)
+}
+
+func testReviewFilters(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7)
+	wick := createFilm(t, repo, "John Wick", date(2014, 10, 24), 7.4)
+	alice := createUser(t, repo, "alice", entities.User)
+	bob := createUser(t, repo, "bob", entities.User)
+	carol := createUser(t, repo, "carol", entities.User)
+
+	first := createReview(t, repo, matrix, alice, entities.ReviewPublished, date(2024, 1, 1))
+	second := createReview(t, repo, matrix, bob, entities.ReviewPending, date(2024, 1, 2))
+	third := createReview(t, repo, matrix, carol, entities.ReviewPublished, date(2024, 1, 3))
+	other := createReview(t, repo, wick, alice, entities.ReviewRejected, date(2024, 1, 4))
+
+	tests := []struct {
+		name  string
+		query entities.ReviewQuery
+		want  []string
+		total int
+	}{
+		{"published of a film, newest first", entities.ReviewQuery{ListQuery: page(10, 0, desc("created_at")), FilmID: matrix.ID, Status: entities.ReviewPublished}, []string{third.ID, first.ID}, 2},
+		{"queue, oldest first", entities.ReviewQuery{ListQuery: page(10, 0, asc("created_at")), Status: entities.ReviewPending}, []string{second.ID}, 1},
+		{"of a user", entities.ReviewQuery{ListQuery: page(10, 0, asc("created_at")), UserID: alice.ID}, []string{first.ID, other.ID}, 2},
+		{"page", entities.ReviewQuery{ListQuery: page(2, 1, asc("created_at"))}, []string{second.ID, third.ID}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reviews, total, err := repo.GetReviews(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("GetReviews() error = %v", err)
+			}
+			assertIDs(t, "GetReviews()", reviewIDs(reviews), tt.want)
+			if total != tt.total {
+				t.Errorf("GetReviews() total = %d, want %d", total, tt.total)
+			}
+		})
+	}
+
+	reviews, _, err := repo.GetReviews(ctx, entities.ReviewQuery{ListQuery: page(10, 0), UserID: bob.ID})
+	if err != nil {
+		t.Fatalf("GetReviews() error = %v", err)
+	}
+	if len(reviews) != 1 || reviews[0].Author != "bob" {
+		t.Errorf("GetReviews() = %+v, want the review by bob", reviews)
+	}
+}
+
+func testCascadeDeleteReviews(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7)
+	wick := createFilm(t, repo, "John Wick", date(2014, 10, 24), 7.4)
+	alice := createUser(t, repo, "alice", entities.User)
+	bob := createUser(t, repo, "bob", entities.User)
+
+	byAlice := createReview(t, repo, matrix, alice, entities.ReviewPublished, date(2024, 1, 1))
+	byBob := createReview(t, repo, matrix, bob, entities.ReviewPublished, date(2024, 1, 2))
+	ofWick := createReview(t, repo, wick, alice, entities.ReviewPublished, date(2024, 1, 3))
+
+	if err := repo.DeleteUser(ctx, bob.ID, entities.AnyVersion); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	_, err := repo.GetReview(ctx, byBob.ID)
+	wantErr(t, "GetReview(review of a deleted user)", err, entities.ErrNotFound)
+
+	if err := repo.DeleteFilm(ctx, wick.ID, entities.AnyVersion); err != nil {
+		t.Fatalf("DeleteFilm() error = %v", err)
+	}
+	_, err = repo.GetReview(ctx, ofWick.ID)
+	wantErr(t, "GetReview(review of a deleted film)", err, entities.ErrNotFound)
+
+	reviews, _, err := repo.GetReviews(ctx, entities.ReviewQuery{ListQuery: page(10, 0)})
+	if err != nil {
+		t.Fatalf("GetReviews() error = %v", err)
+	}
+	assertIDs(t, "reviews left", reviewIDs(reviews), []string{byAlice.ID})
+}
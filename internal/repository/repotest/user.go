@@ -0,0 +1,129 @@
+package repotest
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"filmography/service"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func testUserRoundTrip(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	user := createUser(t, repo, "neo", entities.User)
+	user.Version = 1
+
+	got, err := repo.GetUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUser() error = %v", err)
+	}
+	if got != user {
+		t.Errorf("GetUser() = %+v, want %+v", got, user)
+	}
+
+	got, err = repo.GetUserByUsername(ctx, "neo")
+	if err != nil {
+		t.Fatalf("GetUserByUsername() error = %v", err)
+	}
+	if got != user {
+		t.Errorf("GetUserByUsername() = %+v, want %+v", got, user)
+	}
+
+	// Updates change the username and role but keep the password.
+	err = repo.UpdateUser(ctx, user.ID, entities.UserEntity{Username: "the-one", Role: entities.Admin})
+	if err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	got, err = repo.GetUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUser() after update error = %v", err)
+	}
+	want := entities.UserEntity{ID: user.ID, Username: "the-one", Role: entities.Admin, PasswordHash: user.PasswordHash, Version: 2}
+	if got != want {
+		t.Errorf("GetUser() after update = %+v, want %+v", got, want)
+	}
+
+	err = repo.UpdateUserPassword(ctx, user.ID, "new-hash")
+	if err != nil {
+		t.Fatalf("UpdateUserPassword() error = %v", err)
+	}
+	got, err = repo.GetUserByUsername(ctx, "the-one")
+	if err != nil {
+		t.Fatalf("GetUserByUsername() after password update error = %v", err)
+	}
+	want.PasswordHash = "new-hash"
+	if got != want {
+		t.Errorf("GetUserByUsername() after password update = %+v, want %+v", got, want)
+	}
+
+	if err := repo.DeleteUser(ctx, user.ID, entities.AnyVersion); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	_, err = repo.GetUser(ctx, user.ID)
+	wantErr(t, "GetUser() after delete", err, entities.ErrNotFound)
+}
+
+func testUserNotFound(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	id := uuid.NewString()
+
+	_, err := repo.GetUser(ctx, id)
+	wantErr(t, "GetUser()", err, entities.ErrNotFound)
+
+	_, err = repo.GetUserByUsername(ctx, "nobody")
+	wantErr(t, "GetUserByUsername()", err, entities.ErrUserNotFound)
+	wantErr(t, "GetUserByUsername()", err, entities.ErrNotFound)
+
+	err = repo.UpdateUser(ctx, id, entities.UserEntity{Username: "nobody", Role: entities.User})
+	wantErr(t, "UpdateUser()", err, entities.ErrNotFound)
+
+	err = repo.UpdateUserPassword(ctx, id, "hash")
+	wantErr(t, "UpdateUserPassword()", err, entities.ErrNotFound)
+
+	err = repo.DeleteUser(ctx, id, entities.AnyVersion)
+	wantErr(t, "DeleteUser()", err, entities.ErrNotFound)
+}
+
+func testUserConflict(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	neo := createUser(t, repo, "neo", entities.User)
+	createUser(t, repo, "trinity", entities.User)
+
+	err := repo.CreateUser(ctx, entities.UserEntity{ID: uuid.NewString(), Username: "neo", Role: entities.User})
+	wantErr(t, "CreateUser() with a taken username", err, entities.ErrConflict)
+
+	err = repo.UpdateUser(ctx, neo.ID, entities.UserEntity{Username: "trinity", Role: entities.User})
+	wantErr(t, "UpdateUser() to a taken username", err, entities.ErrConflict)
+
+	// Keeping the own username is not a conflict.
+	err = repo.UpdateUser(ctx, neo.ID, entities.UserEntity{Username: "neo", Role: entities.Admin})
+	if err != nil {
+		t.Errorf("UpdateUser() keeping the username error = %v", err)
+	}
+}
+
+func testUserOrderAndFilters(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	neo := createUser(t, repo, "neo", entities.User)
+	morpheus := createUser(t, repo, "morpheus", entities.Admin)
+	trinity := createUser(t, repo, "trinity", entities.User)
+
+	users, total, err := repo.GetUsers(ctx, entities.UserQuery{ListQuery: page(10, 0, asc("role"), desc("username"))})
+	if err != nil {
+		t.Fatalf("GetUsers() error = %v", err)
+	}
+	if total != 3 {
+		t.Errorf("total = %d, want 3", total)
+	}
+	assertIDs(t, "users by role, -username", userIDs(users), []string{morpheus.ID, trinity.ID, neo.ID})
+
+	users, total, err = repo.GetUsers(ctx, entities.UserQuery{ListQuery: page(1, 1, asc("username")), Role: entities.User})
+	if err != nil {
+		t.Fatalf("GetUsers() by role error = %v", err)
+	}
+	if total != 2 {
+		t.Errorf("total for role = %d, want 2", total)
+	}
+	assertIDs(t, "second user with the user role", userIDs(users), []string{trinity.ID})
+}
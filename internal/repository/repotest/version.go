@@ -0,0 +1,149 @@
+package repotest
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"filmography/service"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func testFilmVersions(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	film := createFilm(t, repo, "Heat", date(1995, 12, 15), 8)
+
+	got, err := repo.GetFilm(ctx, film.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() error = %v", err)
+	}
+	if got.Version != 1 {
+		t.Fatalf("Version of a new film = %d, want 1", got.Version)
+	}
+
+	// An unconditional update and one at the current version both count.
+	film.Version = entities.AnyVersion
+	if err := repo.UpdateFilm(ctx, film.ID, film); err != nil {
+		t.Fatalf("UpdateFilm(any version) error = %v", err)
+	}
+	film.Version = 2
+	film.Title = "Heat (1995)"
+	if err := repo.UpdateFilm(ctx, film.ID, film); err != nil {
+		t.Fatalf("UpdateFilm(version 2) error = %v", err)
+	}
+
+	// A stale update changes nothing.
+	stale := film
+	stale.Version = 2
+	stale.Title = "Stale"
+	err = repo.UpdateFilm(ctx, film.ID, stale)
+	wantErr(t, "UpdateFilm(stale version)", err, entities.ErrVersionMismatch)
+	got, err = repo.GetFilm(ctx, film.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() error = %v", err)
+	}
+	if got.Version != 3 || got.Title != "Heat (1995)" {
+		t.Errorf("film = %q at version %d, want %q at version 3", got.Title, got.Version, "Heat (1995)")
+	}
+
+	err = repo.UpdateFilm(ctx, uuid.NewString(), stale)
+	wantErr(t, "UpdateFilm(unknown)", err, entities.ErrNotFound)
+
+	// Importing the film is an edit too.
+	imported := importedFilm("Heat")
+	imported.ID = film.ID
+	if errs, err := repo.ImportFilms(ctx, []entities.CreditedFilm{imported}, false); err != nil || errs[0] != nil {
+		t.Fatalf("ImportFilms() = %v, %v", errs, err)
+	}
+	got, err = repo.GetFilm(ctx, film.ID)
+	if err != nil {
+		t.Fatalf("GetFilm() error = %v", err)
+	}
+	if got.Version != 4 {
+		t.Errorf("Version after import = %d, want 4", got.Version)
+	}
+
+	err = repo.DeleteFilm(ctx, film.ID, 3)
+	wantErr(t, "DeleteFilm(stale version)", err, entities.ErrVersionMismatch)
+	err = repo.DeleteFilm(ctx, uuid.NewString(), 3)
+	wantErr(t, "DeleteFilm(unknown)", err, entities.ErrNotFound)
+	if err := repo.DeleteFilm(ctx, film.ID, 4); err != nil {
+		t.Fatalf("DeleteFilm(version 4) error = %v", err)
+	}
+}
+
+func testActorVersions(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	actor := createActor(t, repo, "Al Pacino", "", date(1940, 4, 25))
+
+	got, err := repo.GetActor(ctx, actor.ID)
+	if err != nil {
+		t.Fatalf("GetActor() error = %v", err)
+	}
+	if got.Version != 1 {
+		t.Fatalf("Version of a new actor = %d, want 1", got.Version)
+	}
+
+	actor.Version = 1
+	actor.Gender = "male"
+	if err := repo.UpdateActor(ctx, actor.ID, actor); err != nil {
+		t.Fatalf("UpdateActor(version 1) error = %v", err)
+	}
+	err = repo.UpdateActor(ctx, actor.ID, actor)
+	wantErr(t, "UpdateActor(stale version)", err, entities.ErrVersionMismatch)
+
+	// The cast of a film carries the versions of the actors.
+	film := createFilm(t, repo, "Heat", date(1995, 12, 15), 8, actor)
+	cast, err := repo.GetActorsByFilm(ctx, film.ID)
+	if err != nil {
+		t.Fatalf("GetActorsByFilm() error = %v", err)
+	}
+	if len(cast) != 1 || cast[0].Version != 2 {
+		t.Errorf("cast = %+v, want the actor at version 2", cast)
+	}
+
+	err = repo.DeleteActor(ctx, actor.ID, 1)
+	wantErr(t, "DeleteActor(stale version)", err, entities.ErrVersionMismatch)
+	if err := repo.DeleteActor(ctx, actor.ID, 2); err != nil {
+		t.Fatalf("DeleteActor(version 2) error = %v", err)
+	}
+}
+
+func testUserVersions(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	user := createUser(t, repo, "neo", entities.User)
+
+	got, err := repo.GetUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUser() error = %v", err)
+	}
+	if got.Version != 1 {
+		t.Fatalf("Version of a new user = %d, want 1", got.Version)
+	}
+
+	// A new password is not an edit of the user.
+	if err := repo.UpdateUserPassword(ctx, user.ID, "new-hash"); err != nil {
+		t.Fatalf("UpdateUserPassword() error = %v", err)
+	}
+	user.Version = 1
+	user.Role = entities.Admin
+	if err := repo.UpdateUser(ctx, user.ID, user); err != nil {
+		t.Fatalf("UpdateUser(version 1) error = %v", err)
+	}
+	err = repo.UpdateUser(ctx, user.ID, user)
+	wantErr(t, "UpdateUser(stale version)", err, entities.ErrVersionMismatch)
+
+	got, err = repo.GetUserByUsername(ctx, "neo")
+	if err != nil {
+		t.Fatalf("GetUserByUsername() error = %v", err)
+	}
+	if got.Version != 2 || got.Role != entities.Admin {
+		t.Errorf("user = %+v, want an admin at version 2", got)
+	}
+
+	err = repo.DeleteUser(ctx, user.ID, 1)
+	wantErr(t, "DeleteUser(stale version)", err, entities.ErrVersionMismatch)
+	if err := repo.DeleteUser(ctx, user.ID, 2); err != nil {
+		t.Fatalf("DeleteUser(version 2) error = %v", err)
+	}
+}
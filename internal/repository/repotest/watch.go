@@ -0,0 +1,221 @@
+package repotest
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"filmography/service"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func addToWatchlist(t *testing.T, repo service.Repo, user entities.UserEntity, film entities.FilmEntity, position int, added time.Time) {
+	t.Helper()
+	err := repo.AddToWatchlist(context.Background(), entities.WatchlistEntry{UserID: user.ID, FilmID: film.ID, Position: position, AddedAt: added})
+	if err != nil {
+		t.Fatalf("AddToWatchlist(%q, %d) error = %v", film.Title, position, err)
+	}
+}
+
+func watchlistIDs(t *testing.T, repo service.Repo, user entities.UserEntity) []string {
+	t.Helper()
+	entries, _, err := repo.GetWatchlist(context.Background(), entities.WatchQuery{ListQuery: page(100, 0, asc("position")), UserID: user.ID})
+	if err != nil {
+		t.Fatalf("GetWatchlist() error = %v", err)
+	}
+	ids := make([]string, 0, len(entries))
+	for i, entry := range entries {
+		if entry.Position != i+1 {
+			t.Errorf("position of entry %d = %d, want %d", i, entry.Position, i+1)
+		}
+		ids = append(ids, entry.FilmID)
+	}
+	return ids
+}
+
+func createHistoryEntry(t *testing.T, repo service.Repo, user entities.UserEntity, film entities.FilmEntity, watched time.Time, rating *float64) entities.HistoryEntry {
+	t.Helper()
+	entry := entities.HistoryEntry{ID: uuid.NewString(), UserID: user.ID, FilmID: film.ID, Title: film.Title, WatchedAt: watched, Rating: rating}
+	if err := repo.CreateHistoryEntry(context.Background(), entry); err != nil {
+		t.Fatalf("CreateHistoryEntry(%q) error = %v", film.Title, err)
+	}
+	return entry
+}
+
+func historyIDs(entries []entities.HistoryEntry) []string {
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
+
+func ratingOf(rating float64) *float64 {
+	return &rating
+}
+
+func testWatchlistOrder(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7)
+	wick := createFilm(t, repo, "John Wick", date(2014, 10, 24), 7.4)
+	speed := createFilm(t, repo, "Speed", date(1994, 6, 10), 7.3)
+	alice := createUser(t, repo, "alice", entities.User)
+	bob := createUser(t, repo, "bob", entities.User)
+
+	addToWatchlist(t, repo, alice, matrix, 0, date(2024, 1, 1))
+	addToWatchlist(t, repo, alice, wick, 0, date(2024, 1, 2))
+	addToWatchlist(t, repo, alice, speed, 1, date(2024, 1, 3))
+	addToWatchlist(t, repo, bob, wick, 0, date(2024, 1, 4))
+	assertIDs(t, "watchlist", watchlistIDs(t, repo, alice), []string{speed.ID, matrix.ID, wick.ID})
+	assertIDs(t, "watchlist of another user", watchlistIDs(t, repo, bob), []string{wick.ID})
+
+	// Adding a film again moves it and keeps the date it was added on.
+	addToWatchlist(t, repo, alice, speed, 3, date(2024, 2, 1))
+	assertIDs(t, "watchlist after moving down", watchlistIDs(t, repo, alice), []string{matrix.ID, wick.ID, speed.ID})
+	addToWatchlist(t, repo, alice, wick, 0, date(2024, 2, 2))
+	addToWatchlist(t, repo, alice, speed, 99, date(2024, 2, 3))
+	assertIDs(t, "watchlist after re-adding", watchlistIDs(t, repo, alice), []string{matrix.ID, wick.ID, speed.ID})
+
+	entries, total, err := repo.GetWatchlist(ctx, entities.WatchQuery{ListQuery: page(10, 0, desc("release_date")), UserID: alice.ID})
+	if err != nil {
+		t.Fatalf("GetWatchlist() error = %v", err)
+	}
+	if total != 3 || len(entries) != 3 || entries[0].FilmID != wick.ID || entries[0].Title != "John Wick" ||
+		!entries[0].AddedAt.Equal(date(2024, 1, 2)) || !entries[0].ReleaseDate.Equal(wick.ReleaseDate) {
+		t.Errorf("GetWatchlist(-release_date) = %+v, want John Wick first as added", entries)
+	}
+
+	if err := repo.RemoveFromWatchlist(ctx, alice.ID, matrix.ID); err != nil {
+		t.Fatalf("RemoveFromWatchlist() error = %v", err)
+	}
+	assertIDs(t, "watchlist after removing", watchlistIDs(t, repo, alice), []string{wick.ID, speed.ID})
+
+	err = repo.RemoveFromWatchlist(ctx, alice.ID, matrix.ID)
+	wantErr(t, "RemoveFromWatchlist(not listed)", err, entities.ErrNotFound)
+	err = repo.AddToWatchlist(ctx, entities.WatchlistEntry{UserID: alice.ID, FilmID: uuid.NewString(), AddedAt: date(2024, 1, 1)})
+	wantErr(t, "AddToWatchlist(missing film)", err, entities.ErrNotFound)
+	err = repo.AddToWatchlist(ctx, entities.WatchlistEntry{UserID: uuid.NewString(), FilmID: matrix.ID, AddedAt: date(2024, 1, 1)})
+	wantErr(t, "AddToWatchlist(missing user)", err, entities.ErrInvalidReference)
+}
+
+func testHistory(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7)
+	wick := createFilm(t, repo, "John Wick", date(2014, 10, 24), 7.4)
+	alice := createUser(t, repo, "alice", entities.User)
+	bob := createUser(t, repo, "bob", entities.User)
+
+	first := createHistoryEntry(t, repo, alice, matrix, date(2024, 1, 1), ratingOf(8))
+	second := createHistoryEntry(t, repo, alice, wick, date(2024, 2, 1), nil)
+	again := createHistoryEntry(t, repo, alice, matrix, date(2024, 3, 1), ratingOf(9.5))
+	createHistoryEntry(t, repo, bob, matrix, date(2024, 4, 1), nil)
+
+	entries, total, err := repo.GetHistory(ctx, entities.WatchQuery{ListQuery: page(10, 0, desc("watched_at")), UserID: alice.ID})
+	if err != nil {
+		t.Fatalf("GetHistory() error = %v", err)
+	}
+	assertIDs(t, "GetHistory(-watched_at)", historyIDs(entries), []string{again.ID, second.ID, first.ID})
+	if total != 3 {
+		t.Errorf("GetHistory() total = %d, want 3", total)
+	}
+
+	entries, _, err = repo.GetHistory(ctx, entities.WatchQuery{ListQuery: page(10, 0, asc("watched_at")), UserID: alice.ID, FilmID: matrix.ID})
+	if err != nil {
+		t.Fatalf("GetHistory(film) error = %v", err)
+	}
+	assertIDs(t, "GetHistory(film)", historyIDs(entries), []string{first.ID, again.ID})
+
+	got, err := repo.GetHistoryEntry(ctx, alice.ID, again.ID)
+	if err != nil {
+		t.Fatalf("GetHistoryEntry() error = %v", err)
+	}
+	if got.FilmID != matrix.ID || got.Title != "The Matrix" || !got.WatchedAt.Equal(date(2024, 3, 1)) || got.Rating == nil || *got.Rating != 9.5 {
+		t.Errorf("GetHistoryEntry() = %+v, want %+v", got, again)
+	}
+
+	again.WatchedAt, again.Rating = date(2024, 3, 2), nil
+	if err := repo.UpdateHistoryEntry(ctx, again); err != nil {
+		t.Fatalf("UpdateHistoryEntry() error = %v", err)
+	}
+	got, err = repo.GetHistoryEntry(ctx, alice.ID, again.ID)
+	if err != nil {
+		t.Fatalf("GetHistoryEntry() error = %v", err)
+	}
+	if !got.WatchedAt.Equal(date(2024, 3, 2)) || got.Rating != nil {
+		t.Errorf("GetHistoryEntry() after update = %+v, want %+v", got, again)
+	}
+
+	// Viewings are scoped to the user who watched.
+	_, err = repo.GetHistoryEntry(ctx, bob.ID, first.ID)
+	wantErr(t, "GetHistoryEntry(another user)", err, entities.ErrNotFound)
+	err = repo.UpdateHistoryEntry(ctx, entities.HistoryEntry{ID: first.ID, UserID: bob.ID, WatchedAt: date(2024, 1, 1)})
+	wantErr(t, "UpdateHistoryEntry(another user)", err, entities.ErrNotFound)
+	err = repo.DeleteHistoryEntry(ctx, bob.ID, first.ID)
+	wantErr(t, "DeleteHistoryEntry(another user)", err, entities.ErrNotFound)
+
+	if err := repo.DeleteHistoryEntry(ctx, alice.ID, first.ID); err != nil {
+		t.Fatalf("DeleteHistoryEntry() error = %v", err)
+	}
+	_, err = repo.GetHistoryEntry(ctx, alice.ID, first.ID)
+	wantErr(t, "GetHistoryEntry(deleted)", err, entities.ErrNotFound)
+
+	err = repo.CreateHistoryEntry(ctx, entities.HistoryEntry{ID: uuid.NewString(), UserID: alice.ID, FilmID: uuid.NewString(), WatchedAt: date(2024, 1, 1)})
+	wantErr(t, "CreateHistoryEntry(missing film)", err, entities.ErrNotFound)
+
+	// Watching a film takes it off the watchlist, a viewing that cannot be
+	// stored leaves the watchlist as it was.
+	speed := createFilm(t, repo, "Speed", date(1994, 6, 10), 7.3)
+	addToWatchlist(t, repo, alice, matrix, 0, date(2024, 5, 1))
+	addToWatchlist(t, repo, alice, wick, 0, date(2024, 5, 2))
+	addToWatchlist(t, repo, alice, speed, 0, date(2024, 5, 3))
+	err = repo.CreateHistoryEntry(ctx, entities.HistoryEntry{ID: again.ID, UserID: alice.ID, FilmID: wick.ID, WatchedAt: date(2024, 5, 4)})
+	wantErr(t, "CreateHistoryEntry(taken id)", err, entities.ErrConflict)
+	assertIDs(t, "watchlist after a failed viewing", watchlistIDs(t, repo, alice), []string{matrix.ID, wick.ID, speed.ID})
+	createHistoryEntry(t, repo, alice, wick, date(2024, 5, 4), nil)
+	assertIDs(t, "watchlist after a viewing", watchlistIDs(t, repo, alice), []string{matrix.ID, speed.ID})
+}
+
+func testCascadeDeleteWatch(t *testing.T, repo service.Repo) {
+	ctx := context.Background()
+	matrix := createFilm(t, repo, "The Matrix", date(1999, 3, 31), 8.7)
+	wick := createFilm(t, repo, "John Wick", date(2014, 10, 24), 7.4)
+	speed := createFilm(t, repo, "Speed", date(1994, 6, 10), 7.3)
+	heat := createFilm(t, repo, "Heat", date(1995, 12, 15), 8.3)
+	alice := createUser(t, repo, "alice", entities.User)
+	bob := createUser(t, repo, "bob", entities.User)
+
+	kept := createHistoryEntry(t, repo, alice, matrix, date(2024, 1, 1), nil)
+	createHistoryEntry(t, repo, alice, wick, date(2024, 1, 2), nil)
+	createHistoryEntry(t, repo, bob, matrix, date(2024, 1, 3), nil)
+	addToWatchlist(t, repo, alice, matrix, 0, date(2024, 1, 1))
+	addToWatchlist(t, repo, alice, wick, 0, date(2024, 1, 2))
+	addToWatchlist(t, repo, alice, speed, 0, date(2024, 1, 3))
+	addToWatchlist(t, repo, bob, wick, 0, date(2024, 1, 3))
+
+	if err := repo.DeleteFilm(ctx, wick.ID, entities.AnyVersion); err != nil {
+		t.Fatalf("DeleteFilm() error = %v", err)
+	}
+	assertIDs(t, "watchlist after deleting a film", watchlistIDs(t, repo, alice), []string{matrix.ID, speed.ID})
+	entries, _, err := repo.GetHistory(ctx, entities.WatchQuery{ListQuery: page(10, 0), UserID: alice.ID})
+	if err != nil {
+		t.Fatalf("GetHistory() error = %v", err)
+	}
+	assertIDs(t, "history after deleting a film", historyIDs(entries), []string{kept.ID})
+
+	if err := repo.DeleteUser(ctx, bob.ID, entities.AnyVersion); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	assertIDs(t, "watchlist of a deleted user", watchlistIDs(t, repo, bob), []string{})
+	entries, total, err := repo.GetHistory(ctx, entities.WatchQuery{ListQuery: page(10, 0), UserID: bob.ID})
+	if err != nil {
+		t.Fatalf("GetHistory() error = %v", err)
+	}
+	if total != 0 || len(entries) != 0 {
+		t.Errorf("history of a deleted user = %v, want empty", entries)
+	}
+
+	// A new film still goes to the end of a list that lost an entry.
+	addToWatchlist(t, repo, alice, heat, 0, date(2024, 2, 1))
+	assertIDs(t, "watchlist", watchlistIDs(t, repo, alice), []string{matrix.ID, speed.ID, heat.ID})
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"filmography/internal/entities"
+	"fmt"
+	"time"
+)
+
+const selectReviews = `SELECT r.id, r.film_id, r.user_id, u.username, r.text, r.status, r.created_at, r.updated_at, r.moderator_id, r.moderated_at
+FROM reviews r JOIN users u ON u.id = r.user_id`
+
+func (r Repo) CreateReview(ctx context.Context, review entities.ReviewEntity) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := r.exists(queryCtx, "films", review.FilmID)
+	if err != nil {
+		return fmt.Errorf("film %q: %w", review.FilmID, err)
+	}
+
+	_, err = r.db.ExecContext(queryCtx, "INSERT INTO reviews (id, film_id, user_id, text, status, created_at, updated_at) VALUES($1, $2, $3, $4, $5, $6, $7)",
+		review.ID, review.FilmID, review.UserID, review.Text, review.Status, review.CreatedAt, review.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
+	}
+
+	return nil
+}
+
+// GetReviews lists the reviews matching the query. A review list of a
+// film that does not exist is reported as not found rather than empty.
+func (r Repo) GetReviews(ctx context.Context, query entities.ReviewQuery) ([]entities.ReviewEntity, int, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	b := listBuilder{}
+	if query.FilmID != "" {
+		err := r.exists(queryCtx, "films", query.FilmID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("film %q: %w", query.FilmID, err)
+		}
+		b.add("r.film_id = ?", query.FilmID)
+	}
+	if query.UserID != "" {
+		b.add("r.user_id = ?", query.UserID)
+	}
+	if query.Status != "" {
+		b.add("r.status = ?", query.Status)
+	}
+
+	var total int
+	err := r.db.QueryRowContext(queryCtx, "SELECT COUNT(*) FROM reviews r"+b.whereClause(), b.args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count failed: %w", r.dbError(err))
+	}
+
+	b.seek("r.", query.ListQuery, entities.ReviewSortFields)
+	page := selectReviews + b.whereClause() + orderClause("r.", query.Sort, entities.ReviewSortFields) + b.pageClause(query.ListQuery)
+	rows, err := r.db.QueryContext(queryCtx, page, b.args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query context failed: %w", r.dbError(err))
+	}
+	defer rows.Close()
+
+	reviews := make([]entities.ReviewEntity, 0)
+
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan failed: %w", err)
+		}
+
+		reviews = append(reviews, review)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return reviews, total, nil
+}
+
+func (r Repo) GetReview(ctx context.Context, id string) (entities.ReviewEntity, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	review, err := scanReview(r.db.QueryRowContext(queryCtx, selectReviews+" WHERE r.id = $1", id))
+	if err != nil {
+		return entities.ReviewEntity{}, fmt.Errorf("scan failed: %w", r.dbError(err))
+	}
+
+	return review, nil
+}
+
+// UpdateReview stores the text, the status and the moderation of the
+// review.
+func (r Repo) UpdateReview(ctx context.Context, review entities.ReviewEntity) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	moderatorID := sql.NullString{String: review.ModeratorID, Valid: review.ModeratorID != ""}
+	res, err := r.db.ExecContext(queryCtx, "UPDATE reviews SET text = $1, status = $2, updated_at = $3, moderator_id = $4, moderated_at = $5 WHERE id = $6",
+		review.Text, review.Status, review.UpdatedAt, moderatorID, review.ModeratedAt, review.ID)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return fmt.Errorf("review %q: %w", review.ID, entities.ErrNotFound)
+	}
+	return nil
+}
+
+func (r Repo) DeleteReview(ctx context.Context, id string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(queryCtx, "DELETE FROM reviews WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return fmt.Errorf("review %q: %w", id, entities.ErrNotFound)
+	}
+	return nil
+}
+
+// scanReview reads a row selected by selectReviews.
+func scanReview(row interface{ Scan(dest ...any) error }) (entities.ReviewEntity, error) {
+	review := entities.ReviewEntity{}
+	var moderatorID sql.NullString
+	var moderatedAt sql.NullTime
+	err := row.Scan(&review.ID, &review.FilmID, &review.UserID, &review.Author, &review.Text, &review.Status,
+		&review.CreatedAt, &review.UpdatedAt, &moderatorID, &moderatedAt)
+	if err != nil {
+		return entities.ReviewEntity{}, err
+	}
+	review.ModeratorID = moderatorID.String
+	if moderatedAt.Valid {
+		review.ModeratedAt = &moderatedAt.Time
+	}
+	return review, nil
+}
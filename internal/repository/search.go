@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// searchQuery ranks the films and the actors. The snippets are HTML, the
+// text is escaped before ts_headline adds the <mark> tags, the parser
+// keeps the entities as they are.
+var searchQuery = `WITH q AS (SELECT to_tsquery('simple', $1) AS query)
+SELECT 'film', f.id::text, coalesce(f.title, ''),
+       ts_headline('simple', ` + escapeHTML(`coalesce(f.title, '') || ' — ' || coalesce(f.description, '')`) + `, q.query, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2'),
+       ts_rank(f.search, q.query) AS rank
+FROM films f, q
+WHERE f.search @@ q.query
+UNION ALL
+SELECT 'actor', a.id::text, coalesce(a.name, ''),
+       ts_headline('simple', ` + escapeHTML(`coalesce(a.name, '')`) + `, q.query, 'StartSel=<mark>, StopSel=</mark>'),
+       ts_rank(a.search, q.query) AS rank
+FROM people a, q
+WHERE a.search @@ q.query
+ORDER BY rank DESC
+LIMIT $2`
+
+// escapeHTML wraps the SQL expression in the replacements of
+// html.EscapeString, the ampersand goes first.
+func escapeHTML(expr string) string {
+	replacements := [][2]string{
+		{`'&'`, `'&amp;'`},
+		{`'<'`, `'&lt;'`},
+		{`'>'`, `'&gt;'`},
+		{`'"'`, `'&#34;'`},
+		{`''''`, `'&#39;'`},
+	}
+	for _, r := range replacements {
+		expr = "replace(" + expr + ", " + r[0] + ", " + r[1] + ")"
+	}
+	return expr
+}
+
+// Search looks films and actors up through their tsvector columns. Every
+// word of the query has to match as a prefix.
+func (r Postgres) Search(ctx context.Context, query string, limit int) ([]entities.SearchResult, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tsQuery := prefixTsQuery(query)
+	if tsQuery == "" {
+		return make([]entities.SearchResult, 0), nil
+	}
+
+	rows, err := r.db.QueryContext(queryCtx, searchQuery, tsQuery, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query context failed: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]entities.SearchResult, 0)
+
+	for rows.Next() {
+		result := entities.SearchResult{}
+		err := rows.Scan(&result.Type, &result.ID, &result.Title, &result.Snippet, &result.Rank)
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return results, nil
+}
+
+// prefixTsQuery turns free text into a tsquery of prefix terms joined with
+// AND, dropping everything that is not a letter or a digit.
+func prefixTsQuery(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, word+":*")
+	}
+	return strings.Join(terms, " & ")
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"database/sql"
+	"html"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// TestEscapeHTML runs the escaping on SQLite, replace() works the same
+// there as in Postgres.
+func TestEscapeHTML(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	defer db.Close()
+
+	for _, text := range []string{
+		"Heat",
+		`<script>alert("x")</script>`,
+		"Tom & Jerry's <b>",
+		"&amp; already escaped",
+	} {
+		var got string
+		err := db.QueryRow("SELECT "+escapeHTML("?"), text).Scan(&got)
+		if err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		if want := html.EscapeString(text); got != want {
+			t.Errorf("escapeHTML(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS actors_films;
+
+DROP TABLE IF EXISTS actors;
+
+DROP TABLE IF EXISTS films;
+
+DROP TABLE IF EXISTS users;
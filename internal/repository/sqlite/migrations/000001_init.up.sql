@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS actors
+(
+    id       text primary key,
+    name     varchar(255) not null,
+    gender   varchar(10)  not null default '',
+    birthday date
+);
+
+CREATE TABLE IF NOT EXISTS films
+(
+    id           text primary key,
+    title        varchar(255)  not null,
+    description  varchar(1000) not null default '',
+    release_date date,
+    rating       decimal(4, 2) check ( rating >= 0 and rating <= 10 )
+);
+
+CREATE TABLE IF NOT EXISTS actors_films
+(
+    actor_id text not null references actors (id) on delete cascade,
+    film_id  text not null references films (id) on delete cascade,
+    primary key (actor_id, film_id)
+);
+
+CREATE INDEX IF NOT EXISTS actors_films_film_id_idx ON actors_films (film_id);
+
+CREATE TABLE IF NOT EXISTS users
+(
+    id            text primary key,
+    username      varchar(255) not null unique,
+    role          varchar(5)   not null check ( role in ('admin', 'user') ),
+    password_hash varchar(255) not null default ''
+);
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS film_ratings_delete;
+DROP TRIGGER IF EXISTS film_ratings_update;
+DROP TRIGGER IF EXISTS film_ratings_insert;
+
+ALTER TABLE films DROP COLUMN rating_count;
+ALTER TABLE films DROP COLUMN rating_sum;
+
+DROP TABLE IF EXISTS film_rating_history;
+DROP TABLE IF EXISTS film_ratings;
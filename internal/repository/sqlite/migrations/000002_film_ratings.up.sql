@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS film_ratings
+(
+    user_id  text          not null references users (id) on delete cascade,
+    film_id  text          not null references films (id) on delete cascade,
+    rating   decimal(4, 2) not null check ( rating >= 0 and rating <= 10 ),
+    rated_at timestamp     not null,
+    primary key (user_id, film_id)
+);
+
+CREATE INDEX IF NOT EXISTS film_ratings_film_id_idx ON film_ratings (film_id);
+
+-- Every rating a user has given, film_ratings keeps only the latest one.
+CREATE TABLE IF NOT EXISTS film_rating_history
+(
+    id       integer primary key autoincrement,
+    user_id  text          not null references users (id) on delete cascade,
+    film_id  text          not null references films (id) on delete cascade,
+    rating   decimal(4, 2) not null,
+    rated_at timestamp     not null
+);
+
+CREATE INDEX IF NOT EXISTS film_rating_history_user_id_idx ON film_rating_history (user_id, rated_at);
+
+-- The aggregate of the user ratings is kept on the film, the triggers
+-- follow every change of film_ratings including cascaded deletes.
+ALTER TABLE films ADD COLUMN rating_sum decimal(12, 2) not null default 0;
+ALTER TABLE films ADD COLUMN rating_count integer not null default 0;
+
+CREATE TRIGGER IF NOT EXISTS film_ratings_insert
+    AFTER INSERT
+    ON film_ratings
+BEGIN
+    UPDATE films SET rating_sum = rating_sum + NEW.rating, rating_count = rating_count + 1 WHERE id = NEW.film_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS film_ratings_update
+    AFTER UPDATE
+    ON film_ratings
+BEGIN
+    UPDATE films SET rating_sum = rating_sum - OLD.rating, rating_count = rating_count - 1 WHERE id = OLD.film_id;
+    UPDATE films SET rating_sum = rating_sum + NEW.rating, rating_count = rating_count + 1 WHERE id = NEW.film_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS film_ratings_delete
+    AFTER DELETE
+    ON film_ratings
+BEGIN
+    UPDATE films SET rating_sum = rating_sum - OLD.rating, rating_count = rating_count - 1 WHERE id = OLD.film_id;
+END;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS reviews
+(
+    id           text primary key,
+    film_id      text       not null references films (id) on delete cascade,
+    user_id      text       not null references users (id) on delete cascade,
+    text         text       not null,
+    status       varchar(9) not null default 'pending' check ( status in ('pending', 'published', 'rejected') ),
+    created_at   timestamp  not null,
+    updated_at   timestamp  not null,
+    moderator_id text references users (id) on delete set null,
+    moderated_at timestamp,
+    unique (film_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS reviews_film_id_status_idx ON reviews (film_id, status);
+CREATE INDEX IF NOT EXISTS reviews_user_id_idx ON reviews (user_id);
+CREATE INDEX IF NOT EXISTS reviews_status_created_at_idx ON reviews (status, created_at);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS watchlist_close_gap;
+DROP TABLE IF EXISTS watch_history;
+DROP TABLE IF EXISTS watchlist;
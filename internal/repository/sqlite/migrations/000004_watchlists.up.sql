@@ -0,0 +1,33 @@
+-- The films a user plans to watch, in the order the user keeps them.
+CREATE TABLE IF NOT EXISTS watchlist
+(
+    user_id  text      not null references users (id) on delete cascade,
+    film_id  text      not null references films (id) on delete cascade,
+    position integer   not null check ( position > 0 ),
+    added_at timestamp not null,
+    primary key (user_id, film_id)
+);
+
+CREATE INDEX IF NOT EXISTS watchlist_film_id_idx ON watchlist (film_id);
+
+-- Positions have no gaps, the trigger closes the one a removed entry
+-- leaves, including the entries removed with their film.
+CREATE TRIGGER IF NOT EXISTS watchlist_close_gap
+    AFTER DELETE
+    ON watchlist
+BEGIN
+    UPDATE watchlist SET position = position - 1 WHERE user_id = OLD.user_id AND position > OLD.position;
+END;
+
+-- Every viewing of a film, a film may be watched more than once.
+CREATE TABLE IF NOT EXISTS watch_history
+(
+    id         text primary key,
+    user_id    text          not null references users (id) on delete cascade,
+    film_id    text          not null references films (id) on delete cascade,
+    watched_at date          not null,
+    rating     decimal(4, 2) check ( rating >= 0 and rating <= 10 )
+);
+
+CREATE INDEX IF NOT EXISTS watch_history_user_id_idx ON watch_history (user_id, watched_at);
+CREATE INDEX IF NOT EXISTS watch_history_film_id_idx ON watch_history (film_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS film_tags;
+DROP TABLE IF EXISTS genres_films;
+DROP TABLE IF EXISTS genres;
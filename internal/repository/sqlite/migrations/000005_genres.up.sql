@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS genres
+(
+    id   text primary key,
+    name varchar(50) not null
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS genres_name_idx ON genres (lower(name));
+
+CREATE TABLE IF NOT EXISTS genres_films
+(
+    genre_id text not null references genres (id) on delete cascade,
+    film_id  text not null references films (id) on delete cascade,
+    primary key (genre_id, film_id)
+);
+
+CREATE INDEX IF NOT EXISTS genres_films_film_id_idx ON genres_films (film_id);
+
+CREATE TABLE IF NOT EXISTS film_tags
+(
+    film_id text        not null references films (id) on delete cascade,
+    tag     varchar(50) not null,
+    primary key (film_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS film_tags_tag_idx ON film_tags (tag);
@@ -0,0 +1,19 @@
+-- Only the actor credits survive, without character and billing.
+ALTER TABLE people
+    RENAME TO actors;
+
+CREATE TABLE IF NOT EXISTS actors_films
+(
+    actor_id text not null references actors (id) on delete cascade,
+    film_id  text not null references films (id) on delete cascade,
+    primary key (actor_id, film_id)
+);
+
+CREATE INDEX IF NOT EXISTS actors_films_film_id_idx ON actors_films (film_id);
+
+INSERT INTO actors_films (actor_id, film_id)
+SELECT person_id, film_id
+FROM credits
+WHERE role = 'actor';
+
+DROP TABLE credits;
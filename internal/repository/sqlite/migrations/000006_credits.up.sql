@@ -0,0 +1,22 @@
+-- Actors become people of any role, the links of actors_films are kept
+-- as actor credits ordered by name like before.
+ALTER TABLE actors
+    RENAME TO people;
+
+CREATE TABLE IF NOT EXISTS credits
+(
+    film_id        text         not null references films (id) on delete cascade,
+    person_id      text         not null references people (id) on delete cascade,
+    role           varchar(8)   not null check ( role in ('director', 'writer', 'producer', 'composer', 'actor') ),
+    character_name varchar(150) not null default '',
+    billing        integer      not null default 0 check ( billing >= 0 ),
+    primary key (film_id, person_id, role)
+);
+
+CREATE INDEX IF NOT EXISTS credits_person_id_idx ON credits (person_id);
+
+INSERT INTO credits (film_id, person_id, role)
+SELECT film_id, actor_id, 'actor'
+FROM actors_films;
+
+DROP TABLE actors_films;
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN version;
+ALTER TABLE people DROP COLUMN version;
+ALTER TABLE films DROP COLUMN version;
@@ -0,0 +1,5 @@
+-- The version counts the edits of a record, conditional writes compare it
+-- to the one the client has seen.
+ALTER TABLE films ADD COLUMN version integer not null default 1;
+ALTER TABLE people ADD COLUMN version integer not null default 1;
+ALTER TABLE users ADD COLUMN version integer not null default 1;
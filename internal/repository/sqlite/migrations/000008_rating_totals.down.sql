@@ -0,0 +1,27 @@
+DROP TRIGGER IF EXISTS film_ratings_insert;
+DROP TRIGGER IF EXISTS film_ratings_update;
+DROP TRIGGER IF EXISTS film_ratings_delete;
+
+CREATE TRIGGER film_ratings_insert
+    AFTER INSERT
+    ON film_ratings
+BEGIN
+    UPDATE films SET rating_sum = rating_sum + NEW.rating, rating_count = rating_count + 1 WHERE id = NEW.film_id;
+END;
+
+CREATE TRIGGER film_ratings_update
+    AFTER UPDATE
+    ON film_ratings
+BEGIN
+    UPDATE films SET rating_sum = rating_sum - OLD.rating, rating_count = rating_count - 1 WHERE id = OLD.film_id;
+    UPDATE films SET rating_sum = rating_sum + NEW.rating, rating_count = rating_count + 1 WHERE id = NEW.film_id;
+END;
+
+CREATE TRIGGER film_ratings_delete
+    AFTER DELETE
+    ON film_ratings
+BEGIN
+    UPDATE films SET rating_sum = rating_sum - OLD.rating, rating_count = rating_count - 1 WHERE id = OLD.film_id;
+END;
+
+DROP TABLE IF EXISTS rating_totals;
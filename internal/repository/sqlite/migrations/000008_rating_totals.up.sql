@@ -0,0 +1,42 @@
+-- The totals of all user ratings weight the rating of every film that is
+-- read, the triggers keep them in a single row next to the aggregate on
+-- the film instead of adding up the films on each read.
+CREATE TABLE IF NOT EXISTS rating_totals
+(
+    id           integer primary key default 1 check ( id = 1 ),
+    rating_sum   decimal(14, 2) not null default 0,
+    rating_count integer        not null default 0
+);
+
+INSERT OR IGNORE INTO rating_totals (id, rating_sum, rating_count)
+SELECT 1, COALESCE(SUM(rating), 0), COUNT(*)
+FROM film_ratings;
+
+DROP TRIGGER IF EXISTS film_ratings_insert;
+DROP TRIGGER IF EXISTS film_ratings_update;
+DROP TRIGGER IF EXISTS film_ratings_delete;
+
+CREATE TRIGGER film_ratings_insert
+    AFTER INSERT
+    ON film_ratings
+BEGIN
+    UPDATE films SET rating_sum = rating_sum + NEW.rating, rating_count = rating_count + 1 WHERE id = NEW.film_id;
+    UPDATE rating_totals SET rating_sum = rating_sum + NEW.rating, rating_count = rating_count + 1;
+END;
+
+CREATE TRIGGER film_ratings_update
+    AFTER UPDATE
+    ON film_ratings
+BEGIN
+    UPDATE films SET rating_sum = rating_sum - OLD.rating, rating_count = rating_count - 1 WHERE id = OLD.film_id;
+    UPDATE films SET rating_sum = rating_sum + NEW.rating, rating_count = rating_count + 1 WHERE id = NEW.film_id;
+    UPDATE rating_totals SET rating_sum = rating_sum - OLD.rating + NEW.rating;
+END;
+
+CREATE TRIGGER film_ratings_delete
+    AFTER DELETE
+    ON film_ratings
+BEGIN
+    UPDATE films SET rating_sum = rating_sum - OLD.rating, rating_count = rating_count - 1 WHERE id = OLD.film_id;
+    UPDATE rating_totals SET rating_sum = rating_sum - OLD.rating, rating_count = rating_count - 1;
+END;
@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"filmography/config"
+	"filmography/internal/entities"
+	"filmography/internal/repository"
+	"fmt"
+	"regexp"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	gosqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// Repo stores the catalogue in an SQLite database file with the queries of
+// repository.Repo. It has no search index, the service falls back to its
+// in-memory search.
+type Repo struct {
+	repository.Repo
+	db          *sql.DB
+	migratePath string
+}
+
+func New(cfg config.Config) (Repo, error) {
+	db, err := sql.Open("sqlite3", "file:"+cfg.SQLitePath+"?_foreign_keys=on&_busy_timeout=5000")
+	if err != nil {
+		return Repo{}, fmt.Errorf("open failed: %w", err)
+	}
+	// SQLite allows one writer at a time, a single connection keeps
+	// transactions from failing with "database is locked". It serializes
+	// the transactions as well, so the dialect needs no row locks.
+	db.SetMaxOpenConns(1)
+
+	err = db.Ping()
+	if err != nil {
+		return Repo{}, fmt.Errorf("ping failed: %w", err)
+	}
+
+	repo := Repo{
+		Repo:        repository.NewRepo(db, dialect{}),
+		db:          db,
+		migratePath: cfg.SQLiteMigratePath,
+	}
+	if !cfg.AutoMigrate {
+		return repo, nil
+	}
+
+	m, err := repo.Migrate()
+	if err != nil {
+		return Repo{}, fmt.Errorf("migrate failed: %w", err)
+	}
+
+	err = m.Up()
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return Repo{}, fmt.Errorf("migrate up failed: %w", err)
+	}
+
+	return repo, nil
+}
+
+// Migrate returns the schema migrations of the database. Closing them
+// closes the repository as well.
+func (r Repo) Migrate() (*migrate.Migrate, error) {
+	driver, err := sqlite3.WithInstance(r.db, &sqlite3.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("with instance failed: %w", err)
+	}
+
+	m, err := migrate.NewWithDatabaseInstance(r.migratePath, "sqlite3", driver)
+	if err != nil {
+		return nil, fmt.Errorf("new with database instance failed: %w", err)
+	}
+	return m, nil
+}
+
+// placeholder matches the $N placeholders of the Postgres queries.
+var placeholder = regexp.MustCompile(`\$([0-9]+)`)
+
+// dialect rewrites the queries for SQLite.
+type dialect struct{}
+
+// Rebind turns $N into ?N, which SQLite binds by position as well.
+func (dialect) Rebind(query string) string {
+	return placeholder.ReplaceAllString(query, "?${1}")
+}
+
+func (dialect) RowLock() string {
+	return ""
+}
+
+func (dialect) Date(expr string) string {
+	return "date(" + expr + ")"
+}
+
+func (dialect) Error(err error) error {
+	var sqliteErr gosqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+
+	switch sqliteErr.ExtendedCode {
+	case gosqlite3.ErrConstraintUnique, gosqlite3.ErrConstraintPrimaryKey:
+		return fmt.Errorf("%w: %w", entities.ErrConflict, err)
+	case gosqlite3.ErrConstraintForeignKey:
+		return fmt.Errorf("%w: %w", entities.ErrInvalidReference, err)
+	}
+	return err
+}
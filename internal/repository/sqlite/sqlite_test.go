@@ -0,0 +1,26 @@
+package sqlite
+
+import (
+	"filmography/config"
+	"filmography/internal/repository/repotest"
+	"filmography/service"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepo(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) service.Repo {
+		repo, err := New(config.Config{
+			SQLitePath:        filepath.Join(t.TempDir(), "filmography.db"),
+			SQLiteMigratePath: "file://migrations",
+			AutoMigrate:       true,
+		})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		t.Cleanup(func() {
+			repo.Close()
+		})
+		return repo
+	})
+}
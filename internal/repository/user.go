@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"filmography/internal/entities"
 	"fmt"
 	"time"
@@ -11,21 +13,35 @@ func (r Repo) CreateUser(ctx context.Context, user entities.UserEntity) error {
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	row := r.db.QueryRowContext(queryCtx, "INSERT INTO users (id, username) VALUES($1, $2)", user.ID, user.Username)
-	if row.Err() != nil {
-		return fmt.Errorf("query row context order failed: %w", row.Err())
+	_, err := r.db.ExecContext(queryCtx, "INSERT INTO users (id, username, role, password_hash) VALUES($1, $2, $3, $4)", user.ID, user.Username, user.Role, user.PasswordHash)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
 	}
 
 	return nil
 }
 
-func (r Repo) GetUsers(ctx context.Context) ([]entities.UserEntity, error) {
+func (r Repo) GetUsers(ctx context.Context, query entities.UserQuery) ([]entities.UserEntity, int, error) {
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	rows, err := r.db.QueryContext(queryCtx, "SELECT * FROM users")
+	b := listBuilder{}
+	if query.Role != "" {
+		b.add("role = ?", query.Role)
+	}
+
+	var total int
+	err := r.db.QueryRowContext(queryCtx, "SELECT COUNT(*) FROM users"+b.whereClause(), b.args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count failed: %w", err)
+	}
+
+	b.seek("", query.ListQuery, entities.UserSortFields)
+	page := "SELECT id, username, role, password_hash, version FROM users" + b.whereClause() +
+		orderClause("", query.Sort, entities.UserSortFields) + b.pageClause(query.ListQuery)
+	rows, err := r.db.QueryContext(queryCtx, page, b.args...)
 	if err != nil {
-		return nil, fmt.Errorf("query context failed: %w", err)
+		return nil, 0, fmt.Errorf("query context failed: %w", err)
 	}
 	defer rows.Close()
 
@@ -33,29 +49,53 @@ func (r Repo) GetUsers(ctx context.Context) ([]entities.UserEntity, error) {
 
 	for rows.Next() {
 		user := entities.UserEntity{}
-		err := rows.Scan(&user.ID, &user.Username)
+		err := rows.Scan(&user.ID, &user.Username, &user.Role, &user.PasswordHash, &user.Version)
 		if err != nil {
-			return nil, fmt.Errorf("scan failed: %w", err)
+			return nil, 0, fmt.Errorf("scan failed: %w", err)
 		}
 
 		users = append(users, user)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows failed: %w", err)
+	}
 
-	return users, nil
+	return users, total, nil
 }
 
 func (r Repo) GetUser(ctx context.Context, id string) (entities.UserEntity, error) {
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	row := r.db.QueryRowContext(queryCtx, "SELECT * FROM users")
+	row := r.db.QueryRowContext(queryCtx, "SELECT id, username, role, password_hash, version FROM users WHERE id = $1", id)
 	if row.Err() != nil {
-		return entities.UserEntity{}, fmt.Errorf("query context failed: %w", row.Err())
+		return entities.UserEntity{}, fmt.Errorf("query context failed: %w", r.dbError(row.Err()))
 	}
 
 	user := entities.UserEntity{}
-	err := row.Scan(&user.ID, &user.Username)
+	err := row.Scan(&user.ID, &user.Username, &user.Role, &user.PasswordHash, &user.Version)
 	if err != nil {
+		return entities.UserEntity{}, fmt.Errorf("scan failed: %w", r.dbError(err))
+	}
+
+	return user, nil
+}
+
+func (r Repo) GetUserByUsername(ctx context.Context, username string) (entities.UserEntity, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	row := r.db.QueryRowContext(queryCtx, "SELECT id, username, role, password_hash, version FROM users WHERE username = $1", username)
+	if row.Err() != nil {
+		return entities.UserEntity{}, fmt.Errorf("query context failed: %w", r.dbError(row.Err()))
+	}
+
+	user := entities.UserEntity{}
+	err := row.Scan(&user.ID, &user.Username, &user.Role, &user.PasswordHash, &user.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.UserEntity{}, entities.ErrUserNotFound
+		}
 		return entities.UserEntity{}, fmt.Errorf("scan failed: %w", err)
 	}
 
@@ -66,9 +106,30 @@ func (r Repo) UpdateUser(ctx context.Context, id string, user entities.UserEntit
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	res, err := r.db.ExecContext(queryCtx, "UPDATE users SET username = $1  WHERE id = $2", user.Username, id)
+	res, err := r.db.ExecContext(queryCtx, "UPDATE users SET username = $1, role = $2, version = version + 1 WHERE id = $3 AND ($4 = 0 OR version = $4)",
+		user.Username, user.Role, id, user.Version)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return fmt.Errorf("user %q: %w", id, versionError(queryCtx, r.db, "users", id))
+	}
+	return nil
+}
+
+// UpdateUserPassword replaces the password hash of the user.
+func (r Repo) UpdateUserPassword(ctx context.Context, id string, passwordHash string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(queryCtx, "UPDATE users SET password_hash = $1 WHERE id = $2", passwordHash, id)
 	if err != nil {
-		return fmt.Errorf("exec context failed: %w", err)
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
 	}
 
 	num, err := res.RowsAffected()
@@ -76,18 +137,18 @@ func (r Repo) UpdateUser(ctx context.Context, id string, user entities.UserEntit
 		return fmt.Errorf("rows affected failed: %w", err)
 	}
 	if num == 0 {
-		return fmt.Errorf("user does not exists")
+		return fmt.Errorf("user %q: %w", id, entities.ErrNotFound)
 	}
 	return nil
 }
 
-func (r Repo) DeleteUser(ctx context.Context, id string) error {
+func (r Repo) DeleteUser(ctx context.Context, id string, version int) error {
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	res, err := r.db.ExecContext(queryCtx, "DELETE FROM users WHERE id = $1", id)
+	res, err := r.db.ExecContext(queryCtx, "DELETE FROM users WHERE id = $1 AND ($2 = 0 OR version = $2)", id, version)
 	if err != nil {
-		return fmt.Errorf("exec context failed: %w", err)
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
 	}
 
 	num, err := res.RowsAffected()
@@ -95,7 +156,7 @@ func (r Repo) DeleteUser(ctx context.Context, id string) error {
 		return fmt.Errorf("rows affected failed: %w", err)
 	}
 	if num == 0 {
-		return fmt.Errorf("user does not exists")
+		return fmt.Errorf("user %q: %w", id, versionError(queryCtx, r.db, "users", id))
 	}
 	return nil
 }
@@ -0,0 +1,315 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmography/internal/entities"
+	"fmt"
+	"time"
+)
+
+// watchlistEntries and historyEntries expose the film_id of a watchlist
+// entry and the id of a viewing as id, the tie-breaker of orderClause.
+const (
+	watchlistEntries = `(SELECT w.film_id AS id, w.user_id, f.title, f.release_date, w.position, w.added_at
+FROM watchlist w JOIN films f ON f.id = w.film_id) e`
+	historyEntries = `(SELECT h.id, h.user_id, h.film_id, f.title, h.watched_at, h.rating
+FROM watch_history h JOIN films f ON f.id = h.film_id) e`
+)
+
+// AddToWatchlist puts the film on the watchlist of the user at the
+// position of entry, moving the entries behind it down. A zero position
+// appends a new entry and keeps an existing one where it is.
+func (r Repo) AddToWatchlist(ctx context.Context, entry entities.WatchlistEntry) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := r.exists(queryCtx, "films", entry.FilmID)
+	if err != nil {
+		return fmt.Errorf("film %q: %w", entry.FilmID, err)
+	}
+
+	tx, err := r.db.BeginTx(queryCtx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the user serializes the changes of the watchlist, so that
+	// the positions stay consistent. An unknown user is left to the
+	// foreign key.
+	err = tx.lockRow(queryCtx, "users", entry.UserID)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	err = tx.QueryRowContext(queryCtx, "SELECT COUNT(*) FROM watchlist WHERE user_id = $1", entry.UserID).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("count failed: %w", r.dbError(err))
+	}
+
+	var current int
+	var addedAt time.Time
+	err = tx.QueryRowContext(queryCtx, "SELECT position, added_at FROM watchlist WHERE user_id = $1 AND film_id = $2", entry.UserID, entry.FilmID).Scan(&current, &addedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		count++
+	case err != nil:
+		return fmt.Errorf("scan failed: %w", r.dbError(err))
+	default:
+		entry.AddedAt = addedAt
+		// The watchlist_close_gap trigger moves up the entries behind.
+		_, err = tx.ExecContext(queryCtx, "DELETE FROM watchlist WHERE user_id = $1 AND film_id = $2", entry.UserID, entry.FilmID)
+		if err != nil {
+			return fmt.Errorf("exec context failed: %w", r.dbError(err))
+		}
+	}
+
+	position := watchlistPosition(entry.Position, current, count)
+	_, err = tx.ExecContext(queryCtx, "UPDATE watchlist SET position = position + 1 WHERE user_id = $1 AND position >= $2", entry.UserID, position)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
+	}
+	_, err = tx.ExecContext(queryCtx, "INSERT INTO watchlist (user_id, film_id, position, added_at) VALUES($1, $2, $3, $4)", entry.UserID, entry.FilmID, position, entry.AddedAt)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetWatchlist lists the watchlist of the user.
+func (r Repo) GetWatchlist(ctx context.Context, query entities.WatchQuery) ([]entities.WatchlistEntry, int, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	b := listBuilder{}
+	b.add("user_id = ?", query.UserID)
+	if query.FilmID != "" {
+		b.add("id = ?", query.FilmID)
+	}
+
+	var total int
+	err := r.db.QueryRowContext(queryCtx, "SELECT COUNT(*) FROM "+watchlistEntries+b.whereClause(), b.args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count failed: %w", r.dbError(err))
+	}
+
+	b.seek("", query.ListQuery, entities.WatchlistSortFields)
+	page := "SELECT id, user_id, title, release_date, position, added_at FROM " + watchlistEntries + b.whereClause() +
+		orderClause("", query.Sort, entities.WatchlistSortFields) + b.pageClause(query.ListQuery)
+	rows, err := r.db.QueryContext(queryCtx, page, b.args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query context failed: %w", r.dbError(err))
+	}
+	defer rows.Close()
+
+	entries := make([]entities.WatchlistEntry, 0)
+
+	for rows.Next() {
+		entry := entities.WatchlistEntry{}
+		err := rows.Scan(&entry.FilmID, &entry.UserID, &entry.Title, &entry.ReleaseDate, &entry.Position, &entry.AddedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan failed: %w", err)
+		}
+
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+// RemoveFromWatchlist takes the film off the watchlist of the user, the
+// watchlist_close_gap trigger moves up the entries behind it.
+func (r Repo) RemoveFromWatchlist(ctx context.Context, userID string, filmID string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(queryCtx, "DELETE FROM watchlist WHERE user_id = $1 AND film_id = $2", userID, filmID)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return fmt.Errorf("watchlist entry %q: %w", filmID, entities.ErrNotFound)
+	}
+	return nil
+}
+
+// CreateHistoryEntry records the viewing and takes the film off the
+// watchlist of the user in one transaction, so that a watched film never
+// stays on the list. The watchlist_close_gap trigger moves up the entries
+// behind it.
+func (r Repo) CreateHistoryEntry(ctx context.Context, entry entities.HistoryEntry) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := r.exists(queryCtx, "films", entry.FilmID)
+	if err != nil {
+		return fmt.Errorf("film %q: %w", entry.FilmID, err)
+	}
+
+	tx, err := r.db.BeginTx(queryCtx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The user is locked like in AddToWatchlist, the positions of the
+	// watchlist change.
+	err = tx.lockRow(queryCtx, "users", entry.UserID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(queryCtx, "INSERT INTO watch_history (id, user_id, film_id, watched_at, rating) VALUES($1, $2, $3, $4, $5)",
+		entry.ID, entry.UserID, entry.FilmID, entry.WatchedAt, entry.Rating)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
+	}
+	_, err = tx.ExecContext(queryCtx, "DELETE FROM watchlist WHERE user_id = $1 AND film_id = $2", entry.UserID, entry.FilmID)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetHistory lists the viewings of the user.
+func (r Repo) GetHistory(ctx context.Context, query entities.WatchQuery) ([]entities.HistoryEntry, int, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	b := listBuilder{}
+	b.add("user_id = ?", query.UserID)
+	if query.FilmID != "" {
+		b.add("film_id = ?", query.FilmID)
+	}
+
+	var total int
+	err := r.db.QueryRowContext(queryCtx, "SELECT COUNT(*) FROM "+historyEntries+b.whereClause(), b.args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count failed: %w", r.dbError(err))
+	}
+
+	b.seek("", query.ListQuery, entities.HistorySortFields)
+	page := "SELECT id, user_id, film_id, title, watched_at, rating FROM " + historyEntries + b.whereClause() +
+		orderClause("", query.Sort, entities.HistorySortFields) + b.pageClause(query.ListQuery)
+	rows, err := r.db.QueryContext(queryCtx, page, b.args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query context failed: %w", r.dbError(err))
+	}
+	defer rows.Close()
+
+	entries := make([]entities.HistoryEntry, 0)
+
+	for rows.Next() {
+		entry := entities.HistoryEntry{}
+		var rating sql.NullFloat64
+		err := rows.Scan(&entry.ID, &entry.UserID, &entry.FilmID, &entry.Title, &entry.WatchedAt, &rating)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan failed: %w", err)
+		}
+		if rating.Valid {
+			entry.Rating = &rating.Float64
+		}
+
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows failed: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+func (r Repo) GetHistoryEntry(ctx context.Context, userID string, id string) (entities.HistoryEntry, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	entry := entities.HistoryEntry{}
+	var rating sql.NullFloat64
+	err := r.db.QueryRowContext(queryCtx, "SELECT id, user_id, film_id, title, watched_at, rating FROM "+historyEntries+" WHERE id = $1 AND user_id = $2", id, userID).
+		Scan(&entry.ID, &entry.UserID, &entry.FilmID, &entry.Title, &entry.WatchedAt, &rating)
+	if err != nil {
+		return entities.HistoryEntry{}, fmt.Errorf("scan failed: %w", r.dbError(err))
+	}
+	if rating.Valid {
+		entry.Rating = &rating.Float64
+	}
+
+	return entry, nil
+}
+
+// UpdateHistoryEntry changes the date and the rating of a viewing, only
+// the user who watched may change it.
+func (r Repo) UpdateHistoryEntry(ctx context.Context, entry entities.HistoryEntry) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(queryCtx, "UPDATE watch_history SET watched_at = $1, rating = $2 WHERE id = $3 AND user_id = $4",
+		entry.WatchedAt, entry.Rating, entry.ID, entry.UserID)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return fmt.Errorf("history entry %q: %w", entry.ID, entities.ErrNotFound)
+	}
+	return nil
+}
+
+func (r Repo) DeleteHistoryEntry(ctx context.Context, userID string, id string) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(queryCtx, "DELETE FROM watch_history WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("exec context failed: %w", r.dbError(err))
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected failed: %w", err)
+	}
+	if num == 0 {
+		return fmt.Errorf("history entry %q: %w", id, entities.ErrNotFound)
+	}
+	return nil
+}
+
+// watchlistPosition is the position an entry takes on a watchlist of count
+// entries including it. A zero request keeps the current position, or
+// appends an entry that is new.
+func watchlistPosition(requested int, current int, count int) int {
+	if requested == 0 {
+		if current > 0 {
+			return current
+		}
+		return count
+	}
+	return min(requested, count)
+}
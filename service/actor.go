@@ -5,54 +5,110 @@ import (
 	"filmography/internal/entities"
 	"fmt"
 	"github.com/google/uuid"
+	"time"
+)
+
+var (
+	ErrActorNotFound = NewError(CodeActorNotFound, "actor not found")
 )
 
 type ActorService struct {
-	repo ActorRepoInterface
+	repo    ActorRepoInterface
+	weights ratingWeights
 }
 
 type ActorRepoInterface interface {
 	CreateActor(ctx context.Context, actor entities.ActorEntity) error
-	GetActors(ctx context.Context) ([]entities.ActorEntity, error)
+	GetActors(ctx context.Context, query entities.ActorQuery) ([]entities.ActorEntity, int, error)
 	GetActor(ctx context.Context, id string) (entities.ActorEntity, error)
+	GetFilmsByActor(ctx context.Context, actorID string) ([]entities.FilmEntity, error)
+	GetCreditsByPerson(ctx context.Context, personID string) ([]entities.PersonCredit, error)
 	UpdateActor(ctx context.Context, id string, actor entities.ActorEntity) error
-	DeleteActor(ctx context.Context, id string) error
+	DeleteActor(ctx context.Context, id string, version int) error
+	RatingTotalsRepo
 }
 
-func NewActorService(repo ActorRepoInterface) ActorService {
+func NewActorService(repo ActorRepoInterface, ratingPrior float64) ActorService {
 	return ActorService{
-		repo: repo,
+		repo:    repo,
+		weights: ratingWeights{repo: repo, prior: ratingPrior},
 	}
 }
 
 func (svc ActorService) CreateActor(ctx context.Context, actor entities.ActorEntity) error {
+	if fields := validateActor(actor); len(fields) > 0 {
+		return ValidationError(fields)
+	}
+
 	actor.ID = uuid.NewString()
 	err := svc.repo.CreateActor(ctx, actor)
-	return err
+	return repoError(err, ErrActorNotFound)
 }
 
-func (svc ActorService) GetActors(ctx context.Context) ([]entities.ActorEntity, error) {
-	actors, err := svc.repo.GetActors(ctx)
+func (svc ActorService) GetActors(ctx context.Context, query entities.ActorQuery) ([]entities.ActorEntity, int, error) {
+	actors, total, err := svc.repo.GetActors(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("get actors failed: %w", err)
+		return nil, 0, fmt.Errorf("get actors failed: %w", err)
 	}
-	return actors, err
+	return actors, total, err
 }
 
 func (svc ActorService) GetActor(ctx context.Context, id string) (entities.ActorEntity, error) {
 	actor, err := svc.repo.GetActor(ctx, id)
 	if err != nil {
-		return entities.ActorEntity{}, fmt.Errorf("get actor failed: %w", err)
+		return entities.ActorEntity{}, fmt.Errorf("get actor failed: %w", repoError(err, ErrActorNotFound))
 	}
 	return actor, err
 }
 
+func (svc ActorService) GetFilmsByActor(ctx context.Context, actorID string) ([]entities.FilmEntity, error) {
+	films, err := svc.repo.GetFilmsByActor(ctx, actorID)
+	if err != nil {
+		return nil, fmt.Errorf("get films by actor failed: %w", repoError(err, ErrActorNotFound))
+	}
+	err = svc.weights.apply(ctx, films)
+	if err != nil {
+		return nil, err
+	}
+	return films, nil
+}
+
+// GetActorCredits returns every credit of a person, in front of or
+// behind the camera, ordered by the release of the films.
+func (svc ActorService) GetActorCredits(ctx context.Context, actorID string) ([]entities.PersonCredit, error) {
+	credits, err := svc.repo.GetCreditsByPerson(ctx, actorID)
+	if err != nil {
+		return nil, fmt.Errorf("get credits by person failed: %w", repoError(err, ErrActorNotFound))
+	}
+	return credits, nil
+}
+
 func (svc ActorService) UpdateActor(ctx context.Context, id string, actor entities.ActorEntity) error {
+	if fields := validateActor(actor); len(fields) > 0 {
+		return ValidationError(fields)
+	}
+
 	err := svc.repo.UpdateActor(ctx, id, actor)
-	return err
+	return repoError(err, ErrActorNotFound)
 }
 
-func (svc ActorService) DeleteActor(ctx context.Context, id string) error {
-	err := svc.repo.DeleteActor(ctx, id)
-	return err
+// DeleteActor removes the actor if it is still at version, see
+// entities.AnyVersion.
+func (svc ActorService) DeleteActor(ctx context.Context, id string, version int) error {
+	err := svc.repo.DeleteActor(ctx, id, version)
+	return repoError(err, ErrActorNotFound)
+}
+
+func validateActor(actor entities.ActorEntity) []FieldError {
+	fields := make([]FieldError, 0)
+	if len(actor.Name) == 0 || len(actor.Name) > 255 {
+		fields = append(fields, FieldError{Field: "name", Message: "must be between 1 and 255 characters long"})
+	}
+	if len(actor.Gender) > 10 {
+		fields = append(fields, FieldError{Field: "gender", Message: "must be at most 10 characters long"})
+	}
+	if actor.Birthday.After(time.Now()) {
+		fields = append(fields, FieldError{Field: "birthday", Message: "must not be in the future"})
+	}
+	return fields
 }
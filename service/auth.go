@@ -1,38 +1,120 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"filmography/config"
 	"filmography/internal/entities"
 	"fmt"
-	"log"
 	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidCredentials = NewError(CodeInvalidCredentials, "wrong login or password")
 )
 
 type AuthService struct {
-	repo TokenRepo
-	cfg  config.Config
+	repo  TokenRepo
+	users UserService
+	cfg   config.Config
 }
 
 type TokenRepo interface {
 	AddToken(token string, expired time.Duration) error
 	GetToken(token string) bool
+	AddRefreshToken(id string, family string, expired time.Duration) error
+	UseRefreshToken(id string) (bool, error)
+	RevokeFamily(family string, expired time.Duration) error
+	IsFamilyRevoked(family string) bool
 }
 
-func NewAuthService(repo TokenRepo, cfg config.Config) AuthService {
+func NewAuthService(repo TokenRepo, users UserService, cfg config.Config) AuthService {
 	return AuthService{
-		repo: repo,
-		cfg:  cfg,
+		repo:  repo,
+		users: users,
+		cfg:   cfg,
 	}
 }
 
-func (svc AuthService) SingIn(authInfo entities.Auth) (*entities.Token, error) {
-	log.Printf("%+v\n", svc.cfg)
-	if authInfo.Login != svc.cfg.AdminLogin || authInfo.Password != svc.cfg.PostgresDBPassword {
-		return nil, entities.ErrWrongLoginOrPassword
+func (svc AuthService) SingIn(ctx context.Context, authInfo entities.Auth) (*entities.Token, error) {
+	user, err := svc.users.GetUserByUsername(ctx, authInfo.Login)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(authInfo.Password))
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	token, err := svc.issueToken(user.ID, user.Role, "")
+	if err != nil {
+		return nil, fmt.Errorf("issue token failed: %w", err)
+	}
+
+	return token, nil
+}
+
+// Refresh exchanges a refresh token for a new pair of the same family.
+// Every refresh token is single-use: presenting one that was already
+// rotated revokes the whole family and returns ErrTokenReused. The new
+// pair carries the current role of the user, the family of a deleted user
+// is revoked.
+func (svc AuthService) Refresh(ctx context.Context, refreshToken string) (*entities.Token, error) {
+	claims, err := svc.Verify(refreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("verify failed: %w", err)
+	}
+	if claims.Type != RefreshToken || claims.ID == "" || claims.Family == "" {
+		return nil, ErrWrongTokenType
 	}
 
+	if svc.repo.IsFamilyRevoked(claims.Family) {
+		return nil, ErrTokenRevoked
+	}
+
+	user, err := svc.users.GetUser(ctx, claims.UserID)
+	if errors.Is(err, ErrUserNotFound) {
+		err = svc.repo.RevokeFamily(claims.Family, svc.refreshTokenTTL())
+		if err != nil {
+			return nil, fmt.Errorf("revoke family failed: %w", err)
+		}
+		return nil, ErrTokenRevoked
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := svc.repo.UseRefreshToken(claims.ID)
+	if err != nil {
+		return nil, fmt.Errorf("use refresh token failed: %w", err)
+	}
+	if !ok {
+		err = svc.repo.RevokeFamily(claims.Family, svc.refreshTokenTTL())
+		if err != nil {
+			return nil, fmt.Errorf("revoke family failed: %w", err)
+		}
+		return nil, fmt.Errorf("user %s, family %s: %w", claims.UserID, claims.Family, ErrTokenReused)
+	}
+
+	token, err := svc.issueToken(user.ID, user.Role, claims.Family)
+	if err != nil {
+		return nil, fmt.Errorf("issue token failed: %w", err)
+	}
+
+	return token, nil
+}
+
+func (svc AuthService) issueToken(userID string, role entities.Role, family string) (*entities.Token, error) {
 	params := TokenParams{
-		Type:            Admin,
+		ID:              userID,
+		Role:            role,
+		Family:          family,
 		Hs256Secret:     svc.cfg.Hs256Secret,
 		AccessTokenExp:  svc.cfg.AccessTokenExp,
 		RefreshTokenExp: svc.cfg.RefreshTokenExp,
@@ -43,11 +125,88 @@ func (svc AuthService) SingIn(authInfo entities.Auth) (*entities.Token, error) {
 		return nil, fmt.Errorf("new token failed: %w", err)
 	}
 
+	err = svc.repo.AddRefreshToken(token.RTID, token.Family, svc.refreshTokenTTL())
+	if err != nil {
+		return nil, fmt.Errorf("add refresh token failed: %w", err)
+	}
+
 	return token, nil
 }
 
-func (svc AuthService) Logout(token string, expired time.Duration) error {
-	return svc.repo.AddToken(token, expired)
+func (svc AuthService) refreshTokenTTL() time.Duration {
+	return time.Duration(svc.cfg.RefreshTokenExp) * 24 * time.Hour
+}
+
+// SignUp registers a new account with the user role.
+func (svc AuthService) SignUp(ctx context.Context, authInfo entities.Auth) error {
+	user := entities.UserEntity{
+		Username: authInfo.Login,
+		Role:     entities.User,
+	}
+	return svc.users.CreateUser(ctx, user, authInfo.Password)
+}
+
+// IssueToken issues a token pair for the user without checking the
+// password. It is meant for administrative scripting only.
+func (svc AuthService) IssueToken(ctx context.Context, username string) (*entities.Token, error) {
+	user, err := svc.users.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := svc.issueToken(user.ID, user.Role, "")
+	if err != nil {
+		return nil, fmt.Errorf("issue token failed: %w", err)
+	}
+	return token, nil
+}
+
+// BootstrapAdmin creates the admin account from ADMIN_LOGIN and ADMIN_PASS
+// unless a user with that login already exists.
+func (svc AuthService) BootstrapAdmin(ctx context.Context) error {
+	if svc.cfg.AdminLogin == "" {
+		return nil
+	}
+
+	_, err := svc.users.GetUserByUsername(ctx, svc.cfg.AdminLogin)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+
+	admin := entities.UserEntity{
+		Username: svc.cfg.AdminLogin,
+		Role:     entities.Admin,
+	}
+	err = svc.users.CreateUser(ctx, admin, svc.cfg.AdminPass)
+	if err != nil {
+		return fmt.Errorf("create user failed: %w", err)
+	}
+	return nil
+}
+
+// Logout blacklists the access token and, when given, revokes the family
+// of the refresh token.
+func (svc AuthService) Logout(token string, refreshToken string, expired time.Duration) error {
+	err := svc.repo.AddToken(token, expired)
+	if err != nil {
+		return fmt.Errorf("add token failed: %w", err)
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+	claims, err := svc.Verify(refreshToken)
+	if err != nil || claims.Family == "" {
+		return nil
+	}
+	err = svc.repo.RevokeFamily(claims.Family, svc.refreshTokenTTL())
+	if err != nil {
+		return fmt.Errorf("revoke family failed: %w", err)
+	}
+	return nil
 }
 
 func (svc AuthService) CheckToken(userId string) bool {
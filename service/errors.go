@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"filmography/internal/entities"
+	"fmt"
+)
+
+// ErrorCode is a stable machine-readable identifier of an error returned
+// to API clients.
+type ErrorCode string
+
+const (
+	CodeMalformedRequest   ErrorCode = "malformed_request"
+	CodeValidationFailed   ErrorCode = "validation_failed"
+	CodeFilmNotFound       ErrorCode = "film_not_found"
+	CodeActorNotFound      ErrorCode = "actor_not_found"
+	CodeUserNotFound       ErrorCode = "user_not_found"
+	CodeRatingNotFound     ErrorCode = "rating_not_found"
+	CodeReviewNotFound     ErrorCode = "review_not_found"
+	CodeNotOnWatchlist     ErrorCode = "not_on_watchlist"
+	CodeHistoryNotFound    ErrorCode = "history_entry_not_found"
+	CodeGenreNotFound      ErrorCode = "genre_not_found"
+	CodeUnknownActor       ErrorCode = "unknown_actor"
+	CodeUnknownGenre       ErrorCode = "unknown_genre"
+	CodeConflict           ErrorCode = "conflict"
+	CodeInvalidReference   ErrorCode = "invalid_reference"
+	CodeUserExists         ErrorCode = "user_exists"
+	CodeReviewExists       ErrorCode = "review_exists"
+	CodeGenreExists        ErrorCode = "genre_exists"
+	CodeVersionMismatch    ErrorCode = "version_mismatch"
+	CodePatchConflict      ErrorCode = "patch_conflict"
+	CodeUnsupportedMedia   ErrorCode = "unsupported_media_type"
+	CodeInvalidCredentials ErrorCode = "invalid_credentials"
+	CodeTokenMissing       ErrorCode = "token_missing"
+	CodeTokenExpired       ErrorCode = "token_expired"
+	CodeTokenInvalid       ErrorCode = "token_invalid"
+	CodeTokenRevoked       ErrorCode = "token_revoked"
+	CodeTokenReused        ErrorCode = "token_reused"
+	CodeForbidden          ErrorCode = "forbidden"
+	CodeNotFound           ErrorCode = "not_found"
+	CodeMethodNotAllowed   ErrorCode = "method_not_allowed"
+	CodeTimeout            ErrorCode = "timeout"
+	CodeInternal           ErrorCode = "internal_error"
+)
+
+// FieldError describes why a single input field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is the error type the service layer reports to its callers. Code
+// and Message are safe to show to clients, Err is the internal cause and
+// is only meant for logs.
+type Error struct {
+	Code    ErrorCode
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func NewError(code ErrorCode, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func WrapError(code ErrorCode, message string, err error) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+// ValidationError reports the rejected fields of the input.
+func ValidationError(fields []FieldError) *Error {
+	return &Error{Code: CodeValidationFailed, Message: "request validation failed", Fields: fields}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches any *Error with the same code, so callers can compare with
+// the sentinels below regardless of the wrapped cause.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// ErrTimeout is reported when a request runs out of the time it is given.
+var ErrTimeout = NewError(CodeTimeout, "request timed out")
+
+// ErrVersionMismatch is reported when a conditional write finds the record
+// changed since the version the client has seen.
+var ErrVersionMismatch = NewError(CodeVersionMismatch, "record was changed since the given version")
+
+// repoError translates the repository sentinels into errors for clients,
+// notFound is reported when the requested record does not exist.
+func repoError(err error, notFound *Error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, entities.ErrNotFound):
+		return WrapError(notFound.Code, notFound.Message, err)
+	case errors.Is(err, entities.ErrConflict):
+		return WrapError(CodeConflict, "record conflicts with an existing one", err)
+	case errors.Is(err, entities.ErrInvalidReference):
+		return WrapError(CodeInvalidReference, "record references a missing one", err)
+	case errors.Is(err, entities.ErrVersionMismatch):
+		return WrapError(ErrVersionMismatch.Code, ErrVersionMismatch.Message, err)
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"filmography/internal/entities"
+	"fmt"
+	"io"
+)
+
+// ExportEntity selects what an export contains.
+type ExportEntity string
+
+const (
+	ExportFilms  ExportEntity = "films"
+	ExportActors ExportEntity = "actors"
+	ExportAll    ExportEntity = "all"
+
+	// exportWatchlist and exportHistory are the lists of a user, which
+	// are exported by WatchService alone.
+	exportWatchlist ExportEntity = "watchlist"
+	exportHistory   ExportEntity = "history"
+)
+
+type ExportService struct {
+	repo ExportRepoInterface
+}
+
+type ExportRepoInterface interface {
+	ExportFilms(ctx context.Context, fn func(film entities.CreditedFilm) error) error
+	ExportActors(ctx context.Context, fn func(actor entities.ActorEntity) error) error
+}
+
+func NewExportService(repo ExportRepoInterface) ExportService {
+	return ExportService{
+		repo: repo,
+	}
+}
+
+// ExportOptions default to a JSON file of films.
+type ExportOptions struct {
+	Format FileFormat
+	Entity ExportEntity
+}
+
+// Export writes the catalogue to w while it is read from the store, in a
+// form Import accepts. Actors come before films, so that a file of both
+// can be imported back. The options are checked before anything is
+// written.
+func (svc ExportService) Export(ctx context.Context, w io.Writer, options ExportOptions) error {
+	err := ValidateExport(&options)
+	if err != nil {
+		return err
+	}
+	writer, err := newRecordWriter(w, options)
+	if err != nil {
+		return err
+	}
+
+	if options.Entity == ExportActors || options.Entity == ExportAll {
+		err = svc.repo.ExportActors(ctx, func(actor entities.ActorEntity) error {
+			return writer.actor(newActorRecord(actor))
+		})
+		if err != nil {
+			return fmt.Errorf("export actors failed: %w", err)
+		}
+	}
+
+	if options.Entity == ExportFilms || options.Entity == ExportAll {
+		err = svc.repo.ExportFilms(ctx, func(film entities.CreditedFilm) error {
+			return writer.film(newFilmRecord(film))
+		})
+		if err != nil {
+			return fmt.Errorf("export films failed: %w", err)
+		}
+	}
+
+	return writer.close()
+}
+
+// ValidateExport fills in the defaults of options and checks them, so that
+// callers can reject an export before they start to respond.
+func ValidateExport(options *ExportOptions) error {
+	if options.Entity == "" {
+		options.Entity = ExportFilms
+	}
+
+	err := ValidateFormat(&options.Format)
+	if err != nil {
+		return err
+	}
+	switch options.Entity {
+	case ExportFilms, ExportActors:
+	case ExportAll:
+		if options.Format == FormatCSV {
+			return ValidationError([]FieldError{{Field: "entity", Message: "must be films or actors for csv"}})
+		}
+	default:
+		return ValidationError([]FieldError{{Field: "entity", Message: "must be films, actors or all"}})
+	}
+	return nil
+}
+
+// ValidateFormat defaults an export format to JSON and checks it.
+func ValidateFormat(format *FileFormat) error {
+	if *format == "" {
+		*format = FormatJSON
+	}
+	switch *format {
+	case FormatJSON, FormatJSONLines, FormatCSV:
+		return nil
+	}
+	return invalidFormat()
+}
+
+// newRecordWriter expects options checked by ValidateExport.
+func newRecordWriter(w io.Writer, options ExportOptions) (recordWriter, error) {
+	switch options.Format {
+	case FormatJSONLines:
+		return jsonLinesRecordWriter{encoder: json.NewEncoder(w)}, nil
+	case FormatCSV:
+		columns := filmColumns
+		switch options.Entity {
+		case ExportActors:
+			columns = actorColumns
+		case exportWatchlist:
+			columns = watchlistColumns
+		case exportHistory:
+			columns = historyColumns
+		}
+		return newCSVRecordWriter(w, columns)
+	}
+	return &jsonRecordWriter{w: w}, nil
+}
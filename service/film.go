@@ -2,57 +2,209 @@ package service
 
 import (
 	"context"
+	"errors"
 	"filmography/internal/entities"
 	"fmt"
 	"github.com/google/uuid"
+	"slices"
+	"strings"
+)
+
+const (
+	maxFilmTags     = 20
+	maxTagLen       = 50
+	maxCharacterLen = 150
+)
+
+var (
+	ErrFilmNotFound = NewError(CodeFilmNotFound, "film not found")
 )
 
 type FilmService struct {
-	repo FilmRepoInterface
+	repo    FilmRepoInterface
+	weights ratingWeights
 }
 
 type FilmRepoInterface interface {
 	CreateFilm(ctx context.Context, film entities.FilmEntity) error
-	GetFilms(ctx context.Context) ([]entities.FilmEntity, error)
+	GetFilms(ctx context.Context, query entities.FilmQuery) ([]entities.FilmEntity, int, error)
 	GetFilm(ctx context.Context, id string) (entities.FilmEntity, error)
+	GetActorsByFilm(ctx context.Context, filmID string) ([]entities.ActorEntity, error)
 	UpdateFilm(ctx context.Context, id string, film entities.FilmEntity) error
-	DeleteFilm(ctx context.Context, id string) error
+	DeleteFilm(ctx context.Context, id string, version int) error
+	RatingTotalsRepo
 }
 
-func NewFilmService(repo FilmRepoInterface) FilmService {
+func NewFilmService(repo FilmRepoInterface, ratingPrior float64) FilmService {
 	return FilmService{
-		repo: repo,
+		repo:    repo,
+		weights: ratingWeights{repo: repo, prior: ratingPrior},
 	}
 }
 
 func (svc FilmService) CreateFilm(ctx context.Context, film entities.FilmEntity) error {
+	film.Tags = normalizeTags(film.Tags)
+	if fields := validateFilm(film); len(fields) > 0 {
+		return ValidationError(fields)
+	}
+
 	film.ID = uuid.NewString()
 	err := svc.repo.CreateFilm(ctx, film)
-	return err
+	return filmRepoError(err)
 }
 
-func (svc FilmService) GetFilms(ctx context.Context) ([]entities.FilmEntity, error) {
-	films, err := svc.repo.GetFilms(ctx)
+func (svc FilmService) GetFilms(ctx context.Context, query entities.FilmQuery) ([]entities.FilmEntity, int, error) {
+	err := normalizeFilmQuery(&query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	films, total, err := svc.repo.GetFilms(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get films failed: %w", err)
+	}
+	err = svc.weights.apply(ctx, films)
 	if err != nil {
-		return nil, fmt.Errorf("get films failed: %w", err)
+		return nil, 0, err
 	}
-	return films, err
+	return films, total, nil
 }
 
 func (svc FilmService) GetFilm(ctx context.Context, id string) (entities.FilmEntity, error) {
 	film, err := svc.repo.GetFilm(ctx, id)
 	if err != nil {
-		return entities.FilmEntity{}, fmt.Errorf("get film failed: %w", err)
+		return entities.FilmEntity{}, fmt.Errorf("get film failed: %w", repoError(err, ErrFilmNotFound))
 	}
-	return film, err
+	films := []entities.FilmEntity{film}
+	err = svc.weights.apply(ctx, films)
+	if err != nil {
+		return entities.FilmEntity{}, err
+	}
+	return films[0], nil
+}
+
+func (svc FilmService) GetActorsByFilm(ctx context.Context, filmID string) ([]entities.ActorEntity, error) {
+	actors, err := svc.repo.GetActorsByFilm(ctx, filmID)
+	if err != nil {
+		return nil, fmt.Errorf("get actors by film failed: %w", repoError(err, ErrFilmNotFound))
+	}
+	return actors, err
+}
+
+// GetFilmCredits returns the credits of a film ordered by role and
+// billing, only those in role unless it is empty.
+func (svc FilmService) GetFilmCredits(ctx context.Context, filmID string, role entities.CreditRole) ([]entities.CreditEntity, error) {
+	if role != "" && !role.Valid() {
+		return nil, ValidationError([]FieldError{{Field: "role", Message: "must be one of director, writer, producer, composer, actor"}})
+	}
+
+	film, err := svc.repo.GetFilm(ctx, filmID)
+	if err != nil {
+		return nil, fmt.Errorf("get film failed: %w", repoError(err, ErrFilmNotFound))
+	}
+	if role == "" {
+		return film.Credits, nil
+	}
+	return slices.DeleteFunc(film.Credits, func(credit entities.CreditEntity) bool {
+		return credit.Role != role
+	}), nil
 }
 
 func (svc FilmService) UpdateFilm(ctx context.Context, id string, film entities.FilmEntity) error {
+	film.Tags = normalizeTags(film.Tags)
+	if fields := validateFilm(film); len(fields) > 0 {
+		return ValidationError(fields)
+	}
+
 	err := svc.repo.UpdateFilm(ctx, id, film)
-	return err
+	return filmRepoError(err)
+}
+
+// DeleteFilm removes the film if it is still at version, see
+// entities.AnyVersion.
+func (svc FilmService) DeleteFilm(ctx context.Context, id string, version int) error {
+	err := svc.repo.DeleteFilm(ctx, id, version)
+	return repoError(err, ErrFilmNotFound)
 }
 
-func (svc FilmService) DeleteFilm(ctx context.Context, id string) error {
-	err := svc.repo.DeleteFilm(ctx, id)
-	return err
+func validateFilm(film entities.FilmEntity) []FieldError {
+	fields := make([]FieldError, 0)
+	if len(film.Title) == 0 || len(film.Title) > 150 {
+		fields = append(fields, FieldError{Field: "title", Message: "must be between 1 and 150 characters long"})
+	}
+	if len(film.Description) > 1000 {
+		fields = append(fields, FieldError{Field: "description", Message: "must be at most 1000 characters long"})
+	}
+	if film.Rating < 0 || film.Rating > 10 {
+		fields = append(fields, FieldError{Field: "rating", Message: "must be between 0 and 10"})
+	}
+	if len(film.Tags) > maxFilmTags {
+		fields = append(fields, FieldError{Field: "tags", Message: fmt.Sprintf("must be at most %d tags", maxFilmTags)})
+	}
+	for _, tag := range film.Tags {
+		if len(tag) == 0 || len(tag) > maxTagLen {
+			fields = append(fields, FieldError{Field: "tags", Message: fmt.Sprintf("must be between 1 and %d characters long", maxTagLen)})
+			break
+		}
+	}
+	for _, credit := range film.Credits {
+		if !credit.Role.Valid() {
+			fields = append(fields, FieldError{Field: "credits", Message: "role must be one of director, writer, producer, composer, actor"})
+			break
+		}
+		if len(credit.Character) > maxCharacterLen {
+			fields = append(fields, FieldError{Field: "credits", Message: fmt.Sprintf("character must be at most %d characters long", maxCharacterLen)})
+			break
+		}
+		if credit.Billing < 0 {
+			fields = append(fields, FieldError{Field: "credits", Message: "billing must not be negative"})
+			break
+		}
+		if credit.Role != entities.CreditActor && (credit.Character != "" || credit.Billing != 0) {
+			fields = append(fields, FieldError{Field: "credits", Message: "only actor credits have a character and billing"})
+			break
+		}
+	}
+	return fields
+}
+
+// filmRepoError translates the errors of storing a film.
+func filmRepoError(err error) error {
+	switch {
+	case errors.Is(err, entities.ErrUnknownActor):
+		return WrapError(CodeUnknownActor, "film references an unknown person", err)
+	case errors.Is(err, entities.ErrUnknownGenre):
+		return WrapError(CodeUnknownGenre, "film references an unknown genre", err)
+	}
+	return repoError(err, ErrFilmNotFound)
+}
+
+// normalizeTags lowercases and trims tags and drops the duplicates, so
+// that "Noir" and "noir " are the same tag.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// normalizeFilmQuery prepares the genre and tag filters of query, the
+// repositories expect distinct values.
+func normalizeFilmQuery(query *entities.FilmQuery) error {
+	genres := make([]string, 0, len(query.Genres))
+	for _, id := range query.Genres {
+		if _, err := uuid.Parse(id); err != nil {
+			return ValidationError([]FieldError{{Field: "genre", Message: fmt.Sprintf("%q is not a valid genre ID", id)}})
+		}
+		if !slices.Contains(genres, id) {
+			genres = append(genres, id)
+		}
+	}
+	query.Genres = genres
+	query.Tags = normalizeTags(query.Tags)
+	return nil
 }
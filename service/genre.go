@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"filmography/internal/entities"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrGenreNotFound = NewError(CodeGenreNotFound, "genre not found")
+	ErrGenreExists   = NewError(CodeGenreExists, "genre with the name already exists")
+)
+
+// tagFacetLimit is the number of the most used tags in film facets.
+const tagFacetLimit = 50
+
+type GenreService struct {
+	repo GenreRepoInterface
+}
+
+type GenreRepoInterface interface {
+	CreateGenre(ctx context.Context, genre entities.GenreEntity) error
+	GetGenre(ctx context.Context, id string) (entities.GenreEntity, error)
+	GetGenreCounts(ctx context.Context, query entities.FilmQuery) ([]entities.GenreCount, error)
+	GetTagCounts(ctx context.Context, query entities.FilmQuery, limit int) ([]entities.TagCount, error)
+	UpdateGenre(ctx context.Context, id string, genre entities.GenreEntity) error
+	DeleteGenre(ctx context.Context, id string) error
+}
+
+func NewGenreService(repo GenreRepoInterface) GenreService {
+	return GenreService{
+		repo: repo,
+	}
+}
+
+// FilmFacets sum up the genres and tags of the films matching a query.
+type FilmFacets struct {
+	Genres []entities.GenreCount
+	Tags   []entities.TagCount
+}
+
+func (svc GenreService) CreateGenre(ctx context.Context, genre entities.GenreEntity) (entities.GenreEntity, error) {
+	genre.Name = strings.TrimSpace(genre.Name)
+	if fields := validateGenre(genre); len(fields) > 0 {
+		return entities.GenreEntity{}, ValidationError(fields)
+	}
+
+	genre.ID = uuid.NewString()
+	err := svc.repo.CreateGenre(ctx, genre)
+	if err != nil {
+		return entities.GenreEntity{}, genreRepoError(err)
+	}
+	return genre, nil
+}
+
+// GetGenres returns every genre with the number of films it has.
+func (svc GenreService) GetGenres(ctx context.Context) ([]entities.GenreCount, error) {
+	genres, err := svc.repo.GetGenreCounts(ctx, entities.FilmQuery{})
+	if err != nil {
+		return nil, fmt.Errorf("get genre counts failed: %w", err)
+	}
+	return genres, nil
+}
+
+func (svc GenreService) GetGenre(ctx context.Context, id string) (entities.GenreEntity, error) {
+	genre, err := svc.repo.GetGenre(ctx, id)
+	if err != nil {
+		return entities.GenreEntity{}, fmt.Errorf("get genre failed: %w", repoError(err, ErrGenreNotFound))
+	}
+	return genre, nil
+}
+
+func (svc GenreService) UpdateGenre(ctx context.Context, id string, genre entities.GenreEntity) error {
+	genre.Name = strings.TrimSpace(genre.Name)
+	if fields := validateGenre(genre); len(fields) > 0 {
+		return ValidationError(fields)
+	}
+
+	err := svc.repo.UpdateGenre(ctx, id, genre)
+	return genreRepoError(err)
+}
+
+func (svc GenreService) DeleteGenre(ctx context.Context, id string) error {
+	err := svc.repo.DeleteGenre(ctx, id)
+	return repoError(err, ErrGenreNotFound)
+}
+
+// GetFilmFacets counts the films matching query per genre and per tag,
+// every genre is listed and only the most used tags.
+func (svc GenreService) GetFilmFacets(ctx context.Context, query entities.FilmQuery) (FilmFacets, error) {
+	err := normalizeFilmQuery(&query)
+	if err != nil {
+		return FilmFacets{}, err
+	}
+
+	genres, err := svc.repo.GetGenreCounts(ctx, query)
+	if err != nil {
+		return FilmFacets{}, fmt.Errorf("get genre counts failed: %w", err)
+	}
+	tags, err := svc.repo.GetTagCounts(ctx, query, tagFacetLimit)
+	if err != nil {
+		return FilmFacets{}, fmt.Errorf("get tag counts failed: %w", err)
+	}
+	return FilmFacets{Genres: genres, Tags: tags}, nil
+}
+
+// genreRepoError reports a taken name as ErrGenreExists.
+func genreRepoError(err error) error {
+	if errors.Is(err, entities.ErrConflict) {
+		return WrapError(ErrGenreExists.Code, ErrGenreExists.Message, err)
+	}
+	return repoError(err, ErrGenreNotFound)
+}
+
+func validateGenre(genre entities.GenreEntity) []FieldError {
+	fields := make([]FieldError, 0)
+	if len(genre.Name) == 0 || len(genre.Name) > 50 {
+		fields = append(fields, FieldError{Field: "name", Message: "must be between 1 and 50 characters long"})
+	}
+	return fields
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"filmography/internal/entities"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultImportBatchSize is the number of films stored per transaction
+// when the options leave it out.
+const defaultImportBatchSize = 100
+
+type ImportService struct {
+	repo ImportRepoInterface
+}
+
+type ImportRepoInterface interface {
+	ImportFilms(ctx context.Context, films []entities.CreditedFilm, dryRun bool) ([]error, error)
+	ImportActors(ctx context.Context, actors []entities.ActorEntity, dryRun bool) ([]error, error)
+}
+
+func NewImportService(repo ImportRepoInterface) ImportService {
+	return ImportService{
+		repo: repo,
+	}
+}
+
+type ImportOptions struct {
+	Format FileFormat
+	// DryRun checks every record against the store without keeping
+	// anything.
+	DryRun    bool
+	BatchSize int
+}
+
+// ImportReport sums up an import, records that failed are listed by the
+// line they start on, or by their position in a JSON array.
+type ImportReport struct {
+	DryRun   bool          `json:"dry_run"`
+	Rows     int           `json:"rows"`
+	Imported int           `json:"imported"`
+	Failed   int           `json:"failed"`
+	Errors   []ImportError `json:"errors"`
+}
+
+type ImportError struct {
+	Line    int          `json:"line"`
+	Code    ErrorCode    `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// importBatch holds the valid records waiting to be stored, with the
+// lines they were read from.
+type importBatch struct {
+	actors     []entities.ActorEntity
+	actorLines []int
+	films      []entities.CreditedFilm
+	filmLines  []int
+}
+
+func (batch importBatch) len() int {
+	return len(batch.actors) + len(batch.films)
+}
+
+// Import reads films and actors from r and stores them in batches. A
+// record that cannot be read, validated or stored is reported and skipped,
+// errors of the file as a whole abort the import; batches stored before
+// stay.
+func (svc ImportService) Import(ctx context.Context, r io.Reader, options ImportOptions) (ImportReport, error) {
+	if options.BatchSize <= 0 {
+		options.BatchSize = defaultImportBatchSize
+	}
+	reader, err := newRecordReader(r, options.Format)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	report := ImportReport{DryRun: options.DryRun, Errors: make([]ImportError, 0)}
+	batch := importBatch{}
+	for {
+		record, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var recordErr *recordError
+		if errors.As(err, &recordErr) {
+			report.Rows++
+			report.fail(recordErr.line, recordErr.err)
+			continue
+		}
+		if err != nil {
+			return report, fmt.Errorf("read records failed: %w", err)
+		}
+
+		report.Rows++
+		if record.film != nil {
+			film, fields := importedFilm(*record.film)
+			if len(fields) > 0 {
+				report.fail(record.line, ValidationError(fields))
+				continue
+			}
+			batch.films = append(batch.films, film)
+			batch.filmLines = append(batch.filmLines, record.line)
+		} else {
+			actor, fields := importedActor(record.actor.actor(), "")
+			if len(fields) > 0 {
+				report.fail(record.line, ValidationError(fields))
+				continue
+			}
+			batch.actors = append(batch.actors, actor)
+			batch.actorLines = append(batch.actorLines, record.line)
+		}
+
+		if batch.len() >= options.BatchSize {
+			err = svc.store(ctx, batch, options.DryRun, &report)
+			if err != nil {
+				return report, err
+			}
+			batch = importBatch{}
+		}
+	}
+
+	err = svc.store(ctx, batch, options.DryRun, &report)
+	if err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// store imports the actors of the batch before its films, so that the
+// cast of the films finds them.
+func (svc ImportService) store(ctx context.Context, batch importBatch, dryRun bool, report *ImportReport) error {
+	if len(batch.actors) > 0 {
+		errs, err := svc.repo.ImportActors(ctx, batch.actors, dryRun)
+		if err != nil {
+			return fmt.Errorf("import actors failed: %w", err)
+		}
+		report.stored(errs, batch.actorLines, ErrActorNotFound)
+	}
+
+	if len(batch.films) > 0 {
+		errs, err := svc.repo.ImportFilms(ctx, batch.films, dryRun)
+		if err != nil {
+			return fmt.Errorf("import films failed: %w", err)
+		}
+		report.stored(errs, batch.filmLines, ErrFilmNotFound)
+	}
+	return nil
+}
+
+// stored counts the outcome of a stored batch, errs and lines are indexed
+// like the batch.
+func (report *ImportReport) stored(errs []error, lines []int, notFound *Error) {
+	for i, err := range errs {
+		if err == nil {
+			report.Imported++
+			continue
+		}
+		var svcErr *Error
+		if !errors.As(repoError(err, notFound), &svcErr) {
+			svcErr = WrapError(CodeInternal, "record could not be stored", err)
+		}
+		report.fail(lines[i], svcErr)
+	}
+}
+
+func (report *ImportReport) fail(line int, err *Error) {
+	report.Failed++
+	report.Errors = append(report.Errors, ImportError{
+		Line:    line,
+		Code:    err.Code,
+		Message: err.Message,
+		Fields:  err.Fields,
+	})
+}
+
+// importedFilm validates a record with the rules of the API and assigns
+// IDs to the film and to the people and genres that may have to be
+// created. The people of the credits are matched to the cast and to each
+// other by name and birthday, the way the store matches them.
+func importedFilm(record FilmRecord) (entities.CreditedFilm, []FieldError) {
+	film := entities.CreditedFilm{FilmEntity: record.film(), Crew: make([]entities.ActorEntity, 0)}
+	if record.Credits != nil {
+		film.Credits = make([]entities.CreditEntity, 0, len(record.Credits))
+		for _, credit := range record.Credits {
+			film.Credits = append(film.Credits, entities.CreditEntity{Role: credit.Role, Character: credit.Character, Billing: credit.Billing})
+		}
+	}
+	fields := validateFilm(film.FilmEntity)
+	if film.ID == "" {
+		film.ID = uuid.NewString()
+	} else if _, err := uuid.Parse(film.ID); err != nil {
+		fields = append(fields, FieldError{Field: "id", Message: "must be a UUID"})
+	}
+	film.ReleaseDate = dateOf(film.ReleaseDate)
+
+	actors := make([]entities.ActorEntity, 0, len(film.Actors))
+	for i, actor := range film.Actors {
+		actor, actorFields := importedActor(actor, fmt.Sprintf("actors[%d].", i))
+		fields = append(fields, actorFields...)
+		actors = append(actors, actor)
+	}
+	film.Actors = actors
+
+	for i, credit := range record.Credits {
+		person, personFields := importedActor(credit.person(), fmt.Sprintf("credits[%d].", i))
+		fields = append(fields, personFields...)
+		if id, ok := samePerson(film.Actors, person); ok {
+			person.ID = id
+		} else if id, ok := samePerson(film.Crew, person); ok {
+			person.ID = id
+		} else if credit.Role == entities.CreditActor {
+			film.Actors = append(film.Actors, person)
+		} else {
+			film.Crew = append(film.Crew, person)
+		}
+		film.Credits[i].PersonID = person.ID
+	}
+
+	if record.Genres != nil {
+		film.Genres = make([]entities.GenreEntity, 0, len(record.Genres))
+		for i, name := range record.Genres {
+			genre := entities.GenreEntity{ID: uuid.NewString(), Name: strings.TrimSpace(name)}
+			for _, field := range validateGenre(genre) {
+				fields = append(fields, FieldError{Field: fmt.Sprintf("genres[%d]", i), Message: field.Message})
+			}
+			film.Genres = append(film.Genres, genre)
+		}
+	}
+	return film, fields
+}
+
+// samePerson returns the ID of the person of people with the name and
+// birthday of person.
+func samePerson(people []entities.ActorEntity, person entities.ActorEntity) (string, bool) {
+	for _, candidate := range people {
+		if candidate.Name == person.Name && candidate.Birthday.Equal(person.Birthday) {
+			return candidate.ID, true
+		}
+	}
+	return "", false
+}
+
+// importedActor validates an actor, prefix is added to the names of the
+// rejected fields.
+func importedActor(actor entities.ActorEntity, prefix string) (entities.ActorEntity, []FieldError) {
+	fields := validateActor(actor)
+	for i := range fields {
+		fields[i].Field = prefix + fields[i].Field
+	}
+	if actor.ID == "" {
+		actor.ID = uuid.NewString()
+	} else if _, err := uuid.Parse(actor.ID); err != nil {
+		fields = append(fields, FieldError{Field: prefix + "id", Message: "must be a UUID"})
+	}
+	actor.Birthday = dateOf(actor.Birthday)
+	return actor, fields
+}
+
+// dateOf drops the time of day, actors are matched by the date of birth
+// alone.
+func dateOf(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"filmography/internal/entities"
+	"filmography/internal/repository/memory"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// storedNames lists the films and actors kept by repo.
+func storedNames(t *testing.T, repo *memory.Repo) []string {
+	t.Helper()
+	ctx := context.Background()
+	names := make([]string, 0)
+	err := repo.ExportActors(ctx, func(actor entities.ActorEntity) error {
+		names = append(names, "actor "+actor.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportActors() error = %v", err)
+	}
+	err = repo.ExportFilms(ctx, func(film entities.CreditedFilm) error {
+		names = append(names, fmt.Sprintf("film %s (%d cast)", film.Title, len(film.Actors)))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportFilms() error = %v", err)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// reportErrors describes the failed records of a report as "line: code".
+func reportErrors(report ImportReport) []string {
+	got := make([]string, 0, len(report.Errors))
+	for _, importErr := range report.Errors {
+		got = append(got, fmt.Sprintf("%d: %s", importErr.Line, importErr.Code))
+	}
+	return got
+}
+
+func TestImport(t *testing.T) {
+	jsonLines := strings.Join([]string{
+		`{"type": "actor", "name": "Al Pacino", "birthday": "1940-04-25T00:00:00Z"}`,
+		`{"name": "Heat", "actors": [{"name": "Al Pacino", "birthday": "1940-04-25T00:00:00Z"}, {"name": "Robert De Niro"}]}`,
+		``,
+		`{"name": "Heat"`,
+		`{"name": ""}`,
+		`{"id": "42", "name": "Ronin"}`,
+		`{"type": "actor", "name": "Jean Reno", "birthday": "2999-01-01T00:00:00Z"}`,
+		`{"name": "Thief"}`,
+	}, "\n")
+	csvFile := "title,release_date,cast\n" +
+		"Heat,1995-12-15,Al Pacino|male|1940-04-25;Robert De Niro\n" +
+		"Ronin,15.09.1998,\n" +
+		"\"Thief\n(1981)\",1981-03-27,\n" +
+		",,\n" +
+		"Collateral,2004-08-06,Tom Cruise|male|3000-01-01\n"
+
+	tests := []struct {
+		name       string
+		file       string
+		options    ImportOptions
+		wantRows   int
+		wantFailed []string
+		wantStored []string
+	}{
+		{
+			name:       "json lines",
+			file:       jsonLines,
+			options:    ImportOptions{Format: FormatJSONLines},
+			wantRows:   7,
+			wantFailed: []string{"4: malformed_request", "5: validation_failed", "6: validation_failed", "7: validation_failed"},
+			wantStored: []string{"actor Al Pacino", "actor Robert De Niro", "film Heat (2 cast)", "film Thief (0 cast)"},
+		},
+		{
+			name:       "json lines in batches of one",
+			file:       jsonLines,
+			options:    ImportOptions{Format: FormatJSONLines, BatchSize: 1},
+			wantRows:   7,
+			wantFailed: []string{"4: malformed_request", "5: validation_failed", "6: validation_failed", "7: validation_failed"},
+			wantStored: []string{"actor Al Pacino", "actor Robert De Niro", "film Heat (2 cast)", "film Thief (0 cast)"},
+		},
+		{
+			name:       "json lines dry run",
+			file:       jsonLines,
+			options:    ImportOptions{Format: FormatJSONLines, DryRun: true},
+			wantRows:   7,
+			wantFailed: []string{"4: malformed_request", "5: validation_failed", "6: validation_failed", "7: validation_failed"},
+			wantStored: []string{},
+		},
+		{
+			name:       "csv",
+			file:       csvFile,
+			options:    ImportOptions{Format: FormatCSV},
+			wantRows:   5,
+			wantFailed: []string{"3: validation_failed", "6: validation_failed", "7: validation_failed"},
+			wantStored: []string{"actor Al Pacino", "actor Robert De Niro", "film Heat (2 cast)", "film Thief\n(1981) (0 cast)"},
+		},
+		{
+			name:       "csv dry run",
+			file:       csvFile,
+			options:    ImportOptions{Format: FormatCSV, DryRun: true, BatchSize: 2},
+			wantRows:   5,
+			wantFailed: []string{"3: validation_failed", "6: validation_failed", "7: validation_failed"},
+			wantStored: []string{},
+		},
+		{
+			name:       "json array positions",
+			file:       `[{"name": "Heat"}, {"type": "series"}, {"name": "Heat", "rating": 11}]`,
+			options:    ImportOptions{Format: FormatJSON},
+			wantRows:   3,
+			wantFailed: []string{"2: validation_failed", "3: validation_failed"},
+			wantStored: []string{"film Heat (0 cast)"},
+		},
+	}
+
+	for _, tt := range tests {
+		repo := memory.New()
+		report, err := NewImportService(repo).Import(context.Background(), strings.NewReader(tt.file), tt.options)
+		if err != nil {
+			t.Fatalf("%s: Import() error = %v", tt.name, err)
+		}
+
+		wantImported := tt.wantRows - len(tt.wantFailed)
+		if report.DryRun != tt.options.DryRun || report.Rows != tt.wantRows ||
+			report.Imported != wantImported || report.Failed != len(tt.wantFailed) {
+			t.Errorf("%s: Import() report = dry run %v, %d rows, %d imported, %d failed, want %v, %d, %d, %d",
+				tt.name, report.DryRun, report.Rows, report.Imported, report.Failed,
+				tt.options.DryRun, tt.wantRows, wantImported, len(tt.wantFailed))
+		}
+		if got := reportErrors(report); !slices.Equal(got, tt.wantFailed) {
+			t.Errorf("%s: Import() errors = %q, want %q", tt.name, got, tt.wantFailed)
+		}
+		if got := storedNames(t, repo); !slices.Equal(got, tt.wantStored) {
+			t.Errorf("%s: stored = %q, want %q", tt.name, got, tt.wantStored)
+		}
+	}
+}
+
+func TestImportFileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   FileFormat
+		file     string
+		wantCode ErrorCode
+		wantRows int
+	}{
+		{"unknown column", FormatCSV, "title,budget\nHeat,60000000\n", CodeValidationFailed, 0},
+		{"empty csv", FormatCSV, "", CodeValidationFailed, 0},
+		{"not an array", FormatJSON, `{"name": "Heat"}`, CodeMalformedRequest, 0},
+		{"truncated array", FormatJSON, `[{"name": "Heat"}, {"name": "Ronin"`, CodeMalformedRequest, 1},
+	}
+
+	for _, tt := range tests {
+		repo := memory.New()
+		report, err := NewImportService(repo).Import(context.Background(), strings.NewReader(tt.file), ImportOptions{Format: tt.format})
+		var svcErr *Error
+		if !errors.As(err, &svcErr) || svcErr.Code != tt.wantCode {
+			t.Errorf("%s: Import() error = %v, want %s", tt.name, err, tt.wantCode)
+		}
+		if report.Rows != tt.wantRows {
+			t.Errorf("%s: Import() rows = %d, want %d", tt.name, report.Rows, tt.wantRows)
+		}
+	}
+}
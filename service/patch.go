@@ -0,0 +1,176 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"filmography/internal/entities"
+	"filmography/internal/patch"
+	"fmt"
+	"slices"
+)
+
+// maxPatchAttempts bounds how often an unconditional patch is retried when
+// the record changes between reading and writing it.
+const maxPatchAttempts = 3
+
+// ErrPatchConflict is reported when a patch does not fit the record, a
+// path is missing or a test operation failed.
+var ErrPatchConflict = NewError(CodePatchConflict, "patch does not apply to the record")
+
+// PatchFilm applies p to the film as GET /film/{id} returns it and stores
+// the result with the validation of UpdateFilm. The write is conditional
+// on the version the patch was applied to, see patchRecord. It returns the
+// patched film.
+func (svc FilmService) PatchFilm(ctx context.Context, id string, p patch.Patch, version int) (entities.FilmEntity, error) {
+	err := patchRecord(ctx, version, func() error {
+		film, err := svc.GetFilm(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(film.Version, version); err != nil {
+			return err
+		}
+
+		patched, err := applyPatch(film, p)
+		if err != nil {
+			return err
+		}
+		if patched.ID != film.ID {
+			return ValidationError([]FieldError{{Field: "id", Message: "must not be changed"}})
+		}
+		patched.Actors, patched.Credits = patchedCast(film, patched)
+		patched.Version = film.Version
+		return svc.UpdateFilm(ctx, id, patched)
+	})
+	if err != nil {
+		return entities.FilmEntity{}, err
+	}
+	return svc.GetFilm(ctx, id)
+}
+
+// PatchActor applies p to the actor as GET /actor/{id} returns it, see
+// PatchFilm.
+func (svc ActorService) PatchActor(ctx context.Context, id string, p patch.Patch, version int) (entities.ActorEntity, error) {
+	err := patchRecord(ctx, version, func() error {
+		actor, err := svc.GetActor(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(actor.Version, version); err != nil {
+			return err
+		}
+
+		patched, err := applyPatch(actor, p)
+		if err != nil {
+			return err
+		}
+		if patched.ID != actor.ID {
+			return ValidationError([]FieldError{{Field: "id", Message: "must not be changed"}})
+		}
+		patched.Version = actor.Version
+		return svc.UpdateActor(ctx, id, patched)
+	})
+	if err != nil {
+		return entities.ActorEntity{}, err
+	}
+	return svc.GetActor(ctx, id)
+}
+
+// PatchUser applies p to the user as GET /user/{id} returns it, see
+// PatchFilm. The password is not part of the user and stays as it is.
+func (svc UserService) PatchUser(ctx context.Context, id string, p patch.Patch, version int) (entities.UserEntity, error) {
+	err := patchRecord(ctx, version, func() error {
+		user, err := svc.GetUser(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(user.Version, version); err != nil {
+			return err
+		}
+
+		patched, err := applyPatch(user, p)
+		if err != nil {
+			return err
+		}
+		if patched.ID != user.ID {
+			return ValidationError([]FieldError{{Field: "id", Message: "must not be changed"}})
+		}
+		patched.Version = user.Version
+		return svc.UpdateUser(ctx, id, patched)
+	})
+	if err != nil {
+		return entities.UserEntity{}, err
+	}
+	return svc.GetUser(ctx, id)
+}
+
+// patchRecord runs attempt, which reads a record, patches it and writes it
+// back conditional on the version it has read. A record changed in between
+// fails the write as a whole: with an explicit version that is reported
+// right away, an unconditional patch is applied again to the new record.
+func patchRecord(ctx context.Context, version int, attempt func() error) error {
+	var err error
+	for range maxPatchAttempts {
+		if err = attempt(); !errors.Is(err, ErrVersionMismatch) || version != entities.AnyVersion {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// checkVersion fails when the client expects another version than the
+// stored one, before the patch is even applied.
+func checkVersion(stored, version int) error {
+	if version != entities.AnyVersion && version != stored {
+		return WrapError(ErrVersionMismatch.Code, ErrVersionMismatch.Message, entities.ErrVersionMismatch)
+	}
+	return nil
+}
+
+// applyPatch applies p to the JSON of record and decodes the result into a
+// record again. Members the record does not have and values of the wrong
+// type are validation errors.
+func applyPatch[T any](record T, p patch.Patch) (T, error) {
+	var patched T
+	doc, err := json.Marshal(record)
+	if err != nil {
+		return patched, fmt.Errorf("encode record failed: %w", err)
+	}
+
+	doc, err = p.Apply(doc)
+	if errors.Is(err, patch.ErrConflict) {
+		return patched, WrapError(ErrPatchConflict.Code, err.Error(), err)
+	}
+	if err != nil {
+		return patched, fmt.Errorf("apply patch failed: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patched); err != nil {
+		return patched, ValidationError([]FieldError{{Field: "patch", Message: err.Error()}})
+	}
+	return patched, nil
+}
+
+// patchedCast reconciles the two views of the cast after a patch. The
+// stored cast is kept in Credits, an actor removed from Actors loses the
+// actor credit and an actor added to Actors is credited as usual.
+func patchedCast(before, after entities.FilmEntity) ([]entities.ActorEntity, []entities.CreditEntity) {
+	inCast := func(actors []entities.ActorEntity, id string) bool {
+		return slices.ContainsFunc(actors, func(actor entities.ActorEntity) bool { return actor.ID == id })
+	}
+
+	credits := slices.DeleteFunc(after.Credits, func(credit entities.CreditEntity) bool {
+		return credit.Role == entities.CreditActor && inCast(before.Actors, credit.PersonID) && !inCast(after.Actors, credit.PersonID)
+	})
+	added := slices.DeleteFunc(after.Actors, func(actor entities.ActorEntity) bool {
+		return inCast(before.Actors, actor.ID)
+	})
+	return added, credits
+}
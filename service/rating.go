@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrRatingNotFound = NewError(CodeRatingNotFound, "rating not found")
+)
+
+type RatingService struct {
+	repo    RatingRepoInterface
+	weights ratingWeights
+}
+
+type RatingRepoInterface interface {
+	RateFilm(ctx context.Context, rating entities.FilmRating) error
+	GetFilmRating(ctx context.Context, filmID string, userID string) (entities.FilmRating, error)
+	GetRatingHistory(ctx context.Context, query entities.RatingQuery) ([]entities.FilmRating, int, error)
+	GetFilm(ctx context.Context, id string) (entities.FilmEntity, error)
+	RatingTotalsRepo
+}
+
+type RatingTotalsRepo interface {
+	GetRatingTotals(ctx context.Context) (entities.RatingTotals, error)
+}
+
+func NewRatingService(repo RatingRepoInterface, ratingPrior float64) RatingService {
+	return RatingService{
+		repo:    repo,
+		weights: ratingWeights{repo: repo, prior: ratingPrior},
+	}
+}
+
+// RateFilm stores the rating the user gives the film, replacing an earlier
+// one, and returns the updated summary of the film.
+func (svc RatingService) RateFilm(ctx context.Context, filmID string, userID string, rating float64) (entities.RatingSummary, error) {
+	if rating < 0 || rating > 10 {
+		return entities.RatingSummary{}, ValidationError([]FieldError{{Field: "rating", Message: "must be between 0 and 10"}})
+	}
+
+	err := svc.repo.RateFilm(ctx, entities.FilmRating{
+		FilmID:  filmID,
+		UserID:  userID,
+		Rating:  entities.RoundRating(rating),
+		RatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return entities.RatingSummary{}, fmt.Errorf("rate film failed: %w", repoError(err, ErrFilmNotFound))
+	}
+
+	film, err := svc.repo.GetFilm(ctx, filmID)
+	if err != nil {
+		return entities.RatingSummary{}, fmt.Errorf("get film failed: %w", repoError(err, ErrFilmNotFound))
+	}
+	films := []entities.FilmEntity{film}
+	err = svc.weights.apply(ctx, films)
+	if err != nil {
+		return entities.RatingSummary{}, err
+	}
+	return films[0].UserRating, nil
+}
+
+// GetFilmRating returns the current rating the user has given the film.
+func (svc RatingService) GetFilmRating(ctx context.Context, filmID string, userID string) (entities.FilmRating, error) {
+	rating, err := svc.repo.GetFilmRating(ctx, filmID, userID)
+	if err != nil {
+		return entities.FilmRating{}, fmt.Errorf("get film rating failed: %w", repoError(err, ErrRatingNotFound))
+	}
+	return rating, nil
+}
+
+// GetRatingHistory lists every rating the user has given, newest first.
+func (svc RatingService) GetRatingHistory(ctx context.Context, query entities.RatingQuery) ([]entities.FilmRating, int, error) {
+	ratings, total, err := svc.repo.GetRatingHistory(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get rating history failed: %w", err)
+	}
+	return ratings, total, nil
+}
+
+// ratingWeights fills in the Bayesian weighted user rating of films: the
+// mean of a film is pulled towards the mean of all ratings as if it had
+// prior more votes of that value, so that a few votes cannot top the
+// list. A zero prior leaves the score out.
+type ratingWeights struct {
+	repo  RatingTotalsRepo
+	prior float64
+}
+
+func (w ratingWeights) apply(ctx context.Context, films []entities.FilmEntity) error {
+	if w.prior <= 0 || len(films) == 0 {
+		return nil
+	}
+
+	totals, err := w.repo.GetRatingTotals(ctx)
+	if err != nil {
+		return fmt.Errorf("get rating totals failed: %w", err)
+	}
+	if totals.Count == 0 {
+		return nil
+	}
+
+	mean := totals.Sum / float64(totals.Count)
+	for i := range films {
+		summary := films[i].UserRating
+		weighted := entities.RoundRating((w.prior*mean + summary.Mean*float64(summary.Count)) / (w.prior + float64(summary.Count)))
+		films[i].UserRating.Weighted = &weighted
+	}
+	return nil
+}
@@ -0,0 +1,733 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"filmography/internal/entities"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileFormat is the encoding of an import or export file.
+type FileFormat string
+
+const (
+	FormatJSON      FileFormat = "json"
+	FormatCSV       FileFormat = "csv"
+	FormatJSONLines FileFormat = "ndjson"
+)
+
+// Record types tell films and actors apart in JSON files, a record without
+// type is a film.
+const (
+	recordFilm  = "film"
+	recordActor = "actor"
+)
+
+var (
+	// filmColumns is the header of a CSV file of films. The cast column
+	// lists the actors as name|gender|birthday separated by semicolons,
+	// credits as role|name|gender|birthday|character|billing, genres and
+	// tags are separated by semicolons as well.
+	filmColumns = []string{"id", "title", "description", "release_date", "rating", "cast", "credits", "genres", "tags"}
+	// actorColumns is the header of a CSV file of actors.
+	actorColumns = []string{"id", "name", "gender", "birthday"}
+	// watchlistColumns and historyColumns are the headers of the personal
+	// lists of a user.
+	watchlistColumns = []string{"position", "film_id", "title", "release_date", "added_at"}
+	historyColumns   = []string{"id", "film_id", "title", "watched_at", "rating"}
+)
+
+// FilmRecord is a film of an import or export file. Its JSON matches the
+// body of POST /film plus the ID, the cast and the credited people are
+// written out in full so that they can be matched by name and birthday,
+// genres are given by name. A file without credits, genres or tags keeps
+// the stored ones.
+type FilmRecord struct {
+	Type        string                 `json:"type,omitempty"`
+	ID          string                 `json:"id,omitempty"`
+	Title       string                 `json:"name"`
+	Description string                 `json:"description"`
+	ReleaseDate time.Time              `json:"release_date"`
+	Rating      float64                `json:"rating"`
+	Actors      []entities.ActorEntity `json:"actors"`
+	Credits     []CreditRecord         `json:"credits"`
+	Genres      []string               `json:"genres"`
+	Tags        []string               `json:"tags"`
+}
+
+// CreditRecord is a credit of a film record together with the person.
+type CreditRecord struct {
+	ID        string              `json:"id,omitempty"`
+	Name      string              `json:"name"`
+	Gender    string              `json:"gender"`
+	Birthday  time.Time           `json:"birthday"`
+	Role      entities.CreditRole `json:"role"`
+	Character string              `json:"character,omitempty"`
+	Billing   int                 `json:"billing,omitempty"`
+}
+
+func newFilmRecord(film entities.CreditedFilm) FilmRecord {
+	people := make(map[string]entities.ActorEntity, len(film.Actors)+len(film.Crew))
+	for _, person := range slices.Concat(film.Actors, film.Crew) {
+		people[person.ID] = person
+	}
+	credits := make([]CreditRecord, 0, len(film.Credits))
+	for _, credit := range film.Credits {
+		person := people[credit.PersonID]
+		credits = append(credits, CreditRecord{
+			ID:        credit.PersonID,
+			Name:      credit.Name,
+			Gender:    person.Gender,
+			Birthday:  person.Birthday,
+			Role:      credit.Role,
+			Character: credit.Character,
+			Billing:   credit.Billing,
+		})
+	}
+	genres := make([]string, 0, len(film.Genres))
+	for _, genre := range film.Genres {
+		genres = append(genres, genre.Name)
+	}
+
+	return FilmRecord{
+		Type:        recordFilm,
+		ID:          film.ID,
+		Title:       film.Title,
+		Description: film.Description,
+		ReleaseDate: film.ReleaseDate,
+		Rating:      film.Rating,
+		Actors:      film.Actors,
+		Credits:     credits,
+		Genres:      genres,
+		Tags:        append(make([]string, 0, len(film.Tags)), film.Tags...),
+	}
+}
+
+// film returns the film without its credits and genres, which need the
+// IDs importedFilm assigns.
+func (record FilmRecord) film() entities.FilmEntity {
+	film := entities.FilmEntity{
+		ID:          record.ID,
+		Title:       record.Title,
+		Description: record.Description,
+		ReleaseDate: record.ReleaseDate,
+		Rating:      record.Rating,
+		Actors:      record.Actors,
+	}
+	if record.Tags != nil {
+		film.Tags = normalizeTags(record.Tags)
+	}
+	return film
+}
+
+func (record CreditRecord) person() entities.ActorEntity {
+	return entities.ActorEntity{
+		ID:       record.ID,
+		Name:     record.Name,
+		Gender:   record.Gender,
+		Birthday: record.Birthday,
+	}
+}
+
+// ActorRecord is an actor of an import or export file.
+type ActorRecord struct {
+	Type     string    `json:"type"`
+	ID       string    `json:"id,omitempty"`
+	Name     string    `json:"name"`
+	Gender   string    `json:"gender"`
+	Birthday time.Time `json:"birthday"`
+}
+
+func newActorRecord(actor entities.ActorEntity) ActorRecord {
+	return ActorRecord{
+		Type:     recordActor,
+		ID:       actor.ID,
+		Name:     actor.Name,
+		Gender:   actor.Gender,
+		Birthday: actor.Birthday,
+	}
+}
+
+func (record ActorRecord) actor() entities.ActorEntity {
+	return entities.ActorEntity{
+		ID:       record.ID,
+		Name:     record.Name,
+		Gender:   record.Gender,
+		Birthday: record.Birthday,
+	}
+}
+
+// record is a film or an actor read from a file, with the line it starts
+// on or its position in a JSON array.
+type record struct {
+	film  *FilmRecord
+	actor *ActorRecord
+	line  int
+}
+
+// recordError is the fault of a single record, reading goes on with the
+// next one.
+type recordError struct {
+	line int
+	err  *Error
+}
+
+func (e *recordError) Error() string {
+	return fmt.Sprintf("record %d: %v", e.line, e.err)
+}
+
+// recordReader yields the records of a file. next returns io.EOF after the
+// last record and a *recordError for a record that has to be skipped, any
+// other error ends the file.
+type recordReader interface {
+	next() (record, error)
+}
+
+func newRecordReader(r io.Reader, format FileFormat) (recordReader, error) {
+	switch format {
+	case FormatJSON:
+		return newJSONRecordReader(r)
+	case FormatCSV:
+		return newCSVRecordReader(r)
+	case FormatJSONLines:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+		return &jsonLinesRecordReader{scanner: scanner}, nil
+	}
+	return nil, invalidFormat()
+}
+
+func invalidFormat() error {
+	return ValidationError([]FieldError{{Field: "format", Message: fmt.Sprintf("must be %s, %s or %s", FormatJSON, FormatCSV, FormatJSONLines)}})
+}
+
+// decodeRecord reads a JSON record of either type.
+func decodeRecord(data []byte, line int) (record, error) {
+	header := struct {
+		Type string `json:"type"`
+	}{}
+	err := json.Unmarshal(data, &header)
+	if err != nil {
+		return record{}, &recordError{line: line, err: NewError(CodeMalformedRequest, "record is not a valid JSON object")}
+	}
+
+	switch header.Type {
+	case "", recordFilm:
+		film := FilmRecord{}
+		if err := json.Unmarshal(data, &film); err != nil {
+			return record{}, &recordError{line: line, err: NewError(CodeMalformedRequest, "record is not a valid film")}
+		}
+		return record{film: &film, line: line}, nil
+	case recordActor:
+		actor := ActorRecord{}
+		if err := json.Unmarshal(data, &actor); err != nil {
+			return record{}, &recordError{line: line, err: NewError(CodeMalformedRequest, "record is not a valid actor")}
+		}
+		return record{actor: &actor, line: line}, nil
+	}
+	return record{}, &recordError{line: line, err: ValidationError([]FieldError{{Field: "type", Message: "must be film or actor"}})}
+}
+
+type jsonRecordReader struct {
+	decoder *json.Decoder
+	index   int
+}
+
+func newJSONRecordReader(r io.Reader) (*jsonRecordReader, error) {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil || token != json.Delim('[') {
+		return nil, NewError(CodeMalformedRequest, "file is not a JSON array")
+	}
+	return &jsonRecordReader{decoder: decoder}, nil
+}
+
+func (r *jsonRecordReader) next() (record, error) {
+	if !r.decoder.More() {
+		// The decoder cannot go on after a syntax error, it ends the file.
+		if _, err := r.decoder.Token(); err != nil {
+			return record{}, WrapError(CodeMalformedRequest, "file is not a valid JSON array", err)
+		}
+		return record{}, io.EOF
+	}
+
+	raw := json.RawMessage{}
+	err := r.decoder.Decode(&raw)
+	if err != nil {
+		return record{}, WrapError(CodeMalformedRequest, "file is not a valid JSON array", err)
+	}
+	r.index++
+	return decodeRecord(raw, r.index)
+}
+
+// maxLineSize bounds a single line of a JSON Lines file.
+const maxLineSize = 1 << 20
+
+type jsonLinesRecordReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *jsonLinesRecordReader) next() (record, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		return decodeRecord(data, r.line)
+	}
+	if err := r.scanner.Err(); err != nil {
+		return record{}, WrapError(CodeMalformedRequest, fmt.Sprintf("line %d cannot be read", r.line+1), err)
+	}
+	return record{}, io.EOF
+}
+
+type csvRecordReader struct {
+	reader *csv.Reader
+	// films tells whether the file holds films or actors.
+	films bool
+	// columns maps a known column to its index in the file.
+	columns map[string]int
+}
+
+// newCSVRecordReader reads the header, a file with a title column holds
+// films, one with a name column actors. Columns may come in any order.
+func newCSVRecordReader(r io.Reader) (*csvRecordReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ValidationError([]FieldError{{Field: "header", Message: "file is empty"}})
+	}
+	if err != nil {
+		return nil, ValidationError([]FieldError{{Field: "header", Message: err.Error()}})
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	_, films := columns["title"]
+	known := filmColumns
+	if !films {
+		if _, ok := columns["name"]; !ok {
+			return nil, ValidationError([]FieldError{{Field: "header", Message: "title column of films or name column of actors is required"}})
+		}
+		known = actorColumns
+	}
+	for name := range columns {
+		if !slices.Contains(known, name) {
+			return nil, ValidationError([]FieldError{{Field: "header", Message: fmt.Sprintf("unknown column %q", name)}})
+		}
+	}
+	return &csvRecordReader{reader: reader, films: films, columns: columns}, nil
+}
+
+func (r *csvRecordReader) next() (record, error) {
+	row, err := r.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return record{}, io.EOF
+	}
+	parseErr := &csv.ParseError{}
+	if errors.As(err, &parseErr) {
+		return record{}, &recordError{line: parseErr.StartLine, err: NewError(CodeMalformedRequest, parseErr.Err.Error())}
+	}
+	if err != nil {
+		return record{}, fmt.Errorf("read failed: %w", err)
+	}
+	line, _ := r.reader.FieldPos(0)
+
+	value := func(column string) string {
+		i, ok := r.columns[column]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	fields := make([]FieldError, 0)
+	result := record{line: line}
+	if r.films {
+		film := FilmRecord{
+			ID:          value("id"),
+			Title:       value("title"),
+			Description: value("description"),
+		}
+		if v := value("release_date"); v != "" {
+			film.ReleaseDate, err = parseDate(v)
+			if err != nil {
+				fields = append(fields, FieldError{Field: "release_date", Message: "must be a date in YYYY-MM-DD format"})
+			}
+		}
+		if v := value("rating"); v != "" {
+			film.Rating, err = strconv.ParseFloat(v, 64)
+			if err != nil {
+				fields = append(fields, FieldError{Field: "rating", Message: "must be a number"})
+			}
+		}
+		film.Actors, err = parseCast(value("cast"))
+		if err != nil {
+			fields = append(fields, FieldError{Field: "cast", Message: err.Error()})
+		}
+		// Columns left out of the file keep the stored values.
+		if _, ok := r.columns["credits"]; ok {
+			film.Credits, err = parseCredits(value("credits"))
+			if err != nil {
+				fields = append(fields, FieldError{Field: "credits", Message: err.Error()})
+			}
+		}
+		if _, ok := r.columns["genres"]; ok {
+			film.Genres = splitList(value("genres"))
+		}
+		if _, ok := r.columns["tags"]; ok {
+			film.Tags = splitList(value("tags"))
+		}
+		result.film = &film
+	} else {
+		actor := ActorRecord{
+			ID:     value("id"),
+			Name:   value("name"),
+			Gender: value("gender"),
+		}
+		if v := value("birthday"); v != "" {
+			actor.Birthday, err = parseDate(v)
+			if err != nil {
+				fields = append(fields, FieldError{Field: "birthday", Message: "must be a date in YYYY-MM-DD format"})
+			}
+		}
+		result.actor = &actor
+	}
+
+	if len(fields) > 0 {
+		return record{}, &recordError{line: line, err: ValidationError(fields)}
+	}
+	return result, nil
+}
+
+// WatchlistRecord is an entry of an exported watchlist.
+type WatchlistRecord struct {
+	Position    int       `json:"position"`
+	FilmID      string    `json:"film_id"`
+	Title       string    `json:"title"`
+	ReleaseDate time.Time `json:"release_date"`
+	AddedAt     time.Time `json:"added_at"`
+}
+
+func newWatchlistRecord(entry entities.WatchlistEntry) WatchlistRecord {
+	return WatchlistRecord{
+		Position:    entry.Position,
+		FilmID:      entry.FilmID,
+		Title:       entry.Title,
+		ReleaseDate: entry.ReleaseDate,
+		AddedAt:     entry.AddedAt,
+	}
+}
+
+// HistoryRecord is a viewing of an exported watch history.
+type HistoryRecord struct {
+	ID        string    `json:"id"`
+	FilmID    string    `json:"film_id"`
+	Title     string    `json:"title"`
+	WatchedAt time.Time `json:"watched_at"`
+	Rating    *float64  `json:"rating"`
+}
+
+func newHistoryRecord(entry entities.HistoryEntry) HistoryRecord {
+	return HistoryRecord{
+		ID:        entry.ID,
+		FilmID:    entry.FilmID,
+		Title:     entry.Title,
+		WatchedAt: entry.WatchedAt,
+		Rating:    entry.Rating,
+	}
+}
+
+// recordWriter encodes the records of an export.
+type recordWriter interface {
+	film(record FilmRecord) error
+	actor(record ActorRecord) error
+	watchlistEntry(record WatchlistRecord) error
+	historyEntry(record HistoryRecord) error
+	// close ends the file, no record may follow.
+	close() error
+}
+
+type jsonRecordWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (w *jsonRecordWriter) film(record FilmRecord) error {
+	return w.write(record)
+}
+
+func (w *jsonRecordWriter) actor(record ActorRecord) error {
+	return w.write(record)
+}
+
+func (w *jsonRecordWriter) watchlistEntry(record WatchlistRecord) error {
+	return w.write(record)
+}
+
+func (w *jsonRecordWriter) historyEntry(record HistoryRecord) error {
+	return w.write(record)
+}
+
+func (w *jsonRecordWriter) write(record any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal failed: %w", err)
+	}
+
+	separator := ",\n"
+	if !w.written {
+		separator = "[\n"
+		w.written = true
+	}
+	_, err = io.WriteString(w.w, separator)
+	if err != nil {
+		return fmt.Errorf("write failed: %w", err)
+	}
+	_, err = w.w.Write(data)
+	if err != nil {
+		return fmt.Errorf("write failed: %w", err)
+	}
+	return nil
+}
+
+func (w *jsonRecordWriter) close() error {
+	end := "\n]\n"
+	if !w.written {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(w.w, end)
+	if err != nil {
+		return fmt.Errorf("write failed: %w", err)
+	}
+	return nil
+}
+
+type jsonLinesRecordWriter struct {
+	encoder *json.Encoder
+}
+
+func (w jsonLinesRecordWriter) film(record FilmRecord) error {
+	return w.encoder.Encode(record)
+}
+
+func (w jsonLinesRecordWriter) actor(record ActorRecord) error {
+	return w.encoder.Encode(record)
+}
+
+func (w jsonLinesRecordWriter) watchlistEntry(record WatchlistRecord) error {
+	return w.encoder.Encode(record)
+}
+
+func (w jsonLinesRecordWriter) historyEntry(record HistoryRecord) error {
+	return w.encoder.Encode(record)
+}
+
+func (w jsonLinesRecordWriter) close() error {
+	return nil
+}
+
+// csvRecordWriter writes records of one kind, a CSV file holds only one
+// of them.
+type csvRecordWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVRecordWriter(w io.Writer, columns []string) (csvRecordWriter, error) {
+	writer := csv.NewWriter(w)
+	err := writer.Write(columns)
+	if err != nil {
+		return csvRecordWriter{}, fmt.Errorf("write header failed: %w", err)
+	}
+	return csvRecordWriter{writer: writer}, nil
+}
+
+func (w csvRecordWriter) film(record FilmRecord) error {
+	return w.writer.Write([]string{
+		record.ID,
+		record.Title,
+		record.Description,
+		formatDate(record.ReleaseDate),
+		strconv.FormatFloat(record.Rating, 'f', -1, 64),
+		formatCast(record.Actors),
+		formatCredits(record.Credits),
+		strings.Join(record.Genres, ";"),
+		strings.Join(record.Tags, ";"),
+	})
+}
+
+func (w csvRecordWriter) actor(record ActorRecord) error {
+	return w.writer.Write([]string{
+		record.ID,
+		record.Name,
+		record.Gender,
+		formatDate(record.Birthday),
+	})
+}
+
+func (w csvRecordWriter) watchlistEntry(record WatchlistRecord) error {
+	return w.writer.Write([]string{
+		strconv.Itoa(record.Position),
+		record.FilmID,
+		record.Title,
+		formatDate(record.ReleaseDate),
+		record.AddedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (w csvRecordWriter) historyEntry(record HistoryRecord) error {
+	rating := ""
+	if record.Rating != nil {
+		rating = strconv.FormatFloat(*record.Rating, 'f', -1, 64)
+	}
+	return w.writer.Write([]string{
+		record.ID,
+		record.FilmID,
+		record.Title,
+		formatDate(record.WatchedAt),
+		rating,
+	})
+}
+
+func (w csvRecordWriter) close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// parseCast reads the cast column, name|gender|birthday entries separated
+// by semicolons. Gender and birthday may be left out.
+func parseCast(value string) ([]entities.ActorEntity, error) {
+	actors := make([]entities.ActorEntity, 0)
+	for _, entry := range strings.Split(value, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		parts := strings.Split(entry, "|")
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("actor %q must be name|gender|birthday", entry)
+		}
+		parts = append(parts, "", "")
+
+		actor := entities.ActorEntity{
+			Name:   strings.TrimSpace(parts[0]),
+			Gender: strings.TrimSpace(parts[1]),
+		}
+		if v := strings.TrimSpace(parts[2]); v != "" {
+			birthday, err := parseDate(v)
+			if err != nil {
+				return nil, fmt.Errorf("birthday of %q must be a date in YYYY-MM-DD format", actor.Name)
+			}
+			actor.Birthday = birthday
+		}
+		actors = append(actors, actor)
+	}
+	return actors, nil
+}
+
+// formatCast is the inverse of parseCast.
+func formatCast(actors []entities.ActorEntity) string {
+	entries := make([]string, 0, len(actors))
+	for _, actor := range actors {
+		entries = append(entries, actor.Name+"|"+actor.Gender+"|"+formatDate(actor.Birthday))
+	}
+	return strings.Join(entries, ";")
+}
+
+// parseCredits reads the credits column, role|name|gender|birthday|
+// character|billing entries separated by semicolons. Everything after the
+// name may be left out.
+func parseCredits(value string) ([]CreditRecord, error) {
+	credits := make([]CreditRecord, 0)
+	for _, entry := range strings.Split(value, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		parts := strings.Split(entry, "|")
+		if len(parts) < 2 || len(parts) > 6 {
+			return nil, fmt.Errorf("credit %q must be role|name|gender|birthday|character|billing", entry)
+		}
+		parts = append(parts, "", "", "", "")
+
+		credit := CreditRecord{
+			Role:      entities.CreditRole(strings.TrimSpace(parts[0])),
+			Name:      strings.TrimSpace(parts[1]),
+			Gender:    strings.TrimSpace(parts[2]),
+			Character: strings.TrimSpace(parts[4]),
+		}
+		if v := strings.TrimSpace(parts[3]); v != "" {
+			birthday, err := parseDate(v)
+			if err != nil {
+				return nil, fmt.Errorf("birthday of %q must be a date in YYYY-MM-DD format", credit.Name)
+			}
+			credit.Birthday = birthday
+		}
+		if v := strings.TrimSpace(parts[5]); v != "" {
+			billing, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("billing of %q must be a whole number", credit.Name)
+			}
+			credit.Billing = billing
+		}
+		credits = append(credits, credit)
+	}
+	return credits, nil
+}
+
+// formatCredits is the inverse of parseCredits.
+func formatCredits(credits []CreditRecord) string {
+	entries := make([]string, 0, len(credits))
+	for _, credit := range credits {
+		entries = append(entries, strings.Join([]string{
+			string(credit.Role),
+			credit.Name,
+			credit.Gender,
+			formatDate(credit.Birthday),
+			credit.Character,
+			strconv.Itoa(credit.Billing),
+		}, "|"))
+	}
+	return strings.Join(entries, ";")
+}
+
+// splitList reads a column of values separated by semicolons.
+func splitList(value string) []string {
+	values := make([]string, 0)
+	for _, v := range strings.Split(value, ";") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// parseDate accepts plain dates as well as the RFC 3339 timestamps of the
+// JSON API.
+func parseDate(value string) (time.Time, error) {
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Parse(time.RFC3339, value)
+	}
+	return t, nil
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.DateOnly)
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"errors"
+	"filmography/internal/entities"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readRecords reads r to the end and describes every record as
+// "line: film title", "line: actor name" or "line: code fields" for a
+// skipped one. An error of the whole file ends the list as "fatal: code".
+func readRecords(r recordReader) []string {
+	got := make([]string, 0)
+	for {
+		record, err := r.next()
+		if errors.Is(err, io.EOF) {
+			return got
+		}
+		var recordErr *recordError
+		if errors.As(err, &recordErr) {
+			fields := make([]string, 0, len(recordErr.err.Fields))
+			for _, field := range recordErr.err.Fields {
+				fields = append(fields, field.Field)
+			}
+			got = append(got, fmt.Sprintf("%d: %s %s", recordErr.line, recordErr.err.Code, strings.Join(fields, ",")))
+			continue
+		}
+		if err != nil {
+			var svcErr *Error
+			if errors.As(err, &svcErr) {
+				return append(got, "fatal: "+string(svcErr.Code))
+			}
+			return append(got, "fatal: "+err.Error())
+		}
+		if record.film != nil {
+			got = append(got, fmt.Sprintf("%d: film %s", record.line, record.film.Title))
+		} else {
+			got = append(got, fmt.Sprintf("%d: actor %s", record.line, record.actor.Name))
+		}
+	}
+}
+
+func TestRecordReaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  FileFormat
+		file    string
+		want    []string
+		wantErr ErrorCode
+	}{
+		{
+			name:   "json positions",
+			format: FormatJSON,
+			file:   `[{"name": "Heat"}, {"type": "actor", "name": "Al Pacino"}, {"type": "film", "name": "Ronin"}]`,
+			want:   []string{"1: film Heat", "2: actor Al Pacino", "3: film Ronin"},
+		},
+		{
+			name:   "json bad records are skipped",
+			format: FormatJSON,
+			file:   `[{"type": "series"}, 42, {"name": 7}, {"name": "Heat"}]`,
+			want:   []string{"1: validation_failed type", "2: malformed_request ", "3: malformed_request ", "4: film Heat"},
+		},
+		{
+			name:   "json syntax error ends the file",
+			format: FormatJSON,
+			file:   `[{"name": "Heat"}, {"name": ]`,
+			want:   []string{"1: film Heat", "fatal: malformed_request"},
+		},
+		{
+			name:    "json not an array",
+			format:  FormatJSON,
+			file:    `{"name": "Heat"}`,
+			wantErr: CodeMalformedRequest,
+		},
+		{
+			name:   "ndjson lines",
+			format: FormatJSONLines,
+			file:   "{\"name\": \"Heat\"}\n\n   \n{\"type\": \"actor\", \"name\": \"Al Pacino\"}\nnot json\n{\"name\": \"Ronin\"}\n",
+			want:   []string{"1: film Heat", "4: actor Al Pacino", "5: malformed_request ", "6: film Ronin"},
+		},
+		{
+			name:   "csv films",
+			format: FormatCSV,
+			file:   "title,release_date,rating\nHeat,1995-12-15,8.3\nRonin,15.09.1998,x\n\"Multi\nline\",,\nThief,,\n",
+			want:   []string{"2: film Heat", "3: validation_failed release_date,rating", "4: film Multi\nline", "6: film Thief"},
+		},
+		{
+			name:   "csv columns in any order and case",
+			format: FormatCSV,
+			file:   " Birthday ,NAME\n1940-04-25,Al Pacino\n",
+			want:   []string{"2: actor Al Pacino"},
+		},
+		{
+			name:   "csv malformed quote",
+			format: FormatCSV,
+			file:   "name\nAl \"Pacino\nRobert De Niro\n",
+			want:   []string{"2: malformed_request ", "3: actor Robert De Niro"},
+		},
+		{
+			name:   "csv bad cast and credits",
+			format: FormatCSV,
+			file:   "title,cast,credits\nHeat,Al Pacino|male|yesterday,\nRonin,,director\n",
+			want:   []string{"2: validation_failed cast", "3: validation_failed credits"},
+		},
+		{
+			name:    "csv unknown column",
+			format:  FormatCSV,
+			file:    "title,director\nHeat,Mann\n",
+			wantErr: CodeValidationFailed,
+		},
+		{
+			name:    "csv actor file with a film column",
+			format:  FormatCSV,
+			file:    "name,rating\nAl Pacino,8\n",
+			wantErr: CodeValidationFailed,
+		},
+		{
+			name:    "csv without title or name",
+			format:  FormatCSV,
+			file:    "id,gender\n1,male\n",
+			wantErr: CodeValidationFailed,
+		},
+		{
+			name:    "csv empty",
+			format:  FormatCSV,
+			file:    "",
+			wantErr: CodeValidationFailed,
+		},
+		{
+			name:    "unknown format",
+			format:  "xml",
+			file:    "<films/>",
+			wantErr: CodeValidationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		reader, err := newRecordReader(strings.NewReader(tt.file), tt.format)
+		if tt.wantErr != "" {
+			var svcErr *Error
+			if !errors.As(err, &svcErr) || svcErr.Code != tt.wantErr {
+				t.Errorf("%s: newRecordReader() error = %v, want %s", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: newRecordReader() error = %v", tt.name, err)
+		}
+		if got := readRecords(reader); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: records = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCSVFilmRecord(t *testing.T) {
+	file := "id,title,description,release_date,rating,cast,credits,genres,tags\n" +
+		"f1,Heat,\"Cops, robbers\",1995-12-15,8.3," +
+		"Al Pacino|male|1940-04-25;Robert De Niro," +
+		"director|Michael Mann|male|1943-02-05;actor|Al Pacino||1940-04-25|Vincent Hanna|1," +
+		"Crime; Drama;,Heist\n"
+	reader, err := newRecordReader(strings.NewReader(file), FormatCSV)
+	if err != nil {
+		t.Fatalf("newRecordReader() error = %v", err)
+	}
+	record, err := reader.next()
+	if err != nil {
+		t.Fatalf("next() error = %v", err)
+	}
+
+	got := *record.film
+	want := FilmRecord{
+		ID:          "f1",
+		Title:       "Heat",
+		Description: "Cops, robbers",
+		ReleaseDate: time.Date(1995, 12, 15, 0, 0, 0, 0, time.UTC),
+		Rating:      8.3,
+		Actors: []entities.ActorEntity{
+			{Name: "Al Pacino", Gender: "male", Birthday: time.Date(1940, 4, 25, 0, 0, 0, 0, time.UTC)},
+			{Name: "Robert De Niro"},
+		},
+		Credits: []CreditRecord{
+			{Name: "Michael Mann", Gender: "male", Birthday: time.Date(1943, 2, 5, 0, 0, 0, 0, time.UTC), Role: entities.CreditDirector},
+			{Name: "Al Pacino", Birthday: time.Date(1940, 4, 25, 0, 0, 0, 0, time.UTC), Role: entities.CreditActor, Character: "Vincent Hanna", Billing: 1},
+		},
+		Genres: []string{"Crime", "Drama"},
+		Tags:   []string{"Heist"},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("film record =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// A file without the credits, genres and tags columns keeps the stored
+	// ones, which the record tells by nil.
+	reader, err = newRecordReader(strings.NewReader("title\nHeat\n"), FormatCSV)
+	if err != nil {
+		t.Fatalf("newRecordReader() error = %v", err)
+	}
+	record, err = reader.next()
+	if err != nil {
+		t.Fatalf("next() error = %v", err)
+	}
+	if record.film.Credits != nil || record.film.Genres != nil || record.film.Tags != nil {
+		t.Errorf("film record without the columns = %+v, want nil credits, genres and tags", *record.film)
+	}
+}
+
+func TestParseCast(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{" ; ;", "", false},
+		{"Al Pacino", "Al Pacino||", false},
+		{" Al Pacino | male | 1940-04-25 ", "Al Pacino|male|1940-04-25", false},
+		{"Al Pacino||1940-04-25T00:00:00Z;Robert De Niro|male", "Al Pacino||1940-04-25;Robert De Niro|male|", false},
+		{"Al Pacino|male|1940-04-25|extra", "", true},
+		{"Al Pacino|male|25.04.1940", "", true},
+	}
+
+	for _, tt := range tests {
+		actors, err := parseCast(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCast(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if actors == nil {
+			t.Errorf("parseCast(%q) = nil, want an empty cast", tt.value)
+		}
+		if got := formatCast(actors); got != tt.want {
+			t.Errorf("parseCast(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseCredits(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"director|Michael Mann", "director|Michael Mann||||0", false},
+		{"actor|Al Pacino|male|1940-04-25|Vincent Hanna|1", "actor|Al Pacino|male|1940-04-25|Vincent Hanna|1", false},
+		{"director", "", true},
+		{"actor|Al Pacino|male|1940-04-25|Vincent Hanna|1|extra", "", true},
+		{"actor|Al Pacino|||Vincent Hanna|first", "", true},
+		{"writer|Michael Mann||1943", "", true},
+	}
+
+	for _, tt := range tests {
+		credits, err := parseCredits(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCredits(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := formatCredits(credits); got != tt.want {
+			t.Errorf("parseCredits(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
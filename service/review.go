@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"filmography/internal/entities"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrReviewNotFound = NewError(CodeReviewNotFound, "review not found")
+	ErrReviewExists   = NewError(CodeReviewExists, "film already reviewed by the user")
+)
+
+type ReviewService struct {
+	repo ReviewRepoInterface
+}
+
+type ReviewRepoInterface interface {
+	CreateReview(ctx context.Context, review entities.ReviewEntity) error
+	GetReviews(ctx context.Context, query entities.ReviewQuery) ([]entities.ReviewEntity, int, error)
+	GetReview(ctx context.Context, id string) (entities.ReviewEntity, error)
+	UpdateReview(ctx context.Context, review entities.ReviewEntity) error
+	DeleteReview(ctx context.Context, id string) error
+}
+
+func NewReviewService(repo ReviewRepoInterface) ReviewService {
+	return ReviewService{
+		repo: repo,
+	}
+}
+
+// CreateReview stores the review of the user, it waits for moderation
+// before it is published. A user reviews a film once.
+func (svc ReviewService) CreateReview(ctx context.Context, filmID string, userID string, text string) (entities.ReviewEntity, error) {
+	if fields := validateReview(text); len(fields) > 0 {
+		return entities.ReviewEntity{}, ValidationError(fields)
+	}
+
+	now := time.Now().UTC()
+	review := entities.ReviewEntity{
+		ID:        uuid.NewString(),
+		FilmID:    filmID,
+		UserID:    userID,
+		Text:      text,
+		Status:    entities.ReviewPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err := svc.repo.CreateReview(ctx, review)
+	if errors.Is(err, entities.ErrConflict) {
+		return entities.ReviewEntity{}, WrapError(ErrReviewExists.Code, ErrReviewExists.Message, err)
+	}
+	if err != nil {
+		return entities.ReviewEntity{}, fmt.Errorf("create review failed: %w", repoError(err, ErrFilmNotFound))
+	}
+	return svc.GetReview(ctx, review.ID, entities.TokenClaims{UserID: userID})
+}
+
+// GetFilmReviews lists the published reviews of a film.
+func (svc ReviewService) GetFilmReviews(ctx context.Context, query entities.ReviewQuery) ([]entities.ReviewEntity, int, error) {
+	query.Status = entities.ReviewPublished
+	reviews, total, err := svc.repo.GetReviews(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get reviews failed: %w", repoError(err, ErrFilmNotFound))
+	}
+	return reviews, total, nil
+}
+
+// GetReviews lists reviews in any status, it backs the moderation queue
+// and the reviews of a user.
+func (svc ReviewService) GetReviews(ctx context.Context, query entities.ReviewQuery) ([]entities.ReviewEntity, int, error) {
+	if query.Status != "" && !query.Status.Valid() {
+		return nil, 0, ValidationError([]FieldError{{Field: "status", Message: "must be one of pending, published, rejected"}})
+	}
+	reviews, total, err := svc.repo.GetReviews(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get reviews failed: %w", repoError(err, ErrFilmNotFound))
+	}
+	return reviews, total, nil
+}
+
+// GetReview returns a review the user may see: a published one, their own
+// or, for admins, any.
+func (svc ReviewService) GetReview(ctx context.Context, id string, user entities.TokenClaims) (entities.ReviewEntity, error) {
+	review, err := svc.repo.GetReview(ctx, id)
+	if err != nil {
+		return entities.ReviewEntity{}, fmt.Errorf("get review failed: %w", repoError(err, ErrReviewNotFound))
+	}
+	if review.Status != entities.ReviewPublished && review.UserID != user.UserID && user.Role != entities.Admin {
+		return entities.ReviewEntity{}, ErrReviewNotFound
+	}
+	return review, nil
+}
+
+// UpdateReview replaces the text of a review by its author, the edited
+// review goes back to moderation.
+func (svc ReviewService) UpdateReview(ctx context.Context, id string, text string, user entities.TokenClaims) (entities.ReviewEntity, error) {
+	review, err := svc.GetReview(ctx, id, user)
+	if err != nil {
+		return entities.ReviewEntity{}, err
+	}
+	if review.UserID != user.UserID {
+		return entities.ReviewEntity{}, ErrForbidden
+	}
+	if fields := validateReview(text); len(fields) > 0 {
+		return entities.ReviewEntity{}, ValidationError(fields)
+	}
+
+	review.Text = text
+	review.Status = entities.ReviewPending
+	review.UpdatedAt = time.Now().UTC()
+	review.ModeratorID = ""
+	review.ModeratedAt = nil
+	err = svc.repo.UpdateReview(ctx, review)
+	if err != nil {
+		return entities.ReviewEntity{}, fmt.Errorf("update review failed: %w", repoError(err, ErrReviewNotFound))
+	}
+	return review, nil
+}
+
+// ModerateReview publishes or rejects a review on behalf of an admin.
+func (svc ReviewService) ModerateReview(ctx context.Context, id string, status entities.ReviewStatus, moderatorID string) (entities.ReviewEntity, error) {
+	if status != entities.ReviewPublished && status != entities.ReviewRejected {
+		return entities.ReviewEntity{}, ValidationError([]FieldError{{Field: "status", Message: "must be one of published, rejected"}})
+	}
+
+	review, err := svc.repo.GetReview(ctx, id)
+	if err != nil {
+		return entities.ReviewEntity{}, fmt.Errorf("get review failed: %w", repoError(err, ErrReviewNotFound))
+	}
+
+	now := time.Now().UTC()
+	review.Status = status
+	review.ModeratorID = moderatorID
+	review.ModeratedAt = &now
+	err = svc.repo.UpdateReview(ctx, review)
+	if err != nil {
+		return entities.ReviewEntity{}, fmt.Errorf("update review failed: %w", repoError(err, ErrReviewNotFound))
+	}
+	return review, nil
+}
+
+// DeleteReview removes a review of the user, admins may remove any.
+func (svc ReviewService) DeleteReview(ctx context.Context, id string, user entities.TokenClaims) error {
+	review, err := svc.GetReview(ctx, id, user)
+	if err != nil {
+		return err
+	}
+	if review.UserID != user.UserID && user.Role != entities.Admin {
+		return ErrForbidden
+	}
+
+	err = svc.repo.DeleteReview(ctx, id)
+	if err != nil {
+		return fmt.Errorf("delete review failed: %w", repoError(err, ErrReviewNotFound))
+	}
+	return nil
+}
+
+func validateReview(text string) []FieldError {
+	if len(strings.TrimSpace(text)) == 0 || len(text) > 5000 {
+		return []FieldError{{Field: "text", Message: "must be between 1 and 5000 characters long"}}
+	}
+	return nil
+}
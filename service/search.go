@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type SearchService struct {
+	repo SearchRepoInterface
+}
+
+// SearchRepoInterface is implemented by backends with native full-text
+// search. Other backends get memorySearch.
+type SearchRepoInterface interface {
+	Search(ctx context.Context, query string, limit int) ([]entities.SearchResult, error)
+}
+
+func NewSearchService(repo SearchRepoInterface) SearchService {
+	return SearchService{
+		repo: repo,
+	}
+}
+
+func (svc SearchService) Search(ctx context.Context, query string, limit int) ([]entities.SearchResult, error) {
+	results, err := svc.repo.Search(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	return results, err
+}
+
+// memorySearch ranks films and actors in memory by paging through the
+// regular list methods. Every word of the query has to match a word of the
+// title, description or name as a prefix.
+type memorySearch struct {
+	films  FilmRepoInterface
+	actors ActorRepoInterface
+}
+
+const (
+	searchPageSize    = 100
+	searchSnippetSize = 160
+	titleWeight       = 1.0
+	descriptionWeight = 0.4
+)
+
+func (s memorySearch) Search(ctx context.Context, query string, limit int) ([]entities.SearchResult, error) {
+	terms := searchWords(query)
+	results := make([]entities.SearchResult, 0)
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	for offset := 0; ; offset += searchPageSize {
+		films, total, err := s.films.GetFilms(ctx, entities.FilmQuery{ListQuery: entities.ListQuery{Limit: searchPageSize, Offset: offset}})
+		if err != nil {
+			return nil, fmt.Errorf("get films failed: %w", err)
+		}
+		for _, film := range films {
+			if !matchesAll(terms, film.Title+" "+film.Description) {
+				continue
+			}
+			results = append(results, entities.SearchResult{
+				Type:    entities.SearchTypeFilm,
+				ID:      film.ID,
+				Title:   film.Title,
+				Snippet: highlight(terms, film.Title+" — "+film.Description),
+				Rank:    matchRank(terms, film.Title, titleWeight) + matchRank(terms, film.Description, descriptionWeight),
+			})
+		}
+		if offset+searchPageSize >= total {
+			break
+		}
+	}
+
+	for offset := 0; ; offset += searchPageSize {
+		actors, total, err := s.actors.GetActors(ctx, entities.ActorQuery{ListQuery: entities.ListQuery{Limit: searchPageSize, Offset: offset}})
+		if err != nil {
+			return nil, fmt.Errorf("get actors failed: %w", err)
+		}
+		for _, actor := range actors {
+			if !matchesAll(terms, actor.Name) {
+				continue
+			}
+			results = append(results, entities.SearchResult{
+				Type:    entities.SearchTypeActor,
+				ID:      actor.ID,
+				Title:   actor.Name,
+				Snippet: highlight(terms, actor.Name),
+				Rank:    matchRank(terms, actor.Name, titleWeight),
+			})
+		}
+		if offset+searchPageSize >= total {
+			break
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func matchesAll(terms []string, text string) bool {
+	words := searchWords(text)
+	for _, term := range terms {
+		if !hasPrefixWord(words, term) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasPrefixWord(words []string, term string) bool {
+	for _, word := range words {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRank weights the share of words in text matched by the terms.
+func matchRank(terms []string, text string, weight float64) float64 {
+	words := searchWords(text)
+	if len(words) == 0 {
+		return 0
+	}
+	matched := 0
+	for _, word := range words {
+		if matchesTerm(terms, word) {
+			matched++
+		}
+	}
+	return weight * float64(matched) / float64(len(words))
+}
+
+// highlight cuts text down to a snippet around the first word matched by
+// the terms and wraps every matched word in <mark> tags. The snippet is
+// HTML, the text is escaped so that only the tags are markup.
+func highlight(terms []string, text string) string {
+	runes := []rune(text)
+	words := wordSpans(runes)
+
+	if len(runes) > searchSnippetSize {
+		start := 0
+		for _, span := range words {
+			if matchesTerm(terms, string(runes[span[0]:span[1]])) {
+				start = max(0, span[0]-searchSnippetSize/4)
+				break
+			}
+		}
+		end := min(len(runes), start+searchSnippetSize)
+		prefix, suffix := "", ""
+		if start > 0 {
+			prefix = "…"
+		}
+		if end < len(runes) {
+			suffix = "…"
+		}
+		runes = []rune(prefix + string(runes[start:end]) + suffix)
+		words = wordSpans(runes)
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, span := range words {
+		word := string(runes[span[0]:span[1]])
+		if !matchesTerm(terms, word) {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[last:span[0]])))
+		b.WriteString("<mark>" + html.EscapeString(word) + "</mark>")
+		last = span[1]
+	}
+	b.WriteString(html.EscapeString(string(runes[last:])))
+	return b.String()
+}
+
+// wordSpans returns the start and end rune offsets of the words of text.
+func wordSpans(runes []rune) [][2]int {
+	spans := make([][2]int, 0)
+	for i := 0; i < len(runes); {
+		if !unicode.IsLetter(runes[i]) && !unicode.IsDigit(runes[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+			j++
+		}
+		spans = append(spans, [2]int{i, j})
+		i = j
+	}
+	return spans
+}
+
+func matchesTerm(terms []string, word string) bool {
+	word = strings.ToLower(word)
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"filmography/internal/repository/memory"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMemorySearch(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.New()
+	films := []entities.FilmEntity{
+		{ID: "f1", Title: "Heat", Description: "A heist thriller"},
+		{ID: "f2", Title: "Collateral", Description: "A night of heat in LA"},
+		{ID: "f3", Title: "Alien", Description: "In space no one can hear you scream"},
+	}
+	for _, film := range films {
+		if err := repo.CreateFilm(ctx, film); err != nil {
+			t.Fatalf("CreateFilm() error = %v", err)
+		}
+	}
+	if err := repo.CreateActor(ctx, entities.ActorEntity{ID: "a1", Name: "Heath Ledger"}); err != nil {
+		t.Fatalf("CreateActor() error = %v", err)
+	}
+	search := memorySearch{films: repo, actors: repo}
+
+	tests := []struct {
+		name, query string
+		limit       int
+		want        []string
+	}{
+		// A title match outranks a name matched in part, which outranks a
+		// description match.
+		{"ranking", "heat", 10, []string{"f1", "a1", "f2"}},
+		{"limit", "heat", 2, []string{"f1", "a1"}},
+		{"every word", "heat thriller", 10, []string{"f1"}},
+		{"case and punctuation", "HEAT!", 10, []string{"f1", "a1", "f2"}},
+		{"no match", "predator", 10, []string{}},
+		{"no words", "?!", 10, []string{}},
+	}
+
+	for _, tt := range tests {
+		results, err := search.Search(ctx, tt.query, tt.limit)
+		if err != nil {
+			t.Fatalf("%s: Search() error = %v", tt.name, err)
+		}
+		got := make([]string, 0, len(results))
+		for _, result := range results {
+			got = append(got, result.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Search(%q) = %v, want %v", tt.name, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	long := strings.Repeat("word ", 60) + "heat " + strings.Repeat("word ", 60)
+
+	tests := []struct {
+		name  string
+		terms []string
+		text  string
+		want  string
+	}{
+		{"prefix", []string{"heat"}, "Heath and Heat", "<mark>Heath</mark> and <mark>Heat</mark>"},
+		{"no match", []string{"heat"}, "Collateral", "Collateral"},
+		{"markup in text", []string{"heat"}, `<script>alert("heat")</script>`, `&lt;script&gt;alert(&#34;<mark>heat</mark>&#34;)&lt;/script&gt;`},
+		{"entities in text", []string{"tom"}, "Tom & Jerry's", "<mark>Tom</mark> &amp; Jerry&#39;s"},
+	}
+
+	for _, tt := range tests {
+		if got := highlight(tt.terms, tt.text); got != tt.want {
+			t.Errorf("%s: highlight() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	got := highlight([]string{"heat"}, long)
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "<mark>heat</mark>") {
+		t.Errorf("highlight() of a long text = %q, want a snippet around the match", got)
+	}
+}
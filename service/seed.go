@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"filmography/internal/entities"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+)
+
+// Fixture is a catalogue snapshot the store can be seeded from. Actors and
+// films keep their IDs so that films can reference their cast, users get
+// their passwords hashed on load.
+type Fixture struct {
+	Actors []entities.ActorEntity
+	Films  []entities.FilmEntity
+	Users  []FixtureUser
+}
+
+type FixtureUser struct {
+	Username string
+	Role     entities.Role
+	Password string
+}
+
+// LoadFixture reads a JSON fixture file.
+func LoadFixture(path string) (Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Fixture{}, fmt.Errorf("read file failed: %w", err)
+	}
+
+	fixture := Fixture{}
+	err = json.Unmarshal(data, &fixture)
+	if err != nil {
+		return Fixture{}, fmt.Errorf("unmarshal failed: %w", err)
+	}
+	return fixture, nil
+}
+
+// Seed stores the fixture. Records that already exist are left untouched,
+// so seeding the same fixture twice is harmless.
+func (svc Service) Seed(ctx context.Context, fixture Fixture) error {
+	for _, actor := range fixture.Actors {
+		if actor.ID == "" {
+			actor.ID = uuid.NewString()
+		} else if _, err := svc.ActorService.repo.GetActor(ctx, actor.ID); err == nil {
+			continue
+		}
+		if fields := validateActor(actor); len(fields) > 0 {
+			return fmt.Errorf("actor %q: %w", actor.ID, ValidationError(fields))
+		}
+		err := svc.ActorService.repo.CreateActor(ctx, actor)
+		if err != nil {
+			return fmt.Errorf("create actor %q failed: %w", actor.ID, err)
+		}
+	}
+
+	for _, film := range fixture.Films {
+		if film.ID == "" {
+			film.ID = uuid.NewString()
+		} else if _, err := svc.FilmService.repo.GetFilm(ctx, film.ID); err == nil {
+			continue
+		}
+		if fields := validateFilm(film); len(fields) > 0 {
+			return fmt.Errorf("film %q: %w", film.ID, ValidationError(fields))
+		}
+		err := svc.FilmService.repo.CreateFilm(ctx, film)
+		if err != nil {
+			return fmt.Errorf("create film %q failed: %w", film.ID, err)
+		}
+	}
+
+	for _, user := range fixture.Users {
+		err := svc.UserService.CreateUser(ctx, entities.UserEntity{Username: user.Username, Role: user.Role}, user.Password)
+		if err != nil && !errors.Is(err, ErrUserExists) {
+			return fmt.Errorf("create user %q failed: %w", user.Username, err)
+		}
+	}
+
+	return nil
+}
@@ -9,12 +9,25 @@ type Service struct {
 	FilmService
 	AuthService
 	UserService
+	SearchService
+	ImportService
+	ExportService
+	RatingService
+	ReviewService
+	WatchService
+	GenreService
 }
 
 type Repo interface {
 	ActorRepoInterface
 	FilmRepoInterface
 	UserRepoInterface
+	ImportRepoInterface
+	ExportRepoInterface
+	RatingRepoInterface
+	ReviewRepoInterface
+	WatchRepoInterface
+	GenreRepoInterface
 }
 
 type Cache interface {
@@ -22,9 +35,24 @@ type Cache interface {
 }
 
 func New(repo Repo, cache Cache, cfg config.Config) Service {
+	users := NewUserService(repo)
+
+	searcher, ok := repo.(SearchRepoInterface)
+	if !ok {
+		searcher = memorySearch{films: repo, actors: repo}
+	}
+
 	return Service{
-		ActorService: NewActorService(repo),
-		FilmService:  NewFilmService(repo),
-		AuthService:  NewAuthService(cache, cfg),
+		ActorService:  NewActorService(repo, cfg.RatingPriorWeight),
+		FilmService:   NewFilmService(repo, cfg.RatingPriorWeight),
+		AuthService:   NewAuthService(cache, users, cfg),
+		UserService:   users,
+		SearchService: NewSearchService(searcher),
+		ImportService: NewImportService(repo),
+		ExportService: NewExportService(repo),
+		RatingService: NewRatingService(repo, cfg.RatingPriorWeight),
+		ReviewService: NewReviewService(repo),
+		WatchService:  NewWatchService(repo),
+		GenreService:  NewGenreService(repo),
 	}
 }
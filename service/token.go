@@ -1,57 +1,79 @@
 package service
 
 import (
+	"errors"
 	"filmography/internal/entities"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 )
 
 const (
-	Admin = "admin"
+	AccessToken  = "access"
+	RefreshToken = "refresh"
 )
 
 var (
-	ErrTokenExpired = fmt.Errorf("token expired")
-	ErrUnknownType  = fmt.Errorf("unknown type")
+	ErrTokenMissing   = NewError(CodeTokenMissing, "access token required")
+	ErrTokenExpired   = NewError(CodeTokenExpired, "token expired")
+	ErrTokenInvalid   = NewError(CodeTokenInvalid, "wrong token")
+	ErrUnknownRole    = NewError(CodeTokenInvalid, "unknown role")
+	ErrWrongTokenType = NewError(CodeTokenInvalid, "wrong token type")
+	ErrLoggedOut      = NewError(CodeTokenInvalid, "you already logged out")
+	ErrTokenReused    = NewError(CodeTokenReused, "refresh token reused")
+	ErrTokenRevoked   = NewError(CodeTokenRevoked, "refresh token revoked")
+	ErrForbidden      = NewError(CodeForbidden, "forbidden")
 )
 
 type TokenParams struct {
-	ID              any
-	Type            string
+	ID              string
+	Role            entities.Role
+	Family          string
 	Hs256Secret     string
 	AccessTokenExp  int
 	RefreshTokenExp int
 }
 
+// NewToken issues an access and refresh token pair. The refresh token gets
+// a fresh jti and belongs to params.Family, a new family is started if it
+// is empty.
 func NewToken(params TokenParams) (*entities.Token, error) {
-	if params.Type != Admin {
-		return nil, ErrUnknownType
+	if !params.Role.Valid() {
+		return nil, ErrUnknownRole
+	}
+	if params.Family == "" {
+		params.Family = uuid.NewString()
 	}
 
 	accessExp := time.Now().Add(time.Duration(params.AccessTokenExp) * time.Minute)
 
-	access, err := newJwt(accessExp, params)
+	access, err := newJwt(accessExp, params, jwt.MapClaims{"typ": AccessToken})
 	if err != nil {
 		return nil, fmt.Errorf("new jwt failed: %w", err)
 	}
 
 	rtExp := time.Now().Add(time.Duration(params.RefreshTokenExp) * 24 * time.Hour)
+	rtID := uuid.NewString()
 
-	rt, err := newJwt(rtExp, params)
+	rt, err := newJwt(rtExp, params, jwt.MapClaims{"typ": RefreshToken, "jti": rtID, "fam": params.Family})
 	if err != nil {
 		return nil, fmt.Errorf("new rt failed: %w", err)
 	}
 
-	return &entities.Token{Access: access, RT: rt}, nil
+	return &entities.Token{Access: access, RT: rt, RTID: rtID, Family: params.Family}, nil
 }
 
-func newJwt(jwtExp time.Time, p TokenParams) (string, error) {
+func newJwt(jwtExp time.Time, p TokenParams, extra jwt.MapClaims) (string, error) {
 	token := jwt.New(jwt.SigningMethodHS256)
 	claims := token.Claims.(jwt.MapClaims)
 
-	claims["type"] = p.Type
+	for k, v := range extra {
+		claims[k] = v
+	}
+	claims["sub"] = p.ID
+	claims["role"] = string(p.Role)
 	claims["exp"] = jwtExp.UTC().Unix()
 
 	secret := []byte(p.Hs256Secret)
@@ -63,28 +85,50 @@ func newJwt(jwtExp time.Time, p TokenParams) (string, error) {
 	return tokenString, nil
 }
 
-func (svc AuthService) Verify(token string) error {
+// Verify checks the signature and expiration of the token and returns
+// the claims it was issued with.
+func (svc AuthService) Verify(token string) (entities.TokenClaims, error) {
 	tokenJwt, err := jwt.Parse(
 		token,
 		func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+			}
 			return []byte(svc.cfg.Hs256Secret), nil
 		},
 	)
 
 	if err != nil {
-		return fmt.Errorf("token parse failed: %w", err)
+		var validationErr *jwt.ValidationError
+		if errors.As(err, &validationErr) && validationErr.Errors&jwt.ValidationErrorExpired != 0 {
+			return entities.TokenClaims{}, ErrTokenExpired
+		}
+		return entities.TokenClaims{}, WrapError(CodeTokenInvalid, ErrTokenInvalid.Message, fmt.Errorf("token parse failed: %w", err))
 	}
 
 	claims, ok := tokenJwt.Claims.(jwt.MapClaims)
 	if !ok {
-		return fmt.Errorf("jwt map claims failed")
+		return entities.TokenClaims{}, WrapError(CodeTokenInvalid, ErrTokenInvalid.Message, fmt.Errorf("jwt map claims failed"))
 	}
 
 	if !claims.VerifyExpiresAt(time.Now().UTC().Unix(), true) {
-		return ErrTokenExpired
+		return entities.TokenClaims{}, ErrTokenExpired
 	}
-	if string(claims["type"].(string)) != Admin {
-		return ErrUnknownType
+
+	sub, _ := claims["sub"].(string)
+	role, _ := claims["role"].(string)
+	if !entities.Role(role).Valid() {
+		return entities.TokenClaims{}, ErrUnknownRole
 	}
-	return nil
+	typ, _ := claims["typ"].(string)
+	jti, _ := claims["jti"].(string)
+	fam, _ := claims["fam"].(string)
+
+	return entities.TokenClaims{
+		UserID: sub,
+		Role:   entities.Role(role),
+		Type:   typ,
+		ID:     jti,
+		Family: fam,
+	}, nil
 }
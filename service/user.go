@@ -2,9 +2,16 @@ package service
 
 import (
 	"context"
+	"errors"
 	"filmography/internal/entities"
 	"fmt"
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrUserExists   = NewError(CodeUserExists, "user already exists")
+	ErrUserNotFound = NewError(CodeUserNotFound, "user not found")
 )
 
 type UserService struct {
@@ -13,10 +20,12 @@ type UserService struct {
 
 type UserRepoInterface interface {
 	CreateUser(ctx context.Context, user entities.UserEntity) error
-	GetUsers(ctx context.Context) ([]entities.UserEntity, error)
+	GetUsers(ctx context.Context, query entities.UserQuery) ([]entities.UserEntity, int, error)
 	GetUser(ctx context.Context, id string) (entities.UserEntity, error)
+	GetUserByUsername(ctx context.Context, username string) (entities.UserEntity, error)
 	UpdateUser(ctx context.Context, id string, user entities.UserEntity) error
-	DeleteUser(ctx context.Context, id string) error
+	UpdateUserPassword(ctx context.Context, id string, passwordHash string) error
+	DeleteUser(ctx context.Context, id string, version int) error
 }
 
 func NewUserService(repo UserRepoInterface) UserService {
@@ -25,34 +34,139 @@ func NewUserService(repo UserRepoInterface) UserService {
 	}
 }
 
-func (svc UserService) CreateUser(ctx context.Context, user entities.UserEntity) error {
+// CreateUser stores a new user with the bcrypt hash of the given password.
+// An empty role defaults to entities.User.
+func (svc UserService) CreateUser(ctx context.Context, user entities.UserEntity, password string) error {
+	if user.Role == "" {
+		user.Role = entities.User
+	}
+	fields := append(validateUser(user), validatePassword(password)...)
+	if len(fields) > 0 {
+		return ValidationError(fields)
+	}
+
+	_, err := svc.repo.GetUserByUsername(ctx, user.Username)
+	if err == nil {
+		return ErrUserExists
+	}
+	if !errors.Is(err, entities.ErrUserNotFound) {
+		return fmt.Errorf("get user by username failed: %w", err)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("generate from password failed: %w", err)
+	}
+
 	user.ID = uuid.NewString()
-	err := svc.repo.CreateUser(ctx, user)
-	return err
+	user.PasswordHash = string(hash)
+	err = svc.repo.CreateUser(ctx, user)
+	if errors.Is(err, entities.ErrConflict) {
+		return WrapError(ErrUserExists.Code, ErrUserExists.Message, err)
+	}
+	return repoError(err, ErrUserNotFound)
 }
 
-func (svc UserService) GetUsers(ctx context.Context) ([]entities.UserEntity, error) {
-	users, err := svc.repo.GetUsers(ctx)
+// GetUsers returns a page of the users, only those with query.Role unless
+// it is empty.
+func (svc UserService) GetUsers(ctx context.Context, query entities.UserQuery) ([]entities.UserEntity, int, error) {
+	if query.Role != "" && !query.Role.Valid() {
+		return nil, 0, ValidationError([]FieldError{{Field: "role", Message: "must be one of admin, user"}})
+	}
+	users, total, err := svc.repo.GetUsers(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("get users failed: %w", err)
+		return nil, 0, fmt.Errorf("get users failed: %w", err)
 	}
-	return users, err
+	return users, total, err
 }
 
 func (svc UserService) GetUser(ctx context.Context, id string) (entities.UserEntity, error) {
 	user, err := svc.repo.GetUser(ctx, id)
 	if err != nil {
-		return entities.UserEntity{}, fmt.Errorf("get user failed: %w", err)
+		return entities.UserEntity{}, fmt.Errorf("get user failed: %w", repoError(err, ErrUserNotFound))
 	}
 	return user, err
 }
 
 func (svc UserService) UpdateUser(ctx context.Context, id string, user entities.UserEntity) error {
+	if fields := validateUser(user); len(fields) > 0 {
+		return ValidationError(fields)
+	}
 	err := svc.repo.UpdateUser(ctx, id, user)
-	return err
+	if errors.Is(err, entities.ErrConflict) {
+		return WrapError(ErrUserExists.Code, ErrUserExists.Message, err)
+	}
+	return repoError(err, ErrUserNotFound)
 }
 
-func (svc UserService) DeleteUser(ctx context.Context, id string) error {
-	err := svc.repo.DeleteUser(ctx, id)
-	return err
+// GetUserByUsername looks a user up by the login name.
+func (svc UserService) GetUserByUsername(ctx context.Context, username string) (entities.UserEntity, error) {
+	user, err := svc.repo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return entities.UserEntity{}, fmt.Errorf("get user by username failed: %w", repoError(err, ErrUserNotFound))
+	}
+	return user, nil
+}
+
+// SetRole changes the role of the user with the given username.
+func (svc UserService) SetRole(ctx context.Context, username string, role entities.Role) error {
+	user, err := svc.GetUserByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	user.Role = role
+	return svc.UpdateUser(ctx, user.ID, user)
+}
+
+// ResetPassword replaces the password of the user with the given username.
+func (svc UserService) ResetPassword(ctx context.Context, username string, password string) error {
+	if fields := validatePassword(password); len(fields) > 0 {
+		return ValidationError(fields)
+	}
+
+	user, err := svc.GetUserByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("generate from password failed: %w", err)
+	}
+
+	err = svc.repo.UpdateUserPassword(ctx, user.ID, string(hash))
+	return repoError(err, ErrUserNotFound)
+}
+
+// DeleteUser removes the user if it is still at version, see
+// entities.AnyVersion.
+func (svc UserService) DeleteUser(ctx context.Context, id string, version int) error {
+	err := svc.repo.DeleteUser(ctx, id, version)
+	return repoError(err, ErrUserNotFound)
+}
+
+func validateUser(user entities.UserEntity) []FieldError {
+	fields := make([]FieldError, 0)
+	if len(user.Username) < 3 || len(user.Username) > 255 {
+		fields = append(fields, FieldError{Field: "username", Message: "must be between 3 and 255 characters long"})
+	}
+	if !user.Role.Valid() {
+		fields = append(fields, FieldError{Field: "role", Message: "must be one of admin, user"})
+	}
+	return fields
+}
+
+// maxPasswordLength is the number of bytes of a password bcrypt hashes,
+// GenerateFromPassword refuses longer ones.
+const maxPasswordLength = 72
+
+func validatePassword(password string) []FieldError {
+	if len(password) < 8 {
+		return []FieldError{{Field: "password", Message: "must be at least 8 characters long"}}
+	}
+	if len(password) > maxPasswordLength {
+		return []FieldError{{Field: "password", Message: "must be at most 72 bytes long"}}
+	}
+	return nil
 }
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"filmography/internal/entities"
+	"filmography/internal/repository/memory"
+	"strings"
+	"testing"
+)
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		name, password string
+		wantErr        bool
+	}{
+		{"too short", "secret", true},
+		{"shortest", "password", false},
+		{"longest", strings.Repeat("p", 72), false},
+		{"too long for bcrypt", strings.Repeat("p", 73), true},
+		{"72 characters in more bytes", strings.Repeat("п", 72), true},
+	}
+
+	for _, tt := range tests {
+		fields := validatePassword(tt.password)
+		if (len(fields) > 0) != tt.wantErr {
+			t.Errorf("%s: validatePassword() = %v, want error %v", tt.name, fields, tt.wantErr)
+		}
+		for _, field := range fields {
+			if field.Field != "password" {
+				t.Errorf("%s: validatePassword() field = %q, want password", tt.name, field.Field)
+			}
+		}
+	}
+}
+
+func TestPasswordTooLong(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.New()
+	svc := NewUserService(repo)
+	long := strings.Repeat("p", 73)
+
+	err := svc.CreateUser(ctx, entities.UserEntity{Username: "alice"}, long)
+	var svcErr *Error
+	if !errors.As(err, &svcErr) || svcErr.Code != CodeValidationFailed {
+		t.Errorf("CreateUser() error = %v, want %s", err, CodeValidationFailed)
+	}
+
+	if err := svc.CreateUser(ctx, entities.UserEntity{Username: "alice"}, "password"); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	err = svc.ResetPassword(ctx, "alice", long)
+	if !errors.As(err, &svcErr) || svcErr.Code != CodeValidationFailed {
+		t.Errorf("ResetPassword() error = %v, want %s", err, CodeValidationFailed)
+	}
+}
+
+func TestGetUsersRole(t *testing.T) {
+	ctx := context.Background()
+	svc := NewUserService(memory.New())
+	if err := svc.CreateUser(ctx, entities.UserEntity{Username: "alice"}, "password"); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	tests := []struct {
+		role      entities.Role
+		wantTotal int
+		wantErr   bool
+	}{
+		{"", 1, false},
+		{entities.User, 1, false},
+		{entities.Admin, 0, false},
+		{"bogus", 0, true},
+	}
+
+	for _, tt := range tests {
+		_, total, err := svc.GetUsers(ctx, entities.UserQuery{Role: tt.role})
+		var svcErr *Error
+		if tt.wantErr {
+			if !errors.As(err, &svcErr) || svcErr.Code != CodeValidationFailed {
+				t.Errorf("GetUsers(role %q) error = %v, want %s", tt.role, err, CodeValidationFailed)
+			}
+			continue
+		}
+		if err != nil || total != tt.wantTotal {
+			t.Errorf("GetUsers(role %q) = %d users, %v, want %d", tt.role, total, err, tt.wantTotal)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"filmography/internal/entities"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// exportPageSize is the number of entries read at a time while the lists
+// of a user are exported.
+const exportPageSize = 100
+
+var (
+	ErrNotOnWatchlist  = NewError(CodeNotOnWatchlist, "film is not on the watchlist")
+	ErrHistoryNotFound = NewError(CodeHistoryNotFound, "history entry not found")
+)
+
+// WatchService keeps the watchlist and the watch history of users. Every
+// method works on the lists of the given user only.
+type WatchService struct {
+	repo WatchRepoInterface
+}
+
+type WatchRepoInterface interface {
+	AddToWatchlist(ctx context.Context, entry entities.WatchlistEntry) error
+	GetWatchlist(ctx context.Context, query entities.WatchQuery) ([]entities.WatchlistEntry, int, error)
+	RemoveFromWatchlist(ctx context.Context, userID string, filmID string) error
+	CreateHistoryEntry(ctx context.Context, entry entities.HistoryEntry) error
+	GetHistory(ctx context.Context, query entities.WatchQuery) ([]entities.HistoryEntry, int, error)
+	GetHistoryEntry(ctx context.Context, userID string, id string) (entities.HistoryEntry, error)
+	UpdateHistoryEntry(ctx context.Context, entry entities.HistoryEntry) error
+	DeleteHistoryEntry(ctx context.Context, userID string, id string) error
+}
+
+func NewWatchService(repo WatchRepoInterface) WatchService {
+	return WatchService{
+		repo: repo,
+	}
+}
+
+// AddToWatchlist puts the film on the watchlist of the user, or moves it
+// there if it is already on it. Position 0 appends a new film and leaves
+// one already on the list in place, positions past the end append.
+func (svc WatchService) AddToWatchlist(ctx context.Context, userID string, filmID string, position int) (entities.WatchlistEntry, error) {
+	if position < 0 {
+		return entities.WatchlistEntry{}, ValidationError([]FieldError{{Field: "position", Message: "must be a positive integer"}})
+	}
+
+	err := svc.repo.AddToWatchlist(ctx, entities.WatchlistEntry{
+		UserID:   userID,
+		FilmID:   filmID,
+		Position: position,
+		AddedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return entities.WatchlistEntry{}, fmt.Errorf("add to watchlist failed: %w", repoError(err, ErrFilmNotFound))
+	}
+
+	entries, _, err := svc.repo.GetWatchlist(ctx, entities.WatchQuery{ListQuery: entities.ListQuery{Limit: 1}, UserID: userID, FilmID: filmID})
+	if err != nil {
+		return entities.WatchlistEntry{}, fmt.Errorf("get watchlist failed: %w", err)
+	}
+	if len(entries) == 0 {
+		return entities.WatchlistEntry{}, ErrNotOnWatchlist
+	}
+	return entries[0], nil
+}
+
+func (svc WatchService) GetWatchlist(ctx context.Context, query entities.WatchQuery) ([]entities.WatchlistEntry, int, error) {
+	entries, total, err := svc.repo.GetWatchlist(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get watchlist failed: %w", err)
+	}
+	return entries, total, nil
+}
+
+func (svc WatchService) RemoveFromWatchlist(ctx context.Context, userID string, filmID string) error {
+	err := svc.repo.RemoveFromWatchlist(ctx, userID, filmID)
+	if err != nil {
+		return fmt.Errorf("remove from watchlist failed: %w", repoError(err, ErrNotOnWatchlist))
+	}
+	return nil
+}
+
+// AddHistoryEntry records that the user has watched the film, on the day of
+// entry.WatchedAt or today if it is left out. Watching a film takes it off
+// the watchlist.
+func (svc WatchService) AddHistoryEntry(ctx context.Context, entry entities.HistoryEntry) (entities.HistoryEntry, error) {
+	if entry.WatchedAt.IsZero() {
+		entry.WatchedAt = time.Now()
+	}
+	entry.ID = uuid.NewString()
+	entry.WatchedAt = dateOf(entry.WatchedAt)
+	if fields := validateHistoryEntry(entry); len(fields) > 0 {
+		return entities.HistoryEntry{}, ValidationError(fields)
+	}
+
+	err := svc.repo.CreateHistoryEntry(ctx, entry)
+	if err != nil {
+		return entities.HistoryEntry{}, fmt.Errorf("create history entry failed: %w", repoError(err, ErrFilmNotFound))
+	}
+	return svc.GetHistoryEntry(ctx, entry.UserID, entry.ID)
+}
+
+func (svc WatchService) GetHistory(ctx context.Context, query entities.WatchQuery) ([]entities.HistoryEntry, int, error) {
+	entries, total, err := svc.repo.GetHistory(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get history failed: %w", err)
+	}
+	return entries, total, nil
+}
+
+func (svc WatchService) GetHistoryEntry(ctx context.Context, userID string, id string) (entities.HistoryEntry, error) {
+	entry, err := svc.repo.GetHistoryEntry(ctx, userID, id)
+	if err != nil {
+		return entities.HistoryEntry{}, fmt.Errorf("get history entry failed: %w", repoError(err, ErrHistoryNotFound))
+	}
+	return entry, nil
+}
+
+// UpdateHistoryEntry changes the date and the rating of a viewing of the
+// user, the film stays the same.
+func (svc WatchService) UpdateHistoryEntry(ctx context.Context, entry entities.HistoryEntry) (entities.HistoryEntry, error) {
+	entry.WatchedAt = dateOf(entry.WatchedAt)
+	fields := validateHistoryEntry(entry)
+	if entry.WatchedAt.IsZero() {
+		fields = append(fields, FieldError{Field: "watched_at", Message: "is required"})
+	}
+	if len(fields) > 0 {
+		return entities.HistoryEntry{}, ValidationError(fields)
+	}
+
+	err := svc.repo.UpdateHistoryEntry(ctx, entry)
+	if err != nil {
+		return entities.HistoryEntry{}, fmt.Errorf("update history entry failed: %w", repoError(err, ErrHistoryNotFound))
+	}
+	return svc.GetHistoryEntry(ctx, entry.UserID, entry.ID)
+}
+
+func (svc WatchService) DeleteHistoryEntry(ctx context.Context, userID string, id string) error {
+	err := svc.repo.DeleteHistoryEntry(ctx, userID, id)
+	if err != nil {
+		return fmt.Errorf("delete history entry failed: %w", repoError(err, ErrHistoryNotFound))
+	}
+	return nil
+}
+
+// ExportWatchlist writes the watchlist of the user in order, the format
+// is checked before anything is written.
+func (svc WatchService) ExportWatchlist(ctx context.Context, w io.Writer, userID string, format FileFormat) error {
+	writer, err := newWatchRecordWriter(w, format, exportWatchlist)
+	if err != nil {
+		return err
+	}
+
+	query := entities.WatchQuery{
+		ListQuery: entities.ListQuery{Limit: exportPageSize, Sort: []entities.SortField{{Field: "position"}}},
+		UserID:    userID,
+	}
+	for {
+		entries, total, err := svc.repo.GetWatchlist(ctx, query)
+		if err != nil {
+			return fmt.Errorf("get watchlist failed: %w", err)
+		}
+		for _, entry := range entries {
+			if err := writer.watchlistEntry(newWatchlistRecord(entry)); err != nil {
+				return err
+			}
+		}
+		query.Offset += len(entries)
+		if len(entries) == 0 || query.Offset >= total {
+			break
+		}
+	}
+	return writer.close()
+}
+
+// ExportHistory writes the watch history of the user, oldest first.
+func (svc WatchService) ExportHistory(ctx context.Context, w io.Writer, userID string, format FileFormat) error {
+	writer, err := newWatchRecordWriter(w, format, exportHistory)
+	if err != nil {
+		return err
+	}
+
+	query := entities.WatchQuery{
+		ListQuery: entities.ListQuery{Limit: exportPageSize, Sort: []entities.SortField{{Field: "watched_at"}}},
+		UserID:    userID,
+	}
+	for {
+		entries, total, err := svc.repo.GetHistory(ctx, query)
+		if err != nil {
+			return fmt.Errorf("get history failed: %w", err)
+		}
+		for _, entry := range entries {
+			if err := writer.historyEntry(newHistoryRecord(entry)); err != nil {
+				return err
+			}
+		}
+		query.Offset += len(entries)
+		if len(entries) == 0 || query.Offset >= total {
+			break
+		}
+	}
+	return writer.close()
+}
+
+func newWatchRecordWriter(w io.Writer, format FileFormat, entity ExportEntity) (recordWriter, error) {
+	err := ValidateFormat(&format)
+	if err != nil {
+		return nil, err
+	}
+	return newRecordWriter(w, ExportOptions{Format: format, Entity: entity})
+}
+
+func validateHistoryEntry(entry entities.HistoryEntry) []FieldError {
+	fields := make([]FieldError, 0)
+	if entry.WatchedAt.After(time.Now()) {
+		fields = append(fields, FieldError{Field: "watched_at", Message: "must not be in the future"})
+	}
+	if entry.Rating != nil && (*entry.Rating < 0 || *entry.Rating > 10) {
+		fields = append(fields, FieldError{Field: "rating", Message: "must be between 0 and 10"})
+	}
+	return fields
+}